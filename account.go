@@ -0,0 +1,112 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"unicode"
+
+	"github.com/m4tthewde/blunt/components"
+	"github.com/m4tthewde/blunt/users"
+)
+
+// accountErrors maps the errors of the user store to message keys.
+var accountErrors = map[error]string{
+	users.ErrInvalidName:        "account.error.invalidName",
+	users.ErrWeakPassword:       "account.error.weakPassword",
+	users.ErrNameTaken:          "account.error.nameTaken",
+	users.ErrInvalidCredentials: "account.error.invalidCredentials",
+}
+
+func loginPage(w http.ResponseWriter, r *http.Request) {
+	render(w, r, components.Login(components.AccountForm{
+		Next:         localPath(r.URL.Query().Get("next")),
+		Registration: config.Auth.Registration,
+	}))
+}
+
+func login(w http.ResponseWriter, r *http.Request) {
+	form := components.AccountForm{
+		Name:         strings.TrimSpace(r.PostFormValue("name")),
+		Next:         localPath(r.PostFormValue("next")),
+		Registration: config.Auth.Registration,
+	}
+
+	user, err := accounts.Authenticate(r.Context(), form.Name, r.PostFormValue("password"))
+	if errors.Is(err, users.ErrInvalidCredentials) {
+		form.Error = accountErrors[err]
+		w.WriteHeader(http.StatusUnauthorized)
+		render(w, r, components.Login(form))
+		return
+	}
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	err = auth.Login(w, r, user)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	http.Redirect(w, r, form.Next, http.StatusSeeOther)
+}
+
+func registerPage(w http.ResponseWriter, r *http.Request) {
+	render(w, r, components.Register(components.AccountForm{}))
+}
+
+func register(w http.ResponseWriter, r *http.Request) {
+	form := components.AccountForm{Name: strings.TrimSpace(r.PostFormValue("name"))}
+
+	password := r.PostFormValue("password")
+	if password != r.PostFormValue("password_repeat") {
+		form.Error = "account.error.passwordMismatch"
+		w.WriteHeader(http.StatusBadRequest)
+		render(w, r, components.Register(form))
+		return
+	}
+
+	user, err := accounts.Register(r.Context(), form.Name, password)
+	if key, ok := accountErrors[err]; ok {
+		form.Error = key
+		w.WriteHeader(http.StatusBadRequest)
+		render(w, r, components.Register(form))
+		return
+	}
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	err = auth.Login(w, r, user)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+func logout(w http.ResponseWriter, r *http.Request) {
+	err := auth.Logout(w, r)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+// localPath only lets redirects after a login lead to pages of the explorer.
+// Browsers read backslashes as slashes and drop tabs and newlines, so paths
+// with either could still name another host.
+func localPath(path string) string {
+	if !strings.HasPrefix(path, "/") || strings.HasPrefix(path, "//") ||
+		strings.Contains(path, "\\") || strings.ContainsFunc(path, unicode.IsControl) {
+		return "/"
+	}
+
+	return path
+}
@@ -0,0 +1,29 @@
+package main
+
+import "testing"
+
+func TestLocalPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/me/watchlist", "/me/watchlist"},
+		{"/movie/949?tab=cast#crew", "/movie/949?tab=cast#crew"},
+		{"", "/"},
+		{"me/watchlist", "/"},
+		{"https://evil.example", "/"},
+		{"//evil.example", "/"},
+		{"/\\evil.example", "/"},
+		{"/me\\..\\..\\evil", "/"},
+		{"/\t/evil.example", "/"},
+		{"/\n/evil.example", "/"},
+		{"/\x00", "/"},
+	}
+
+	for _, test := range tests {
+		got := localPath(test.path)
+		if got != test.want {
+			t.Errorf("localPath(%q) = %q, want %q", test.path, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,358 @@
+// Package api serves a versioned JSON API under /api/v1 for scripts and other
+// frontends. Its response types are stable and don't follow TMDB's schema.
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/m4tthewde/blunt/catalog"
+	"github.com/m4tthewde/blunt/graph"
+	"github.com/m4tthewde/blunt/metrics"
+	"github.com/m4tthewde/blunt/ratelimit"
+	"github.com/m4tthewde/blunt/tmdb"
+)
+
+const Prefix = "/api/v1"
+
+// defaultPathDepth is the depth of path searches that don't ask for one.
+// Every level multiplies the lookups by fanout squared.
+const defaultPathDepth = 2
+
+// Limits bound the graph endpoints, just like the graph page and CLI.
+// MaxLookups caps the TMDB lookups of a single search.
+type Limits struct {
+	MaxDepth   int
+	MaxFanOut  int
+	MaxLookups int
+}
+
+type API struct {
+	client  *tmdb.Client
+	limits  Limits
+	limiter *ratelimit.Limiter
+	mux     *http.ServeMux
+}
+
+// statusError is returned by handlers to answer with a specific status.
+type statusError struct {
+	status     int
+	code       string
+	err        error
+	retryAfter int
+}
+
+func (e *statusError) Error() string {
+	return e.err.Error()
+}
+
+func badRequest(format string, args ...any) error {
+	return &statusError{status: http.StatusBadRequest, code: "bad_request", err: fmt.Errorf(format, args...)}
+}
+
+type handlerFunc func(ctx context.Context, r *http.Request) (any, error)
+
+// New creates the API. Requests are rate limited by limiter, which may be
+// nil.
+func New(client *tmdb.Client, limits Limits, limiter *ratelimit.Limiter) *API {
+	a := API{
+		client:  client,
+		limits:  limits,
+		limiter: limiter,
+		mux:     http.NewServeMux(),
+	}
+
+	for _, route := range a.routes() {
+		a.mux.Handle(route.Method+" "+Prefix+route.Path, a.handle(a.limit(route)))
+	}
+
+	a.mux.HandleFunc("GET "+Prefix+"/openapi.json", a.openApi)
+
+	a.mux.Handle(Prefix+"/", a.handle(func(ctx context.Context, r *http.Request) (any, error) {
+		return nil, &statusError{status: http.StatusNotFound, code: "not_found", err: errors.New("no such endpoint")}
+	}))
+
+	return &a
+}
+
+func (a *API) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	a.mux.ServeHTTP(w, r)
+}
+
+func (a *API) handle(handler handlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response, err := handler(r.Context(), r)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+
+		writeJson(w, http.StatusOK, response)
+	})
+}
+
+// limit rejects requests once the client exhausted the route's budget.
+func (a *API) limit(route route) handlerFunc {
+	return func(ctx context.Context, r *http.Request) (any, error) {
+		delay, ok := a.limiter.Allow(r, route.class, 1)
+		if !ok {
+			return nil, &statusError{
+				status:     http.StatusTooManyRequests,
+				code:       "rate_limited",
+				err:        errors.New("too many requests, slow down"),
+				retryAfter: ratelimit.RetryAfter(delay),
+			}
+		}
+
+		return route.handler(ctx, r)
+	}
+}
+
+// checkLookups refuses searches that could need more TMDB lookups than a
+// request may make, before they make any.
+func (a *API) checkLookups(estimate int) error {
+	if estimate > a.limits.MaxLookups {
+		return &statusError{
+			status: http.StatusUnprocessableEntity,
+			code:   "too_many_lookups",
+			err:    fmt.Errorf("search may need more than %d TMDB lookups, lower depth or fanout", a.limits.MaxLookups),
+		}
+	}
+
+	return nil
+}
+
+// charge bills the TMDB lookups of a graph search. Its cost is only known
+// once it ran, the request itself already paid for one lookup.
+func (a *API) charge(r *http.Request, lookups *graph.Lookups) {
+	a.limiter.Charge(r, ratelimit.Graph, lookups.Used()-1)
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	status := http.StatusBadGateway
+	code := "upstream_error"
+	message := "the movie database could not be reached"
+
+	var statusErr *statusError
+
+	switch {
+	case errors.As(err, &statusErr):
+		status = statusErr.status
+		code = statusErr.code
+		message = statusErr.err.Error()
+
+		if statusErr.retryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(statusErr.retryAfter))
+		}
+	case errors.Is(err, tmdb.ErrNotFound):
+		status = http.StatusNotFound
+		code = "not_found"
+		message = "the requested resource does not exist"
+	case errors.As(err, new(*graph.ErrTooManyLookups)):
+		status = http.StatusUnprocessableEntity
+		code = "too_many_lookups"
+		message = err.Error() + ", lower depth or fanout"
+	case errors.Is(err, graph.ErrNoPath):
+		status = http.StatusNotFound
+		code = "no_path"
+		message = err.Error()
+	case errors.Is(err, context.Canceled):
+		return
+	default:
+		slog.ErrorContext(r.Context(), "api request failed", "method", r.Method, "path", r.URL.Path, "error", err)
+	}
+
+	writeJson(w, status, Error{Error: ErrorBody{Code: code, Message: message}})
+}
+
+func writeJson(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	err := json.NewEncoder(w).Encode(v)
+	if err != nil {
+		slog.Debug("api: writing response failed", "error", err)
+	}
+}
+
+func pathId(r *http.Request) (int64, error) {
+	return parseId("id", r.PathValue("id"))
+}
+
+func parseId(name string, value string) (int64, error) {
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || id < 1 {
+		return 0, badRequest("%s must be a positive integer", name)
+	}
+
+	return id, nil
+}
+
+// queryInt reads an optional integer parameter within [1, max].
+func queryInt(r *http.Request, name string, fallback int, max int) (int, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return fallback, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 || n > max {
+		return 0, badRequest("%s must be between 1 and %d", name, max)
+	}
+
+	return n, nil
+}
+
+func (a *API) search(ctx context.Context, r *http.Request) (any, error) {
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+	if query == "" {
+		return nil, badRequest("q is required")
+	}
+
+	results, err := catalog.Search(ctx, a.client, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return SearchResponse{Query: query, Results: NewSearchResults(results)}, nil
+}
+
+func (a *API) movie(ctx context.Context, r *http.Request) (any, error) {
+	id, err := pathId(r)
+	if err != nil {
+		return nil, err
+	}
+
+	movie, err := a.client.MovieDetails(ctx, strconv.FormatInt(id, 10))
+	if err != nil {
+		return nil, err
+	}
+
+	return NewMovie(movie), nil
+}
+
+func (a *API) movieCredits(ctx context.Context, r *http.Request) (any, error) {
+	id, err := pathId(r)
+	if err != nil {
+		return nil, err
+	}
+
+	credits, err := a.client.Credits(ctx, strconv.FormatInt(id, 10))
+	if err != nil {
+		return nil, err
+	}
+
+	return NewMovieCredits(credits), nil
+}
+
+func (a *API) person(ctx context.Context, r *http.Request) (any, error) {
+	id, err := pathId(r)
+	if err != nil {
+		return nil, err
+	}
+
+	person, err := a.client.People(ctx, strconv.FormatInt(id, 10))
+	if err != nil {
+		return nil, err
+	}
+
+	return NewPerson(person), nil
+}
+
+func (a *API) personCredits(ctx context.Context, r *http.Request) (any, error) {
+	id, err := pathId(r)
+	if err != nil {
+		return nil, err
+	}
+
+	credits, err := a.client.PeopleCredits(ctx, strconv.FormatInt(id, 10))
+	if err != nil {
+		return nil, err
+	}
+
+	return NewPersonCredits(credits), nil
+}
+
+func (a *API) graph(ctx context.Context, r *http.Request) (any, error) {
+	defer metrics.TrackGraph()()
+
+	nodeType := r.PathValue("type")
+	if nodeType != graph.Movie && nodeType != graph.Person {
+		return nil, badRequest("type must be %q or %q", graph.Movie, graph.Person)
+	}
+
+	id, err := pathId(r)
+	if err != nil {
+		return nil, err
+	}
+
+	depth, err := queryInt(r, "depth", 1, a.limits.MaxDepth)
+	if err != nil {
+		return nil, err
+	}
+
+	fanOut, err := queryInt(r, "fanout", a.limits.MaxFanOut, a.limits.MaxFanOut)
+	if err != nil {
+		return nil, err
+	}
+
+	err = a.checkLookups(graph.NeighbourhoodLookups(depth, fanOut))
+	if err != nil {
+		return nil, err
+	}
+
+	lookups := graph.Lookups{Limit: a.limits.MaxLookups}
+	defer a.charge(r, &lookups)
+
+	g, err := graph.Neighbourhood(ctx, a.client, &lookups, nodeType, id, depth, fanOut)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewGraph(g), nil
+}
+
+func (a *API) path(ctx context.Context, r *http.Request) (any, error) {
+	defer metrics.TrackGraph()()
+
+	from, err := parseId("from", r.URL.Query().Get("from"))
+	if err != nil {
+		return nil, err
+	}
+
+	to, err := parseId("to", r.URL.Query().Get("to"))
+	if err != nil {
+		return nil, err
+	}
+
+	depth, err := queryInt(r, "depth", min(defaultPathDepth, a.limits.MaxDepth), a.limits.MaxDepth)
+	if err != nil {
+		return nil, err
+	}
+
+	fanOut, err := queryInt(r, "fanout", a.limits.MaxFanOut, a.limits.MaxFanOut)
+	if err != nil {
+		return nil, err
+	}
+
+	err = a.checkLookups(graph.PathLookups(depth, fanOut))
+	if err != nil {
+		return nil, err
+	}
+
+	lookups := graph.Lookups{Limit: a.limits.MaxLookups}
+	defer a.charge(r, &lookups)
+
+	nodes, err := graph.Path(ctx, a.client, &lookups, from, to, depth, fanOut)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewPath(from, to, nodes), nil
+}
@@ -0,0 +1,167 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/m4tthewde/blunt/ratelimit"
+	"github.com/m4tthewde/blunt/tmdb"
+)
+
+// fakeChain serves a chain of people 1 to 9 where movie 100+k stars person k
+// and person k+1, so the shortest path from 1 to k passes k-1 movies.
+func fakeChain(t *testing.T) *tmdb.Client {
+	t.Helper()
+
+	const people = 9
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+		id, _ := strconv.Atoi(parts[1])
+
+		var response any
+
+		switch {
+		case len(parts) == 2 && parts[0] == "person" && id >= 1 && id <= people:
+			response = tmdb.PeopleResponse{Id: int64(id), Name: fmt.Sprintf("Person %d", id)}
+		case len(parts) == 3 && parts[0] == "person" && parts[2] == "movie_credits":
+			cast := make([]tmdb.PeopleCredit, 0)
+			for _, movie := range []int{100 + id - 1, 100 + id} {
+				if movie > 100 && movie < 100+people {
+					cast = append(cast, tmdb.PeopleCredit{Id: int64(movie), Title: fmt.Sprintf("Movie %d", movie)})
+				}
+			}
+
+			response = tmdb.PeopleCreditsResponse{Cast: cast}
+		case len(parts) == 3 && parts[0] == "movie" && parts[2] == "credits":
+			response = tmdb.MovieCreditsResponse{Id: int64(id), Cast: []tmdb.MovieCastMember{
+				{Id: int64(id - 100), Name: fmt.Sprintf("Person %d", id-100)},
+				{Id: int64(id - 99), Name: fmt.Sprintf("Person %d", id-99)},
+			}}
+		default:
+			http.NotFound(w, r)
+			return
+		}
+
+		json.NewEncoder(w).Encode(response)
+	}))
+	t.Cleanup(server.Close)
+
+	return tmdb.NewClient(tmdb.Options{BaseUrl: server.URL})
+}
+
+func get(a *API, path string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	a.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, Prefix+path, nil))
+
+	return rec
+}
+
+func TestPathChargesLookups(t *testing.T) {
+	limiter := ratelimit.New(ratelimit.Options{Budgets: map[ratelimit.Class]ratelimit.Budget{
+		ratelimit.Graph: {PerMinute: 1, Burst: 20},
+	}})
+	a := New(fakeChain(t), Limits{MaxDepth: 4, MaxFanOut: 2, MaxLookups: 200}, limiter)
+
+	rec := get(a, "/path?from=1&to=4&depth=3")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+
+	var path Path
+
+	err := json.NewDecoder(rec.Body).Decode(&path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(path.Steps) != 7 {
+		t.Errorf("%d steps, want 7", len(path.Steps))
+	}
+
+	// both people and the target's credits, then the credits of people 1 to
+	// 3 and movies 101 and 102
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	_, ok := limiter.Allow(r, ratelimit.Graph, 20-8)
+	if !ok {
+		t.Fatal("charged more than 8 lookups")
+	}
+
+	_, ok = limiter.Allow(r, ratelimit.Graph, 1)
+	if ok {
+		t.Error("charged less than 8 lookups")
+	}
+}
+
+func TestGraphLookupLimit(t *testing.T) {
+	a := New(fakeChain(t), Limits{MaxDepth: 4, MaxFanOut: 2, MaxLookups: 4}, nil)
+
+	rec := get(a, "/graph/person/1?depth=4")
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+
+	var response Error
+
+	err := json.NewDecoder(rec.Body).Decode(&response)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if response.Error.Code != "too_many_lookups" {
+		t.Errorf("code %q", response.Error.Code)
+	}
+
+	rec = get(a, "/graph/person/1?depth=2")
+	if rec.Code != http.StatusOK {
+		t.Errorf("status %d: %s", rec.Code, rec.Body)
+	}
+}
+
+func TestPathDepth(t *testing.T) {
+	a := New(fakeChain(t), Limits{MaxDepth: 4, MaxFanOut: 2, MaxLookups: 200}, nil)
+
+	tests := []struct {
+		query  string
+		status int
+	}{
+		// two movies by default, person 4 is three away
+		{"from=1&to=3", http.StatusOK},
+		{"from=1&to=4", http.StatusNotFound},
+		{"from=1&to=4&depth=3", http.StatusOK},
+		{"from=1&to=4&depth=5", http.StatusBadRequest},
+		// 3 + 1 + 2 + 4 + 8 + 16 + 32 + 64 lookups at most
+		{"from=1&to=5&depth=4", http.StatusOK},
+	}
+
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			rec := get(a, "/path?"+test.query)
+			if rec.Code != test.status {
+				t.Errorf("status %d, want %d: %s", rec.Code, test.status, rec.Body)
+			}
+		})
+	}
+}
+
+func TestPathLookupEstimate(t *testing.T) {
+	a := New(fakeChain(t), Limits{MaxDepth: 4, MaxFanOut: 5, MaxLookups: 200}, nil)
+
+	// depth 3 may need 3 + 1 + 5 + 25 + 125 + 625 lookups, though the path
+	// is found after 8
+	rec := get(a, "/path?from=1&to=4&depth=3")
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+
+	rec = get(a, "/path?from=1&to=4&depth=3&fanout=3")
+	if rec.Code != http.StatusOK {
+		t.Errorf("status %d: %s", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,264 @@
+package api
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/m4tthewde/blunt/ratelimit"
+)
+
+// route describes an endpoint once, for both the mux and the OpenAPI
+// document, so the two can't drift apart.
+type route struct {
+	Method       string
+	Path         string
+	Summary      string
+	Params       []param
+	Response     any
+	handler      handlerFunc
+	notFoundable bool
+	// class is the rate limit budget the route draws from, a request costs
+	// one unit of it. Handlers charge for further work they cause.
+	class ratelimit.Class
+}
+
+type param struct {
+	Name        string
+	In          string
+	Type        string
+	Required    bool
+	Description string
+}
+
+func (a *API) routes() []route {
+	id := func(description string) param {
+		return param{Name: "id", In: "path", Type: "integer", Required: true, Description: description}
+	}
+	depth := param{Name: "depth", In: "query", Type: "integer", Description: "levels to expand, 1 by default, bounded by the server's graph.max_depth"}
+	pathDepth := param{Name: "depth", In: "query", Type: "integer", Description: "movies in the path, 2 by default, bounded by the server's graph.max_depth"}
+	fanOut := param{Name: "fanout", In: "query", Type: "integer", Description: "credits followed per node, bounded by the server's graph.fanout"}
+
+	return []route{
+		{
+			Method:   "GET",
+			Path:     "/search",
+			Summary:  "Search movies and people, most popular first",
+			Params:   []param{{Name: "q", In: "query", Type: "string", Required: true, Description: "search text"}},
+			Response: SearchResponse{},
+			handler:  a.search,
+			class:    ratelimit.Search,
+		},
+		{
+			Method:       "GET",
+			Path:         "/movies/{id}",
+			Summary:      "Movie details",
+			Params:       []param{id("TMDB movie id")},
+			Response:     Movie{},
+			handler:      a.movie,
+			notFoundable: true,
+			class:        ratelimit.Detail,
+		},
+		{
+			Method:       "GET",
+			Path:         "/movies/{id}/credits",
+			Summary:      "Cast of a movie in billing order",
+			Params:       []param{id("TMDB movie id")},
+			Response:     MovieCredits{},
+			handler:      a.movieCredits,
+			notFoundable: true,
+			class:        ratelimit.Detail,
+		},
+		{
+			Method:       "GET",
+			Path:         "/people/{id}",
+			Summary:      "Person details",
+			Params:       []param{id("TMDB person id")},
+			Response:     Person{},
+			handler:      a.person,
+			notFoundable: true,
+			class:        ratelimit.Detail,
+		},
+		{
+			Method:       "GET",
+			Path:         "/people/{id}/credits",
+			Summary:      "Released movies of a person, newest first",
+			Params:       []param{id("TMDB person id")},
+			Response:     PersonCredits{},
+			handler:      a.personCredits,
+			notFoundable: true,
+			class:        ratelimit.Detail,
+		},
+		{
+			Method:  "GET",
+			Path:    "/graph/{type}/{id}",
+			Summary: "Neighbourhood graph of a movie or person",
+			Params: []param{
+				{Name: "type", In: "path", Type: "string", Required: true, Description: "movie or person"},
+				id("TMDB movie or person id"),
+				depth,
+				fanOut,
+			},
+			Response:     Graph{},
+			handler:      a.graph,
+			notFoundable: true,
+			class:        ratelimit.Graph,
+		},
+		{
+			Method:  "GET",
+			Path:    "/path",
+			Summary: "Shortest connection between two people through shared movies",
+			Params: []param{
+				{Name: "from", In: "query", Type: "integer", Required: true, Description: "TMDB person id"},
+				{Name: "to", In: "query", Type: "integer", Required: true, Description: "TMDB person id"},
+				pathDepth,
+				fanOut,
+			},
+			Response:     Path{},
+			handler:      a.path,
+			notFoundable: true,
+			class:        ratelimit.Graph,
+		},
+	}
+}
+
+func (a *API) openApi(w http.ResponseWriter, r *http.Request) {
+	writeJson(w, http.StatusOK, a.document())
+}
+
+// document builds an OpenAPI 3 description of the routes. Schemas are derived
+// from the response types, where fields tagged omitempty are optional.
+func (a *API) document() map[string]any {
+	schemas := map[string]any{}
+	paths := map[string]any{}
+
+	errorRef := schemaRef(reflect.TypeOf(Error{}), schemas)
+	errorResponse := func(description string) map[string]any {
+		return map[string]any{
+			"description": description,
+			"content":     map[string]any{"application/json": map[string]any{"schema": errorRef}},
+		}
+	}
+
+	for _, route := range a.routes() {
+		params := make([]map[string]any, 0)
+		for _, p := range route.Params {
+			params = append(params, map[string]any{
+				"name":        p.Name,
+				"in":          p.In,
+				"required":    p.Required || p.In == "path",
+				"description": p.Description,
+				"schema":      map[string]any{"type": p.Type},
+			})
+		}
+
+		responses := map[string]any{
+			"200": map[string]any{
+				"description": "OK",
+				"content": map[string]any{"application/json": map[string]any{
+					"schema": schemaRef(reflect.TypeOf(route.Response), schemas),
+				}},
+			},
+			"400": errorResponse("invalid parameters"),
+			"429": errorResponse("the client exhausted its rate limit, see the Retry-After header"),
+			"502": errorResponse("TMDB failed or could not be reached"),
+		}
+		if route.notFoundable {
+			responses["404"] = errorResponse("not found")
+		}
+		if route.class == ratelimit.Graph {
+			responses["422"] = errorResponse("the search may need more TMDB lookups than the server's graph.max_lookups")
+		}
+
+		item, ok := paths[Prefix+route.Path].(map[string]any)
+		if !ok {
+			item = map[string]any{}
+			paths[Prefix+route.Path] = item
+		}
+
+		item[strings.ToLower(route.Method)] = map[string]any{
+			"summary":    route.Summary,
+			"parameters": params,
+			"responses":  responses,
+		}
+	}
+
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":       "blunt",
+			"version":     "1",
+			"description": "Movies, people and their connections, backed by TMDB. Image URLs may be relative to this server.",
+		},
+		"paths":      paths,
+		"components": map[string]any{"schemas": schemas},
+	}
+}
+
+// schemaRef returns the schema of t, registering named structs as shared
+// components.
+func schemaRef(t reflect.Type, schemas map[string]any) map[string]any {
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		return map[string]any{"type": "integer", "format": "int64"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice:
+		return map[string]any{"type": "array", "items": schemaRef(t.Elem(), schemas)}
+	case reflect.Pointer:
+		return schemaRef(t.Elem(), schemas)
+	case reflect.Struct:
+	default:
+		return map[string]any{}
+	}
+
+	ref := map[string]any{"$ref": "#/components/schemas/" + t.Name()}
+	if _, ok := schemas[t.Name()]; ok {
+		return ref
+	}
+
+	properties := map[string]any{}
+	required := make([]string, 0)
+
+	// register before recursing, so self-referencing types terminate
+	schema := map[string]any{"type": "object", "properties": properties}
+	schemas[t.Name()] = schema
+
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		property := schemaRef(field.Type, schemas)
+
+		enum := field.Tag.Get("enum")
+		if enum != "" {
+			property["enum"] = strings.Split(enum, ",")
+		}
+
+		properties[name] = property
+
+		if !strings.Contains(options, "omitempty") {
+			required = append(required, name)
+		}
+	}
+
+	if len(required) != 0 {
+		schema["required"] = required
+	}
+
+	return ref
+}
@@ -0,0 +1,238 @@
+package api
+
+import (
+	"github.com/m4tthewde/blunt/catalog"
+	"github.com/m4tthewde/blunt/graph"
+	"github.com/m4tthewde/blunt/tmdb"
+)
+
+// The response types below are the public schema of the API. They are
+// deliberately decoupled from TMDB's field names, so changes on TMDB's side
+// don't leak into clients.
+
+type SearchResult struct {
+	Type         string  `json:"type" enum:"movie,person"`
+	Id           int64   `json:"id"`
+	Name         string  `json:"name"`
+	OriginalName string  `json:"original_name,omitempty"`
+	Year         string  `json:"year,omitempty"`
+	ImageUrl     string  `json:"image_url"`
+	Popularity   float64 `json:"popularity"`
+}
+
+type SearchResponse struct {
+	Query   string         `json:"query"`
+	Results []SearchResult `json:"results"`
+}
+
+type Movie struct {
+	Id               int64   `json:"id"`
+	Title            string  `json:"title"`
+	OriginalTitle    string  `json:"original_title"`
+	OriginalLanguage string  `json:"original_language"`
+	Tagline          string  `json:"tagline,omitempty"`
+	Overview         string  `json:"overview,omitempty"`
+	ReleaseDate      string  `json:"release_date,omitempty"`
+	RuntimeMinutes   int64   `json:"runtime_minutes"`
+	Revenue          int64   `json:"revenue"`
+	Popularity       float64 `json:"popularity"`
+	PosterUrl        string  `json:"poster_url"`
+}
+
+type CastMember struct {
+	PersonId   int64   `json:"person_id"`
+	Name       string  `json:"name"`
+	Character  string  `json:"character,omitempty"`
+	ProfileUrl string  `json:"profile_url"`
+	Popularity float64 `json:"popularity"`
+}
+
+type MovieCredits struct {
+	MovieId int64        `json:"movie_id"`
+	Cast    []CastMember `json:"cast"`
+}
+
+type Person struct {
+	Id                 int64  `json:"id"`
+	Name               string `json:"name"`
+	Birthday           string `json:"birthday,omitempty"`
+	Deathday           string `json:"deathday,omitempty"`
+	PlaceOfBirth       string `json:"place_of_birth,omitempty"`
+	KnownForDepartment string `json:"known_for_department,omitempty"`
+	Homepage           string `json:"homepage,omitempty"`
+	Biography          string `json:"biography,omitempty"`
+	ProfileUrl         string `json:"profile_url"`
+}
+
+type PersonCredit struct {
+	MovieId       int64   `json:"movie_id"`
+	Title         string  `json:"title"`
+	OriginalTitle string  `json:"original_title"`
+	ReleaseDate   string  `json:"release_date"`
+	Year          string  `json:"year"`
+	PosterUrl     string  `json:"poster_url"`
+	Popularity    float64 `json:"popularity"`
+}
+
+type PersonCredits struct {
+	PersonId int64          `json:"person_id"`
+	Credits  []PersonCredit `json:"credits"`
+	// FamilyPending counts credits family mode hides until their
+	// certification is looked up, a later request may return them.
+	FamilyPending int `json:"family_pending,omitempty"`
+}
+
+type GraphNode struct {
+	Key      string `json:"key"`
+	Type     string `json:"type" enum:"movie,person"`
+	Id       int64  `json:"id"`
+	Name     string `json:"name"`
+	ImageUrl string `json:"image_url"`
+}
+
+type GraphEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type Graph struct {
+	Root  string      `json:"root"`
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+}
+
+type Path struct {
+	From  int64       `json:"from"`
+	To    int64       `json:"to"`
+	Steps []GraphNode `json:"steps"`
+}
+
+type ErrorBody struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type Error struct {
+	Error ErrorBody `json:"error"`
+}
+
+// imageWidth is the width of images linked from the API, large enough for
+// detail pages.
+const imageWidth = 500
+
+func NewSearchResults(results []catalog.SearchResult) []SearchResult {
+	searchResults := make([]SearchResult, 0)
+
+	for _, result := range results {
+		searchResults = append(searchResults, SearchResult{
+			Type:         result.Type,
+			Id:           result.Id,
+			Name:         result.Name,
+			OriginalName: result.OriginalName,
+			Year:         result.Year,
+			ImageUrl:     tmdb.ImageUrl(result.ImageKind, result.ImagePath, imageWidth),
+			Popularity:   result.Popularity,
+		})
+	}
+
+	return searchResults
+}
+
+func NewMovie(movie *tmdb.MovieDetailsResponse) Movie {
+	return Movie{
+		Id:               movie.Id,
+		Title:            movie.Title,
+		OriginalTitle:    movie.OriginalTitle,
+		OriginalLanguage: movie.OriginalLanguage,
+		Tagline:          movie.Tagline,
+		Overview:         movie.Overview,
+		ReleaseDate:      movie.ReleaseDate,
+		RuntimeMinutes:   movie.Runtime,
+		Revenue:          movie.Revenue,
+		Popularity:       movie.Popularity,
+		PosterUrl:        tmdb.ImageUrl(tmdb.PosterImage, movie.PosterPath, imageWidth),
+	}
+}
+
+func NewMovieCredits(credits *tmdb.MovieCreditsResponse) MovieCredits {
+	cast := make([]CastMember, 0)
+
+	for _, castMember := range credits.Cast {
+		cast = append(cast, CastMember{
+			PersonId:   castMember.Id,
+			Name:       castMember.Name,
+			Character:  castMember.Character,
+			ProfileUrl: tmdb.ImageUrl(tmdb.ProfileImage, castMember.ProfilePath, imageWidth),
+			Popularity: castMember.Popularity,
+		})
+	}
+
+	return MovieCredits{MovieId: credits.Id, Cast: cast}
+}
+
+func NewPerson(person *tmdb.PeopleResponse) Person {
+	return Person{
+		Id:                 person.Id,
+		Name:               person.Name,
+		Birthday:           person.Birthday,
+		Deathday:           person.Deathday,
+		PlaceOfBirth:       person.PlaceOfBirth,
+		KnownForDepartment: person.KnownForDepartment,
+		Homepage:           person.Homepage,
+		Biography:          person.Biography,
+		ProfileUrl:         tmdb.ImageUrl(tmdb.ProfileImage, person.ProfilePath, imageWidth),
+	}
+}
+
+// NewPersonCredits keeps released movies only, newest first, like the
+// person page does.
+func NewPersonCredits(credits *tmdb.PeopleCreditsResponse) PersonCredits {
+	personCredits := make([]PersonCredit, 0)
+
+	for _, credit := range catalog.ReleasedCredits(credits.Cast) {
+		personCredits = append(personCredits, PersonCredit{
+			MovieId:       credit.Id,
+			Title:         credit.Title,
+			OriginalTitle: credit.OriginalTitle,
+			ReleaseDate:   credit.ReleaseDate,
+			Year:          tmdb.GetReleaseYear(credit.ReleaseDate),
+			PosterUrl:     tmdb.ImageUrl(tmdb.PosterImage, credit.PosterPath, imageWidth),
+			Popularity:    credit.Popularity,
+		})
+	}
+
+	return PersonCredits{PersonId: credits.Id, Credits: personCredits, FamilyPending: credits.Pending}
+}
+
+func NewGraphNode(node graph.Node) GraphNode {
+	return GraphNode{
+		Key:      node.Key(),
+		Type:     node.Type,
+		Id:       node.Id,
+		Name:     node.Name,
+		ImageUrl: node.ImagePath,
+	}
+}
+
+func NewGraph(g *graph.Graph) Graph {
+	nodes := make([]GraphNode, 0)
+	for _, node := range g.Nodes {
+		nodes = append(nodes, NewGraphNode(node))
+	}
+
+	edges := make([]GraphEdge, 0)
+	for _, edge := range g.Edges {
+		edges = append(edges, GraphEdge{From: edge.From, To: edge.To})
+	}
+
+	return Graph{Root: g.Root, Nodes: nodes, Edges: edges}
+}
+
+func NewPath(from int64, to int64, nodes []graph.Node) Path {
+	steps := make([]GraphNode, 0)
+	for _, node := range nodes {
+		steps = append(steps, NewGraphNode(node))
+	}
+
+	return Path{From: from, To: to, Steps: steps}
+}
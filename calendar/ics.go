@@ -0,0 +1,99 @@
+// Package calendar writes upcoming movie releases as iCalendar (RFC 5545)
+// files calendar apps can subscribe to.
+package calendar
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"time"
+)
+
+// Event is a whole day event.
+type Event struct {
+	// Uid stays the same while the event changes, so subscribed calendars
+	// update it instead of adding another.
+	Uid         string
+	Date        time.Time
+	Summary     string
+	Description string
+	Url         string
+	Category    string
+}
+
+type Calendar struct {
+	Name   string
+	Events []Event
+}
+
+// maxLine is the number of octets a content line may have before it is
+// folded.
+const maxLine = 75
+
+// Write writes the calendar, stamped with the time it was created.
+func (c Calendar) Write(w io.Writer, stamp time.Time) error {
+	b := bufio.NewWriter(w)
+
+	line(b, "BEGIN", "VCALENDAR")
+	line(b, "VERSION", "2.0")
+	line(b, "PRODID", "-//blunt//releases//EN")
+	line(b, "CALSCALE", "GREGORIAN")
+	line(b, "METHOD", "PUBLISH")
+	line(b, "X-WR-CALNAME", escape(c.Name))
+
+	for _, event := range c.Events {
+		line(b, "BEGIN", "VEVENT")
+		line(b, "UID", escape(event.Uid))
+		line(b, "DTSTAMP", stamp.UTC().Format("20060102T150405Z"))
+		line(b, "DTSTART;VALUE=DATE", event.Date.Format("20060102"))
+		line(b, "DTEND;VALUE=DATE", event.Date.AddDate(0, 0, 1).Format("20060102"))
+		line(b, "SUMMARY", escape(event.Summary))
+
+		if event.Description != "" {
+			line(b, "DESCRIPTION", escape(event.Description))
+		}
+
+		if event.Url != "" {
+			line(b, "URL;VALUE=URI", event.Url)
+		}
+
+		if event.Category != "" {
+			line(b, "CATEGORIES", escape(event.Category))
+		}
+
+		// releases don't make anybody busy
+		line(b, "TRANSP", "TRANSPARENT")
+		line(b, "END", "VEVENT")
+	}
+
+	line(b, "END", "VCALENDAR")
+
+	return b.Flush()
+}
+
+// line writes a content line, folded after maxLine octets without
+// splitting UTF-8 sequences.
+func line(b *bufio.Writer, name string, value string) {
+	content := name + ":" + value
+
+	width := 0
+	for _, r := range content {
+		size := len(string(r))
+		if width+size > maxLine {
+			b.WriteString("\r\n ")
+			// the space of the continuation counts
+			width = 1
+		}
+
+		b.WriteRune(r)
+		width += size
+	}
+
+	b.WriteString("\r\n")
+}
+
+var escaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+func escape(text string) string {
+	return escaper.Replace(text)
+}
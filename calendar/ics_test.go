@@ -0,0 +1,135 @@
+package calendar
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestLine(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		lines int
+	}{
+		{"short", "Heat", 1},
+		{"exactly the limit", strings.Repeat("x", maxLine-len("SUMMARY:")), 1},
+		{"one octet over", strings.Repeat("x", maxLine-len("SUMMARY:")+1), 2},
+		// continuations hold one octet less because of their space
+		{"long", strings.Repeat("x", 3*maxLine), 4},
+		{"multibyte", strings.Repeat("ä", 60), 2},
+		{"four byte runes", strings.Repeat("🎬", 40), 3},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var out strings.Builder
+
+			b := bufio.NewWriter(&out)
+			line(b, "SUMMARY", test.value)
+			b.Flush()
+
+			content, ok := strings.CutSuffix(out.String(), "\r\n")
+			if !ok {
+				t.Fatalf("%q doesn't end with CRLF", out.String())
+			}
+
+			lines := strings.Split(content, "\r\n")
+			if len(lines) != test.lines {
+				t.Errorf("%d lines, want %d", len(lines), test.lines)
+			}
+
+			for i, l := range lines {
+				if len(l) > maxLine {
+					t.Errorf("line %d has %d octets", i+1, len(l))
+				}
+
+				if !utf8.ValidString(l) {
+					t.Errorf("line %d splits a UTF-8 sequence: %q", i+1, l)
+				}
+
+				if i > 0 && !strings.HasPrefix(l, " ") {
+					t.Errorf("continuation %d doesn't start with a space", i+1)
+				}
+			}
+
+			// unfolding removes every CRLF followed by a space
+			if unfolded := strings.ReplaceAll(content, "\r\n ", ""); unfolded != "SUMMARY:"+test.value {
+				t.Errorf("unfolded to %q", unfolded)
+			}
+		})
+	}
+}
+
+func TestEscape(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"Heat", "Heat"},
+		{"Crouching Tiger, Hidden Dragon", `Crouching Tiger\, Hidden Dragon`},
+		{"Premiere; Berlin", `Premiere\; Berlin`},
+		{`C:\movies`, `C:\\movies`},
+		{"first\nsecond", `first\nsecond`},
+		{"first\r\nsecond", `first\nsecond`},
+		// escaped before the backslashes of the others are added
+		{`\,`, `\\\,`},
+		// colons and quotes need no escaping in text values
+		{`Mission: "Impossible"`, `Mission: "Impossible"`},
+	}
+
+	for _, test := range tests {
+		if got := escape(test.text); got != test.want {
+			t.Errorf("escape(%q) = %q, want %q", test.text, got, test.want)
+		}
+	}
+}
+
+func TestWrite(t *testing.T) {
+	cal := Calendar{
+		Name: "Releases, upcoming",
+		Events: []Event{{
+			Uid:         "release-1-DE-3@example.com",
+			Date:        time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC),
+			Summary:     "Heat (Theatrical)",
+			Description: "Re-release\nhttps://example.com/movie/1",
+			Url:         "https://example.com/movie/1",
+			Category:    "Theatrical",
+		}},
+	}
+
+	var out strings.Builder
+
+	err := cal.Write(&out, time.Date(2025, 6, 1, 12, 30, 0, 0, time.FixedZone("CEST", 2*60*60)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//blunt//releases//EN",
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		`X-WR-CALNAME:Releases\, upcoming`,
+		"BEGIN:VEVENT",
+		"UID:release-1-DE-3@example.com",
+		"DTSTAMP:20250601T103000Z",
+		"DTSTART;VALUE=DATE:20251231",
+		"DTEND;VALUE=DATE:20260101",
+		"SUMMARY:Heat (Theatrical)",
+		`DESCRIPTION:Re-release\nhttps://example.com/movie/1`,
+		"URL;VALUE=URI:https://example.com/movie/1",
+		"CATEGORIES:Theatrical",
+		"TRANSP:TRANSPARENT",
+		"END:VEVENT",
+		"END:VCALENDAR",
+		"",
+	}, "\r\n")
+
+	if out.String() != want {
+		t.Errorf("wrote\n%s\nwant\n%s", out.String(), want)
+	}
+}
@@ -0,0 +1,221 @@
+package calendar
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/m4tthewde/blunt/library"
+	"github.com/m4tthewde/blunt/tmdb"
+)
+
+const (
+	// MaxMovies bounds the TMDB lookups of a calendar.
+	MaxMovies = 50
+	// concurrency of the lookups, TMDB's rate limit applies on top.
+	concurrency = 4
+	// lateRelease is how long after its first release a movie may still
+	// get a release somewhere, digital and physical ones come months later.
+	lateRelease = 365 * 24 * time.Hour
+)
+
+// Movie is a movie to look up releases of. Its details are looked up too
+// when Title is empty.
+type Movie struct {
+	Id    int64
+	Title string
+}
+
+type Release struct {
+	MovieId int64
+	Title   string
+	// Date in the DateOnly layout, local to the region.
+	Date string
+	// Type is one of the release types of the tmdb package.
+	Type int
+	Note string
+}
+
+// TypeName returns the message key suffix of a release type.
+func TypeName(releaseType int) string {
+	name, ok := typeNames[releaseType]
+	if !ok {
+		return "other"
+	}
+
+	return name
+}
+
+var typeNames = map[int]string{
+	tmdb.ReleasePremiere:          "premiere",
+	tmdb.ReleaseTheatricalLimited: "theatricalLimited",
+	tmdb.ReleaseTheatrical:        "theatrical",
+	tmdb.ReleaseDigital:           "digital",
+	tmdb.ReleasePhysical:          "physical",
+	tmdb.ReleaseTV:                "tv",
+}
+
+// Candidates picks the credits of a person that can still have upcoming
+// releases, without a date or first released within lateRelease, newest
+// first and at most MaxMovies of them.
+func Candidates(credits []tmdb.PeopleCredit, today time.Time) []Movie {
+	since := today.Add(-lateRelease).Format(library.DateLayout)
+
+	recent := make([]tmdb.PeopleCredit, 0)
+	seen := make(map[int64]bool)
+
+	for _, credit := range credits {
+		if seen[credit.Id] || (credit.ReleaseDate != "" && credit.ReleaseDate < since) {
+			continue
+		}
+
+		seen[credit.Id] = true
+		recent = append(recent, credit)
+	}
+
+	// movies without a date are the furthest away
+	date := func(credit tmdb.PeopleCredit) string {
+		return cmp.Or(credit.ReleaseDate, "9999-12-31")
+	}
+
+	slices.SortFunc(recent, func(a, b tmdb.PeopleCredit) int {
+		return cmp.Or(cmp.Compare(date(b), date(a)), cmp.Compare(a.Id, b.Id))
+	})
+
+	movies := make([]Movie, 0, min(MaxMovies, len(recent)))
+	for _, credit := range recent[:min(MaxMovies, len(recent))] {
+		movies = append(movies, Movie{Id: credit.Id, Title: credit.Title})
+	}
+
+	return movies
+}
+
+// Lookups returns the TMDB lookups Upcoming makes for movies, the release
+// dates of every movie and the titles they lack.
+func Lookups(movies []Movie) int {
+	lookups := len(movies)
+	for _, movie := range movies {
+		if movie.Title == "" {
+			lookups++
+		}
+	}
+
+	return lookups
+}
+
+// Upcoming returns the releases of the movies in region from today on,
+// ordered by date. Movies TMDB doesn't know are left out.
+func Upcoming(ctx context.Context, client *tmdb.Client, movies []Movie, region string, today time.Time) ([]Release, error) {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var firstErr error
+
+	releases := make([]Release, 0)
+	slots := make(chan struct{}, concurrency)
+
+	for _, movie := range movies {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			found, err := lookup(ctx, client, movie, region, today)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if errors.Is(err, tmdb.ErrNotFound) {
+				return
+			}
+
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				return
+			}
+
+			releases = append(releases, found...)
+		}()
+	}
+
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	slices.SortFunc(releases, func(a, b Release) int {
+		return cmp.Or(
+			cmp.Compare(a.Date, b.Date),
+			cmp.Compare(a.Title, b.Title),
+			cmp.Compare(a.MovieId, b.MovieId),
+			cmp.Compare(a.Type, b.Type),
+		)
+	})
+
+	return releases, nil
+}
+
+func lookup(ctx context.Context, client *tmdb.Client, movie Movie, region string, today time.Time) ([]Release, error) {
+	id := strconv.FormatInt(movie.Id, 10)
+
+	if movie.Title == "" {
+		details, err := client.MovieDetails(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+
+		movie.Title = details.Title
+	}
+
+	dates, err := client.ReleaseDates(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return upcoming(movie, dates.Country(region), today), nil
+}
+
+// upcoming keeps the next release of every type from today on, the ones
+// after it are usually festival or event screenings.
+func upcoming(movie Movie, dates []tmdb.ReleaseDate, today time.Time) []Release {
+	now := today.Format(library.DateLayout)
+	first := make(map[int]Release)
+
+	for _, date := range dates {
+		day := date.Date()
+
+		_, err := time.Parse(library.DateLayout, day)
+		if err != nil || day < now {
+			continue
+		}
+
+		release, ok := first[date.Type]
+		if ok && release.Date <= day {
+			continue
+		}
+
+		first[date.Type] = Release{
+			MovieId: movie.Id,
+			Title:   movie.Title,
+			Date:    day,
+			Type:    date.Type,
+			Note:    date.Note,
+		}
+	}
+
+	releases := make([]Release, 0, len(first))
+	for _, release := range first {
+		releases = append(releases, release)
+	}
+
+	return releases
+}
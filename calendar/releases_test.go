@@ -0,0 +1,89 @@
+package calendar
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/m4tthewde/blunt/tmdb"
+)
+
+var today = time.Date(2025, 6, 15, 0, 0, 0, 0, time.UTC)
+
+func TestCandidates(t *testing.T) {
+	credits := []tmdb.PeopleCredit{
+		{Id: 1, Title: "Old", ReleaseDate: "2010-01-01"},
+		{Id: 2, Title: "Last year", ReleaseDate: "2024-09-01"},
+		{Id: 3, Title: "Announced"},
+		{Id: 4, Title: "Next year", ReleaseDate: "2026-03-01"},
+		{Id: 5, Title: "Just too old", ReleaseDate: "2024-06-14"},
+		// a second role in the same movie
+		{Id: 4, Title: "Next year", ReleaseDate: "2026-03-01"},
+		{Id: 6, Title: "Also announced"},
+	}
+
+	want := []Movie{
+		{Id: 3, Title: "Announced"},
+		{Id: 6, Title: "Also announced"},
+		{Id: 4, Title: "Next year"},
+		{Id: 2, Title: "Last year"},
+	}
+
+	if got := Candidates(credits, today); !reflect.DeepEqual(got, want) {
+		t.Errorf("candidates %v, want %v", got, want)
+	}
+}
+
+func TestCandidatesMaxMovies(t *testing.T) {
+	credits := make([]tmdb.PeopleCredit, 0)
+	for id := range MaxMovies + 10 {
+		credits = append(credits, tmdb.PeopleCredit{Id: int64(id), ReleaseDate: today.AddDate(0, 0, id).Format(time.DateOnly)})
+	}
+
+	movies := Candidates(credits, today)
+	if len(movies) != MaxMovies {
+		t.Fatalf("%d candidates, want %d", len(movies), MaxMovies)
+	}
+
+	// the newest ones
+	if movies[0].Id != MaxMovies+9 || movies[MaxMovies-1].Id != 10 {
+		t.Errorf("candidates from %d to %d", movies[0].Id, movies[MaxMovies-1].Id)
+	}
+}
+
+func TestUpcoming(t *testing.T) {
+	movie := Movie{Id: 1, Title: "Heat"}
+	dates := []tmdb.ReleaseDate{
+		{ReleaseDate: "2025-01-10T00:00:00.000Z", Type: tmdb.ReleaseTheatrical},
+		{ReleaseDate: "2025-07-01T00:00:00.000Z", Type: tmdb.ReleaseTheatrical, Note: "Re-release"},
+		{ReleaseDate: "2025-09-01T00:00:00.000Z", Type: tmdb.ReleaseTheatrical, Note: "Festival"},
+		{ReleaseDate: "2025-06-15T00:00:00.000Z", Type: tmdb.ReleaseDigital},
+		{ReleaseDate: "2025-12-01T00:00:00.000Z", Type: tmdb.ReleasePhysical},
+		{ReleaseDate: "2025-11-01T00:00:00.000Z", Type: tmdb.ReleasePhysical},
+		{ReleaseDate: "", Type: tmdb.ReleaseTV},
+		{ReleaseDate: "soon", Type: tmdb.ReleasePremiere},
+	}
+
+	want := []Release{
+		{MovieId: 1, Title: "Heat", Date: "2025-06-15", Type: tmdb.ReleaseDigital},
+		{MovieId: 1, Title: "Heat", Date: "2025-07-01", Type: tmdb.ReleaseTheatrical, Note: "Re-release"},
+		{MovieId: 1, Title: "Heat", Date: "2025-11-01", Type: tmdb.ReleasePhysical},
+	}
+
+	got := upcoming(movie, dates, today)
+	slices.SortFunc(got, func(a, b Release) int { return a.Type - b.Type })
+	slices.SortFunc(want, func(a, b Release) int { return a.Type - b.Type })
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("releases\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestLookups(t *testing.T) {
+	movies := []Movie{{Id: 1, Title: "Heat"}, {Id: 2}, {Id: 3}}
+
+	if got := Lookups(movies); got != 5 {
+		t.Errorf("%d lookups, want 5", got)
+	}
+}
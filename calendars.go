@@ -0,0 +1,153 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/m4tthewde/blunt/calendar"
+	"github.com/m4tthewde/blunt/i18n"
+	"github.com/m4tthewde/blunt/library"
+	"github.com/m4tthewde/blunt/ratelimit"
+	"github.com/m4tthewde/blunt/tmdb"
+)
+
+// calendarRegion is the region of a calendar's release dates. Calendar apps
+// don't say which language their users speak, a `region` query parameter
+// can be part of the subscribed URL instead.
+func calendarRegion(r *http.Request) (string, bool) {
+	region := r.URL.Query().Get("region")
+	if region == "" {
+		return client.Region(r.Context()), true
+	}
+
+	if len(region) != 2 {
+		return "", false
+	}
+
+	return strings.ToUpper(region), true
+}
+
+// castMemberReleases serves the upcoming releases of a person's movies.
+func castMemberReleases(w http.ResponseWriter, r *http.Request) {
+	region, ok := calendarRegion(r)
+	if !ok {
+		http.Error(w, "invalid region", http.StatusBadRequest)
+		return
+	}
+
+	idString := r.PathValue("id")
+
+	person, err := client.People(r.Context(), idString)
+	if errors.Is(err, tmdb.ErrNotFound) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	credits, err := client.PeopleCredits(r.Context(), idString)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	today := time.Now()
+	movies := calendar.Candidates(credits.Cast, today)
+	name := person.Name + " – " + i18n.T(r.Context(), "releases.title")
+
+	writeReleases(w, r, name, movies, region, today)
+}
+
+// movieReleases serves the upcoming releases of the movies given as
+// comma separated ids in the `movies` query parameter.
+func movieReleases(w http.ResponseWriter, r *http.Request) {
+	region, ok := calendarRegion(r)
+	if !ok {
+		http.Error(w, "invalid region", http.StatusBadRequest)
+		return
+	}
+
+	movies := make([]calendar.Movie, 0)
+	seen := make(map[int64]bool)
+
+	for field := range strings.SplitSeq(r.URL.Query().Get("movies"), ",") {
+		id, err := strconv.ParseInt(strings.TrimSpace(field), 10, 64)
+		if err != nil || id < 1 {
+			http.Error(w, "invalid movies", http.StatusBadRequest)
+			return
+		}
+
+		if !seen[id] {
+			seen[id] = true
+			movies = append(movies, calendar.Movie{Id: id})
+		}
+	}
+
+	if len(movies) > calendar.MaxMovies {
+		http.Error(w, fmt.Sprintf("at most %d movies", calendar.MaxMovies), http.StatusBadRequest)
+		return
+	}
+
+	writeReleases(w, r, i18n.T(r.Context(), "releases.title"), movies, region, time.Now())
+}
+
+// writeReleases writes the calendar of the movies' releases. The request
+// paid for one lookup, the ones of the movies are charged on top.
+func writeReleases(w http.ResponseWriter, r *http.Request, name string, movies []calendar.Movie, region string, today time.Time) {
+	limiter.Charge(r, ratelimit.Graph, calendar.Lookups(movies))
+
+	releases, err := calendar.Upcoming(r.Context(), client, movies, region, today)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	cal := calendar.Calendar{Name: name, Events: make([]calendar.Event, 0, len(releases))}
+
+	for _, release := range releases {
+		event, err := releaseEvent(r, region, release)
+		if err != nil {
+			serverError(w, r, err)
+			return
+		}
+
+		cal.Events = append(cal.Events, event)
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+
+	err = cal.Write(w, time.Now())
+	if err != nil {
+		serverError(w, r, err)
+	}
+}
+
+func releaseEvent(r *http.Request, region string, release calendar.Release) (calendar.Event, error) {
+	date, err := time.Parse(library.DateLayout, release.Date)
+	if err != nil {
+		return calendar.Event{}, fmt.Errorf("release of movie %d: %w", release.MovieId, err)
+	}
+
+	kind := i18n.T(r.Context(), "releases.type."+calendar.TypeName(release.Type))
+	movieUrl := fmt.Sprintf("%s/movie/%d", baseUrl(r), release.MovieId)
+
+	description := movieUrl
+	if release.Note != "" {
+		description = release.Note + "\n" + description
+	}
+
+	return calendar.Event{
+		Uid:         fmt.Sprintf("release-%d-%s-%d@%s", release.MovieId, region, release.Type, r.Host),
+		Date:        date,
+		Summary:     release.Title + " (" + kind + ")",
+		Description: description,
+		Url:         movieUrl,
+		Category:    kind,
+	}, nil
+}
@@ -0,0 +1,106 @@
+package catalog
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"time"
+
+	"github.com/m4tthewde/blunt/tmdb"
+)
+
+const (
+	Movie  = "movie"
+	Person = "person"
+)
+
+type SearchResult struct {
+	Type         string
+	Id           int64
+	Name         string
+	OriginalName string
+	Year         string
+	ImageKind    string
+	ImagePath    string
+	Popularity   float64
+}
+
+// Search merges movie and people results into a single list ordered by
+// popularity.
+func Search(ctx context.Context, client *tmdb.Client, search string) ([]SearchResult, error) {
+	movieResponse, err := client.SearchMovies(ctx, search)
+	if err != nil {
+		return nil, err
+	}
+
+	peopleResponse, err := client.SearchPeople(ctx, search)
+	if err != nil {
+		return nil, err
+	}
+
+	searchResults := make([]SearchResult, 0)
+
+	for _, movieResult := range movieResponse.Results {
+		searchResults = append(searchResults, SearchResult{
+			Type:         Movie,
+			Id:           movieResult.Id,
+			Name:         movieResult.Title,
+			OriginalName: movieResult.OriginalTitle,
+			Year:         tmdb.GetReleaseYear(movieResult.ReleaseDate),
+			ImageKind:    tmdb.PosterImage,
+			ImagePath:    movieResult.PosterPath,
+			Popularity:   movieResult.Popularity,
+		})
+	}
+
+	for _, peopleResult := range peopleResponse.Results {
+		searchResults = append(searchResults, SearchResult{
+			Type:       Person,
+			Id:         peopleResult.Id,
+			Name:       peopleResult.Name,
+			ImageKind:  tmdb.ProfileImage,
+			ImagePath:  peopleResult.ProfilePath,
+			Popularity: peopleResult.Popularity,
+		})
+	}
+
+	slices.SortFunc(searchResults,
+		func(a, b SearchResult) int {
+			return cmp.Compare(b.Popularity, a.Popularity)
+		},
+	)
+
+	return searchResults, nil
+}
+
+// ReleasedCredits drops credits without a release date and orders the rest
+// newest first.
+func ReleasedCredits(credits []tmdb.PeopleCredit) []tmdb.PeopleCredit {
+	cast := make([]tmdb.PeopleCredit, 0)
+	for _, c := range credits {
+		if c.ReleaseDate != "" {
+			cast = append(cast, c)
+		}
+	}
+
+	slices.SortFunc(cast,
+		func(a, b tmdb.PeopleCredit) int {
+			timeA, err := time.Parse(time.DateOnly, a.ReleaseDate)
+			if err != nil {
+				return 0
+			}
+			timeB, err := time.Parse(time.DateOnly, b.ReleaseDate)
+			if err != nil {
+				return 0
+			}
+
+			if timeA.Before(timeB) {
+				return 1
+			}
+
+			return -1
+		},
+	)
+
+	return cast
+}
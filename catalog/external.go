@@ -0,0 +1,150 @@
+package catalog
+
+import (
+	"context"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/m4tthewde/blunt/tmdb"
+)
+
+// ExternalId is the id of a movie or person on another site, Source is one
+// of the /find sources of the tmdb package.
+type ExternalId struct {
+	Source string
+	Id     string
+}
+
+var (
+	imdbIdPattern     = regexp.MustCompile(`^(tt|nm)\d{7,}$`)
+	wikidataIdPattern = regexp.MustCompile(`^Q\d+$`)
+)
+
+// ParseExternalId recognises IMDb and Wikidata ids, as well as links to
+// their pages, e.g. https://www.imdb.com/title/tt0133093/ or
+// https://www.wikidata.org/wiki/Q83495.
+func ParseExternalId(search string) (ExternalId, bool) {
+	search = strings.TrimSpace(search)
+
+	if strings.Contains(search, "/") {
+		u, err := url.Parse(search)
+		if err != nil {
+			return ExternalId{}, false
+		}
+
+		// links copied without the scheme
+		if u.Host == "" {
+			u, err = url.Parse("https://" + search)
+			if err != nil {
+				return ExternalId{}, false
+			}
+		}
+
+		host := strings.ToLower(u.Hostname())
+		segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+
+		switch {
+		case host == "imdb.com" || strings.HasSuffix(host, ".imdb.com"):
+			// localized pages have the language first, /de/title/tt0133093/
+			for i, segment := range segments[:len(segments)-1] {
+				if (segment == "title" || segment == "name") && imdbIdPattern.MatchString(segments[i+1]) {
+					return ExternalId{tmdb.ImdbSource, segments[i+1]}, true
+				}
+			}
+		case host == "wikidata.org" || strings.HasSuffix(host, ".wikidata.org"):
+			// /wiki/Q83495 or /entity/Q83495
+			if len(segments) == 2 && (segments[0] == "wiki" || segments[0] == "entity") && wikidataIdPattern.MatchString(segments[1]) {
+				return ExternalId{tmdb.WikidataSource, segments[1]}, true
+			}
+		}
+
+		return ExternalId{}, false
+	}
+
+	switch {
+	case imdbIdPattern.MatchString(search):
+		return ExternalId{tmdb.ImdbSource, search}, true
+	case wikidataIdPattern.MatchString(search):
+		return ExternalId{tmdb.WikidataSource, search}, true
+	}
+
+	return ExternalId{}, false
+}
+
+// FindExternal returns the movie or person with the external id. It
+// reports false when TMDB doesn't know the id.
+func FindExternal(ctx context.Context, client *tmdb.Client, id ExternalId) (SearchResult, bool, error) {
+	found, err := client.Find(ctx, id.Id, id.Source)
+	if err != nil {
+		return SearchResult{}, false, err
+	}
+
+	if len(found.MovieResults) > 0 {
+		movie := found.MovieResults[0]
+
+		return SearchResult{
+			Type:         Movie,
+			Id:           movie.Id,
+			Name:         movie.Title,
+			OriginalName: movie.OriginalTitle,
+			Year:         tmdb.GetReleaseYear(movie.ReleaseDate),
+			ImageKind:    tmdb.PosterImage,
+			ImagePath:    movie.PosterPath,
+			Popularity:   movie.Popularity,
+		}, true, nil
+	}
+
+	if len(found.PersonResults) > 0 {
+		person := found.PersonResults[0]
+
+		return SearchResult{
+			Type:       Person,
+			Id:         person.Id,
+			Name:       person.Name,
+			ImageKind:  tmdb.ProfileImage,
+			ImagePath:  person.ProfilePath,
+			Popularity: person.Popularity,
+		}, true, nil
+	}
+
+	return SearchResult{}, false, nil
+}
+
+// ExternalLink points to a page about a movie or person on another site.
+type ExternalLink struct {
+	Site string
+	Url  string
+}
+
+// ExternalLinks returns the pages other sites have about a movie or person,
+// kind is Movie or Person. TMDB's own page comes first.
+func ExternalLinks(kind string, id int64, ids *tmdb.ExternalIdsResponse) []ExternalLink {
+	tmdbPath := "movie"
+	imdbPath := "title"
+	if kind == Person {
+		tmdbPath = "person"
+		imdbPath = "name"
+	}
+
+	links := []ExternalLink{{"TMDB", "https://www.themoviedb.org/" + tmdbPath + "/" + strconv.FormatInt(id, 10)}}
+
+	if ids.ImdbId != "" {
+		links = append(links, ExternalLink{"IMDb", "https://www.imdb.com/" + imdbPath + "/" + url.PathEscape(ids.ImdbId) + "/"})
+	}
+	if ids.WikidataId != "" {
+		links = append(links, ExternalLink{"Wikidata", "https://www.wikidata.org/wiki/" + url.PathEscape(ids.WikidataId)})
+	}
+	if ids.FacebookId != "" {
+		links = append(links, ExternalLink{"Facebook", "https://www.facebook.com/" + url.PathEscape(ids.FacebookId)})
+	}
+	if ids.InstagramId != "" {
+		links = append(links, ExternalLink{"Instagram", "https://www.instagram.com/" + url.PathEscape(ids.InstagramId) + "/"})
+	}
+	if ids.TwitterId != "" {
+		links = append(links, ExternalLink{"X", "https://x.com/" + url.PathEscape(ids.TwitterId)})
+	}
+
+	return links
+}
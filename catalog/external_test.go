@@ -0,0 +1,68 @@
+package catalog
+
+import (
+	"testing"
+
+	"github.com/m4tthewde/blunt/tmdb"
+)
+
+func TestParseExternalId(t *testing.T) {
+	imdb := func(id string) ExternalId { return ExternalId{tmdb.ImdbSource, id} }
+	wikidata := func(id string) ExternalId { return ExternalId{tmdb.WikidataSource, id} }
+
+	tests := []struct {
+		search string
+		want   ExternalId
+		ok     bool
+	}{
+		{"tt0133093", imdb("tt0133093"), true},
+		{"  nm0000206 ", imdb("nm0000206"), true},
+		{"tt12345678", imdb("tt12345678"), true},
+		{"Q83495", wikidata("Q83495"), true},
+		{"https://www.imdb.com/title/tt0133093/", imdb("tt0133093"), true},
+		{"https://www.imdb.com/name/nm0000206/?ref_=tt_cl_t_1", imdb("nm0000206"), true},
+		{"https://m.imdb.com/title/tt0133093/", imdb("tt0133093"), true},
+		{"https://IMDB.com/title/tt0133093", imdb("tt0133093"), true},
+		// localized pages have the language first
+		{"https://www.imdb.com/de/title/tt0133093/", imdb("tt0133093"), true},
+		{"https://www.imdb.com/fr/name/nm0000206/", imdb("nm0000206"), true},
+		// links copied without the scheme
+		{"www.imdb.com/title/tt0133093/", imdb("tt0133093"), true},
+		{"imdb.com/de/title/tt0133093", imdb("tt0133093"), true},
+		{"www.wikidata.org/wiki/Q83495", wikidata("Q83495"), true},
+		{"https://www.wikidata.org/wiki/Q83495", wikidata("Q83495"), true},
+		{"http://www.wikidata.org/entity/Q83495", wikidata("Q83495"), true},
+		{"https://m.wikidata.org/wiki/Q83495", wikidata("Q83495"), true},
+
+		// not an id
+		{"The Matrix", ExternalId{}, false},
+		{"tt013309", ExternalId{}, false},
+		{"tt0133093x", ExternalId{}, false},
+		{"Q", ExternalId{}, false},
+		{"q83495", ExternalId{}, false},
+		{"AC/DC", ExternalId{}, false},
+		// pages that aren't a title or name
+		{"https://www.imdb.com/chart/top/", ExternalId{}, false},
+		{"https://www.imdb.com/title/", ExternalId{}, false},
+		{"https://www.imdb.com/list/ls000000001/", ExternalId{}, false},
+		{"https://www.wikidata.org/wiki/Q83495/extra", ExternalId{}, false},
+		{"https://www.wikidata.org/wiki/Property:P345", ExternalId{}, false},
+		{"https://www.wikidata.org/w/Q83495", ExternalId{}, false},
+		// hosts that only look alike
+		{"https://notimdb.com/title/tt0133093/", ExternalId{}, false},
+		{"https://imdb.com.example.com/title/tt0133093/", ExternalId{}, false},
+		{"https://example.com/imdb.com/title/tt0133093/", ExternalId{}, false},
+		{"https://wikidata.org.example.com/wiki/Q83495", ExternalId{}, false},
+		{"https://www.themoviedb.org/movie/603", ExternalId{}, false},
+		{"https://www.imdb.com:443\x7f/title/tt0133093/", ExternalId{}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.search, func(t *testing.T) {
+			got, ok := ParseExternalId(test.search)
+			if ok != test.ok || got != test.want {
+				t.Errorf("ParseExternalId(%q) = %v, %v, want %v, %v", test.search, got, ok, test.want, test.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,441 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/m4tthewde/blunt/catalog"
+	"github.com/m4tthewde/blunt/graph"
+	"github.com/m4tthewde/blunt/tmdb"
+	"github.com/m4tthewde/blunt/tracing"
+	"gopkg.in/yaml.v3"
+)
+
+const usage = `Usage: blunt [global flags] <command> [arguments]
+
+Commands:
+  serve                                  start the web server (default)
+  search <query> [--json]                search movies and people
+  movie <id> [--json]                    show movie details and cast
+  person <id> [--json]                   show person details and credits
+  path <personA> <personB> [--depth n] [--fanout n] [--json]
+                                         find a chain of shared movies
+  graph <movie|person> <id> [--depth n] [--fanout n] [--format dot|json]
+                                         print the graph around a movie or person
+  config check                           validate the configuration and print it
+
+Global flags (override config.yaml and environment variables):
+  --config path  --listen addr  --token token  --token-file path
+  --auth bearer|api_key  --api-key key  --tmdb-base-url url  --language lang  --region code
+  --cache-ttl duration  --cache-size n  --rate-limit n  --rate-burst n
+  --graph-fanout n  --graph-max-depth n  --log-level level  --log-format text|json
+`
+
+func run(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		args = []string{"serve"}
+	}
+
+	command, args := args[0], args[1:]
+
+	switch command {
+	case "config":
+		return configCommand(ctx, os.Stdout, args)
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+		return nil
+	}
+
+	err := config.Validate()
+	if err != nil {
+		return fmt.Errorf("invalid configuration:\n%w", err)
+	}
+
+	logger, err := config.Logger(os.Stderr)
+	if err != nil {
+		return err
+	}
+
+	slog.SetDefault(logger)
+
+	client = tmdb.NewClient(config.TmdbOptions())
+
+	switch command {
+	case "serve":
+		err = verifyCredentials(ctx)
+		if err != nil {
+			return err
+		}
+
+		shutdownTracing, err := tracing.Setup(ctx, config.TracingOptions())
+		if err != nil {
+			return err
+		}
+
+		defer shutdownTracing(context.WithoutCancel(ctx))
+
+		go refreshImageConfiguration(ctx)
+
+		return serve(ctx)
+	case "search":
+		return searchCommand(ctx, os.Stdout, args)
+	case "movie":
+		return movieCommand(ctx, os.Stdout, args)
+	case "person":
+		return personCommand(ctx, os.Stdout, args)
+	case "path":
+		return pathCommand(ctx, os.Stdout, args)
+	case "graph":
+		return graphCommand(ctx, os.Stdout, args)
+	}
+
+	fmt.Fprint(os.Stderr, usage)
+	return fmt.Errorf("unknown command %q", command)
+}
+
+func searchCommand(ctx context.Context, w io.Writer, args []string) error {
+	fs := flag.NewFlagSet("search", flag.ContinueOnError)
+	asJson := fs.Bool("json", false, "print JSON instead of a table")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if len(positional) == 0 {
+		return errors.New("usage: blunt search <query>")
+	}
+
+	results, err := catalog.Search(ctx, client, strings.Join(positional, " "))
+	if err != nil {
+		return err
+	}
+
+	if *asJson {
+		return writeJson(w, results)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TYPE\tID\tNAME\tYEAR\tPOPULARITY")
+	for _, result := range results {
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%.1f\n", result.Type, result.Id, result.Name, result.Year, result.Popularity)
+	}
+
+	return tw.Flush()
+}
+
+func movieCommand(ctx context.Context, w io.Writer, args []string) error {
+	fs := flag.NewFlagSet("movie", flag.ContinueOnError)
+	asJson := fs.Bool("json", false, "print JSON instead of a table")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if len(positional) != 1 {
+		return errors.New("usage: blunt movie <id>")
+	}
+
+	movieDetails, err := client.MovieDetails(ctx, positional[0])
+	if err != nil {
+		return err
+	}
+
+	credits, err := client.Credits(ctx, positional[0])
+	if err != nil {
+		return err
+	}
+
+	if *asJson {
+		return writeJson(w, struct {
+			Movie *tmdb.MovieDetailsResponse `json:"movie"`
+			Cast  []tmdb.MovieCastMember     `json:"cast"`
+		}{movieDetails, credits.Cast})
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Title:\t%s\n", movieDetails.Title)
+	fmt.Fprintf(tw, "Original title:\t%s\n", movieDetails.OriginalTitle)
+	fmt.Fprintf(tw, "Date:\t%s\n", movieDetails.ReleaseDate)
+	fmt.Fprintf(tw, "Tagline:\t%s\n", movieDetails.Tagline)
+	fmt.Fprintf(tw, "Runtime:\t%dmin\n", movieDetails.Runtime)
+	fmt.Fprintf(tw, "Language:\t%s\n", movieDetails.OriginalLanguage)
+	fmt.Fprintf(tw, "Revenue:\t$%d\n", movieDetails.Revenue)
+	fmt.Fprintln(tw)
+	fmt.Fprintln(tw, "ID\tNAME\tCHARACTER")
+	for _, castMember := range credits.Cast {
+		fmt.Fprintf(tw, "%d\t%s\t%s\n", castMember.Id, castMember.Name, castMember.Character)
+	}
+
+	return tw.Flush()
+}
+
+func personCommand(ctx context.Context, w io.Writer, args []string) error {
+	fs := flag.NewFlagSet("person", flag.ContinueOnError)
+	asJson := fs.Bool("json", false, "print JSON instead of a table")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if len(positional) != 1 {
+		return errors.New("usage: blunt person <id>")
+	}
+
+	person, err := client.People(ctx, positional[0])
+	if err != nil {
+		return err
+	}
+
+	peopleCredits, err := client.PeopleCredits(ctx, positional[0])
+	if err != nil {
+		return err
+	}
+
+	cast := catalog.ReleasedCredits(peopleCredits.Cast)
+
+	if *asJson {
+		return writeJson(w, struct {
+			Person  *tmdb.PeopleResponse `json:"person"`
+			Credits []tmdb.PeopleCredit  `json:"credits"`
+		}{person, cast})
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Name:\t%s\n", person.Name)
+	fmt.Fprintf(tw, "Birthday:\t%s\n", person.Birthday)
+	if person.Deathday != "" {
+		fmt.Fprintf(tw, "Deathday:\t%s\n", person.Deathday)
+	}
+	fmt.Fprintf(tw, "Place of birth:\t%s\n", person.PlaceOfBirth)
+	fmt.Fprintf(tw, "Known for:\t%s\n", person.KnownForDepartment)
+	fmt.Fprintln(tw)
+	fmt.Fprintln(tw, "ID\tTITLE\tYEAR")
+	for _, credit := range cast {
+		fmt.Fprintf(tw, "%d\t%s\t%s\n", credit.Id, credit.Title, tmdb.GetReleaseYear(credit.ReleaseDate))
+	}
+
+	return tw.Flush()
+}
+
+func pathCommand(ctx context.Context, w io.Writer, args []string) error {
+	fs := flag.NewFlagSet("path", flag.ContinueOnError)
+	depth := fs.Int("depth", min(3, config.Graph.MaxDepth), "maximum number of movies in the path")
+	fanOut := fs.Int("fanout", config.Graph.FanOut, "credits followed per movie or person")
+	asJson := fs.Bool("json", false, "print JSON instead of a table")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if len(positional) != 2 {
+		return errors.New("usage: blunt path <personA> <personB>")
+	}
+
+	from, err := strconv.ParseInt(positional[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid person id %q", positional[0])
+	}
+
+	to, err := strconv.ParseInt(positional[1], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid person id %q", positional[1])
+	}
+
+	err = checkGraphLimits(*depth, *fanOut)
+	if err != nil {
+		return err
+	}
+
+	path, err := graph.Path(ctx, client, nil, from, to, *depth, *fanOut)
+	if err != nil {
+		return err
+	}
+
+	if *asJson {
+		return writeJson(w, path)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TYPE\tID\tNAME")
+	for _, node := range path {
+		fmt.Fprintf(tw, "%s\t%d\t%s\n", node.Type, node.Id, node.Name)
+	}
+
+	return tw.Flush()
+}
+
+func graphCommand(ctx context.Context, w io.Writer, args []string) error {
+	fs := flag.NewFlagSet("graph", flag.ContinueOnError)
+	depth := fs.Int("depth", min(2, config.Graph.MaxDepth), "number of levels to expand")
+	fanOut := fs.Int("fanout", config.Graph.FanOut, "credits followed per movie or person")
+	format := fs.String("format", "dot", "output format: dot or json")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if len(positional) != 2 || (positional[0] != graph.Movie && positional[0] != graph.Person) {
+		return errors.New("usage: blunt graph <movie|person> <id>")
+	}
+
+	id, err := strconv.ParseInt(positional[1], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid id %q", positional[1])
+	}
+
+	err = checkGraphLimits(*depth, *fanOut)
+	if err != nil {
+		return err
+	}
+
+	g, err := graph.Neighbourhood(ctx, client, nil, positional[0], id, *depth, *fanOut)
+	if err != nil {
+		return err
+	}
+
+	switch *format {
+	case "dot":
+		return g.WriteDot(w)
+	case "json":
+		return writeJson(w, g)
+	}
+
+	return fmt.Errorf("unknown format %q", *format)
+}
+
+func configCommand(ctx context.Context, w io.Writer, args []string) error {
+	if len(args) != 1 || args[0] != "check" {
+		return errors.New("usage: blunt config check")
+	}
+
+	err := config.Validate()
+	if err != nil {
+		return fmt.Errorf("invalid configuration:\n%w", err)
+	}
+
+	client = tmdb.NewClient(config.TmdbOptions())
+
+	err = verifyCredentials(ctx)
+	if err != nil {
+		return err
+	}
+
+	redacted := config
+	if redacted.Token != "" {
+		redacted.Token = "<redacted>"
+	}
+	if redacted.ApiKey != "" {
+		redacted.ApiKey = "<redacted>"
+	}
+
+	data, err := yaml.Marshal(redacted)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(w, "configuration is valid")
+	_, err = w.Write(data)
+	return err
+}
+
+// verifyCredentials fails with an explanation instead of letting every page
+// render empty when TMDB rejects the configured credentials.
+func verifyCredentials(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	err := client.VerifyCredentials(ctx)
+	if errors.Is(err, tmdb.ErrInvalidCredentials) {
+		if config.Tmdb.Auth == tmdb.AuthApiKey {
+			return fmt.Errorf("TMDB rejected the configured api_key, check that it is a valid v3 API key: %w", err)
+		}
+
+		return fmt.Errorf("TMDB rejected the configured token, check that it is a valid v4 read access token or set tmdb.auth to api_key for v3 keys: %w", err)
+	}
+	if err != nil {
+		return fmt.Errorf("could not verify TMDB credentials: %w", err)
+	}
+
+	// the threshold can only be checked against TMDB's certifications
+	err = client.CheckFamily(ctx)
+	if err != nil {
+		return fmt.Errorf("family.max_certification: %w", err)
+	}
+
+	return nil
+}
+
+// refreshImageConfiguration keeps the image sizes in sync with TMDB, which
+// recommends refreshing them every few days.
+func refreshImageConfiguration(ctx context.Context) {
+	ticker := time.NewTicker(24 * time.Hour)
+	defer ticker.Stop()
+
+	for {
+		err := client.LoadImageConfiguration(ctx)
+		if err != nil {
+			slog.WarnContext(ctx, "could not load TMDB image configuration, keeping the previous one", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func checkGraphLimits(depth int, fanOut int) error {
+	if depth < 1 || depth > config.Graph.MaxDepth {
+		return fmt.Errorf("depth must be between 1 and %d", config.Graph.MaxDepth)
+	}
+
+	if fanOut < 1 || fanOut > config.Graph.FanOut {
+		return fmt.Errorf("fanout must be between 1 and %d", config.Graph.FanOut)
+	}
+
+	return nil
+}
+
+// parseArgs allows flags to appear before, between and after positional
+// arguments, e.g. `blunt graph movie 550 --depth 3`.
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	positional := make([]string, 0)
+
+	for {
+		err := fs.Parse(args)
+		if err != nil {
+			return nil, err
+		}
+
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+func writeJson(w io.Writer, v any) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(v)
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/m4tthewde/blunt/graph"
+	"github.com/m4tthewde/blunt/tmdb"
+)
+
+// fakeHeat serves Heat, Al Pacino and Robert De Niro, who share it, and
+// points the CLI's client at it with the default configuration.
+func fakeHeat(t *testing.T) {
+	t.Helper()
+
+	responses := map[string]any{
+		"/search/movie": tmdb.MovieSearchResponse{Results: []tmdb.MovieSearchResult{
+			{Id: 949, Title: "Heat", ReleaseDate: "1995-12-15", Popularity: 30},
+		}},
+		"/search/person": tmdb.PeopleSearchResponse{Results: []tmdb.PeopleSearchResult{
+			{Id: 1158, Name: "Al Pacino", Popularity: 20},
+		}},
+		"/movie/949": tmdb.MovieDetailsResponse{Id: 949, Title: "Heat", ReleaseDate: "1995-12-15", Runtime: 170, Tagline: "A Los Angeles crime saga"},
+		"/movie/949/credits": tmdb.MovieCreditsResponse{Id: 949, Cast: []tmdb.MovieCastMember{
+			{Id: 1158, Name: "Al Pacino", Character: "Lt. Vincent Hanna"},
+			{Id: 380, Name: "Robert De Niro", Character: "Neil McCauley"},
+		}},
+		"/person/1158": tmdb.PeopleResponse{Id: 1158, Name: "Al Pacino", Birthday: "1940-04-25"},
+		"/person/1158/movie_credits": tmdb.PeopleCreditsResponse{Cast: []tmdb.PeopleCredit{
+			{Id: 949, Title: "Heat", ReleaseDate: "1995-12-15"},
+			// unreleased credits aren't listed
+			{Id: 1000, Title: "Untitled"},
+		}},
+		"/movie/1000/credits": tmdb.MovieCreditsResponse{Id: 1000},
+		"/person/380":         tmdb.PeopleResponse{Id: 380, Name: "Robert De Niro"},
+		"/person/380/movie_credits": tmdb.PeopleCreditsResponse{Cast: []tmdb.PeopleCredit{
+			{Id: 949, Title: "Heat", ReleaseDate: "1995-12-15"},
+		}},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response, ok := responses[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		json.NewEncoder(w).Encode(response)
+	}))
+	t.Cleanup(server.Close)
+
+	savedClient, savedConfig := client, config
+	t.Cleanup(func() { client, config = savedClient, savedConfig })
+
+	config = defaultConfig()
+	client = tmdb.NewClient(tmdb.Options{BaseUrl: server.URL})
+}
+
+func TestCommands(t *testing.T) {
+	fakeHeat(t)
+
+	tests := []struct {
+		name    string
+		command func(ctx context.Context, w io.Writer, args []string) error
+		args    []string
+		want    []string
+		notWant []string
+	}{
+		{
+			name:    "search",
+			command: searchCommand,
+			args:    []string{"heat"},
+			want:    []string{"TYPE", "movie   949   Heat", "person  1158  Al Pacino"},
+		},
+		{
+			name:    "search as JSON",
+			command: searchCommand,
+			args:    []string{"--json", "heat"},
+			want:    []string{`"Name": "Heat"`, `"Year": "1995"`},
+		},
+		{
+			name:    "movie",
+			command: movieCommand,
+			args:    []string{"949"},
+			want:    []string{"Title:", "Heat", "170min", "Robert De Niro", "Neil McCauley"},
+		},
+		{
+			name:    "person",
+			command: personCommand,
+			args:    []string{"1158"},
+			want:    []string{"Al Pacino", "1940-04-25", "949  Heat   1995"},
+			notWant: []string{"Untitled"},
+		},
+		{
+			name:    "person as JSON",
+			command: personCommand,
+			args:    []string{"1158", "--json"},
+			want:    []string{`"person": {`, `"credits": [`},
+			notWant: []string{"Untitled"},
+		},
+		{
+			name:    "path",
+			command: pathCommand,
+			args:    []string{"1158", "380"},
+			want:    []string{"person  1158  Al Pacino", "movie   949   Heat", "person  380   Robert De Niro"},
+		},
+		{
+			// flags may follow the positional arguments
+			name:    "graph",
+			command: graphCommand,
+			args:    []string{"movie", "949", "--depth", "1"},
+			want:    []string{"digraph blunt {", `"movie:949" [label="Heat", shape=box];`, `"movie:949" -> "person:380";`},
+			notWant: []string{`"person:1158" ->`},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var out strings.Builder
+
+			err := test.command(context.Background(), &out, test.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, want := range test.want {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("output doesn't contain %q:\n%s", want, out.String())
+				}
+			}
+
+			for _, notWant := range test.notWant {
+				if strings.Contains(out.String(), notWant) {
+					t.Errorf("output contains %q:\n%s", notWant, out.String())
+				}
+			}
+		})
+	}
+}
+
+func TestGraphCommandJson(t *testing.T) {
+	fakeHeat(t)
+
+	var out strings.Builder
+
+	err := graphCommand(context.Background(), &out, []string{"--format", "json", "--depth", "2", "person", "1158"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var g graph.Graph
+
+	err = json.Unmarshal([]byte(out.String()), &g)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Al Pacino, Heat, the unreleased movie and Robert De Niro
+	if g.Root != "person:1158" || len(g.Nodes) != 4 {
+		t.Errorf("root %s, %d nodes", g.Root, len(g.Nodes))
+	}
+}
+
+func TestCommandErrors(t *testing.T) {
+	fakeHeat(t)
+
+	tests := []struct {
+		name    string
+		command func(ctx context.Context, w io.Writer, args []string) error
+		args    []string
+		want    string
+	}{
+		{"search without query", searchCommand, nil, "usage: blunt search"},
+		{"unknown flag", searchCommand, []string{"--verbose", "heat"}, "flag provided but not defined"},
+		{"movie without id", movieCommand, nil, "usage: blunt movie"},
+		{"unknown movie", movieCommand, []string{"1"}, "404 Not Found"},
+		{"person with two ids", personCommand, []string{"1158", "380"}, "usage: blunt person"},
+		{"path with one person", pathCommand, []string{"1158"}, "usage: blunt path"},
+		{"path with a name", pathCommand, []string{"pacino", "380"}, `invalid person id "pacino"`},
+		{"path too deep", pathCommand, []string{"1158", "380", "--depth", "5"}, "depth must be between 1 and 4"},
+		{"path fanning out too far", pathCommand, []string{"1158", "380", "--fanout", "6"}, "fanout must be between 1 and 5"},
+		{"graph of a series", graphCommand, []string{"tv", "1"}, "usage: blunt graph"},
+		{"graph with a name", graphCommand, []string{"movie", "heat"}, `invalid id "heat"`},
+		{"graph depth 0", graphCommand, []string{"movie", "949", "--depth", "0"}, "depth must be between 1 and 4"},
+		{"graph format", graphCommand, []string{"movie", "949", "--format", "svg"}, `unknown format "svg"`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var out strings.Builder
+
+			err := test.command(context.Background(), &out, test.args)
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Errorf("error %v, want one containing %q", err, test.want)
+			}
+		})
+	}
+}
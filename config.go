@@ -0,0 +1,866 @@
+package main
+
+import (
+	"cmp"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"net"
+	"net/netip"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/m4tthewde/blunt/logging"
+	"github.com/m4tthewde/blunt/ratelimit"
+	"github.com/m4tthewde/blunt/tmdb"
+	"github.com/m4tthewde/blunt/tracing"
+	"github.com/m4tthewde/blunt/users"
+	"gopkg.in/yaml.v3"
+)
+
+type Config struct {
+	Listen    string          `yaml:"listen"`
+	Server    ServerConfig    `yaml:"server"`
+	Token     string          `yaml:"token"`
+	TokenFile string          `yaml:"token_file"`
+	ApiKey    string          `yaml:"api_key"`
+	Tmdb      TmdbConfig      `yaml:"tmdb"`
+	Cache     CacheConfig     `yaml:"cache"`
+	RateLimit RateLimitConfig `yaml:"rate_limit"`
+	Clients   ClientsConfig   `yaml:"clients"`
+	Graph     GraphConfig     `yaml:"graph"`
+	Images    ImagesConfig    `yaml:"images"`
+	GraphQL   GraphQLConfig   `yaml:"graphql"`
+	Metrics   MetricsConfig   `yaml:"metrics"`
+	Tracing   TracingConfig   `yaml:"tracing"`
+	Database  string          `yaml:"database"`
+	Auth      AuthConfig      `yaml:"auth"`
+	Follow    FollowConfig    `yaml:"follow"`
+	Family    FamilyConfig    `yaml:"family"`
+	LogLevel  string          `yaml:"log_level"`
+	LogFormat string          `yaml:"log_format"`
+}
+
+type ServerConfig struct {
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout"`
+	ReadTimeout       time.Duration `yaml:"read_timeout"`
+	// WriteTimeout bounds the whole request, including TMDB lookups, so it
+	// has to leave room for slow graph and path searches.
+	WriteTimeout time.Duration `yaml:"write_timeout"`
+	IdleTimeout  time.Duration `yaml:"idle_timeout"`
+	// ShutdownTimeout is how long in-flight requests may take to finish on
+	// SIGINT or SIGTERM.
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+	// TLSCert and TLSKey enable HTTPS. The files are reloaded when they
+	// change, e.g. after a certificate renewal.
+	TLSCert string `yaml:"tls_cert"`
+	TLSKey  string `yaml:"tls_key"`
+	// SecureCookies marks session and CSRF cookies Secure on plain HTTP
+	// requests too, for a reverse proxy that terminates TLS.
+	SecureCookies bool `yaml:"secure_cookies"`
+}
+
+type TmdbConfig struct {
+	// Auth selects how requests are authenticated: "bearer" uses token, a v4
+	// read access token, "api_key" uses api_key, a classic v3 key.
+	Auth     string `yaml:"auth"`
+	BaseUrl  string `yaml:"base_url"`
+	Language string `yaml:"language"`
+	Region   string `yaml:"region"`
+	// IncludeAdult lets searches find adult titles.
+	IncludeAdult bool `yaml:"include_adult"`
+}
+
+type CacheConfig struct {
+	TTL  time.Duration `yaml:"ttl"`
+	Size int           `yaml:"size"`
+}
+
+type RateLimitConfig struct {
+	RequestsPerSecond float64 `yaml:"requests_per_second"`
+	Burst             int     `yaml:"burst"`
+}
+
+// ClientsConfig limits inbound requests per client, as opposed to
+// RateLimitConfig which limits the requests sent to TMDB.
+type ClientsConfig struct {
+	RateLimit bool `yaml:"rate_limit"`
+	// Budgets are cost units per minute, most requests cost one, graph and
+	// path requests cost one per level of depth.
+	Search BudgetConfig `yaml:"search"`
+	Detail BudgetConfig `yaml:"detail"`
+	Graph  BudgetConfig `yaml:"graph"`
+	Login  BudgetConfig `yaml:"login"`
+	Images BudgetConfig `yaml:"images"`
+	// Allow lists addresses or CIDR ranges of internal clients that are never
+	// limited.
+	Allow []string `yaml:"allow"`
+	// TrustedProxies lists reverse proxies whose X-Forwarded-For header is
+	// believed, and in auth proxy mode the user header.
+	TrustedProxies []string `yaml:"trusted_proxies"`
+}
+
+type BudgetConfig struct {
+	PerMinute float64 `yaml:"per_minute"`
+	Burst     int     `yaml:"burst"`
+}
+
+type GraphConfig struct {
+	FanOut   int `yaml:"fan_out"`
+	MaxDepth int `yaml:"max_depth"`
+	// MaxLookups is the number of TMDB lookups a single graph or path search
+	// of the API may cause.
+	MaxLookups int `yaml:"max_lookups"`
+}
+
+type ImagesConfig struct {
+	// Proxy serves images through /img/ instead of linking to TMDB.
+	Proxy      bool   `yaml:"proxy"`
+	CacheDir   string `yaml:"cache_dir"`
+	MaxCacheMB int64  `yaml:"max_cache_mb"`
+	Resize     bool   `yaml:"resize"`
+	WebP       bool   `yaml:"webp"`
+}
+
+type GraphQLConfig struct {
+	MaxDepth int `yaml:"max_depth"`
+	// MaxComplexity is the number of distinct TMDB lookups a single query may
+	// cause.
+	MaxComplexity int `yaml:"max_complexity"`
+}
+
+type MetricsConfig struct {
+	// Enabled serves Prometheus metrics at /metrics.
+	Enabled bool `yaml:"enabled"`
+}
+
+type TracingConfig struct {
+	// Exporter is none, stdout or otlp.
+	Exporter string `yaml:"exporter"`
+	// Endpoint of the OTLP/HTTP collector. When empty the standard
+	// OTEL_EXPORTER_OTLP_* environment variables apply.
+	Endpoint    string  `yaml:"endpoint"`
+	ServiceName string  `yaml:"service_name"`
+	SampleRatio float64 `yaml:"sample_ratio"`
+}
+
+type AuthConfig struct {
+	// Mode is local for accounts with passwords, or proxy when an SSO gateway
+	// authenticates users and passes their name in ProxyHeader.
+	Mode string `yaml:"mode"`
+	// Registration lets visitors create local accounts.
+	Registration bool          `yaml:"registration"`
+	SessionTTL   time.Duration `yaml:"session_ttl"`
+	ProxyHeader  string        `yaml:"proxy_header"`
+}
+
+type FollowConfig struct {
+	// Interval between checks of a followed person's credits.
+	Interval time.Duration `yaml:"interval"`
+	// Webhooks lets users add webhooks. Deliveries only go to public
+	// addresses, but they are still requests the server makes for anyone
+	// with an account.
+	Webhooks bool `yaml:"webhooks"`
+}
+
+// FamilyConfig hides adult titles and movies certified above a threshold
+// everywhere, in the UI as well as the API and GraphQL.
+type FamilyConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Region whose certifications count, tmdb.region when empty.
+	Region string `yaml:"region"`
+	// MaxCertification is the highest certification of Region allowed.
+	MaxCertification string `yaml:"max_certification"`
+	// AllowUnrated lets movies without a certification in Region through.
+	AllowUnrated bool `yaml:"allow_unrated"`
+}
+
+var config Config
+
+func defaultConfig() Config {
+	return Config{
+		Listen: ":8080",
+		Server: ServerConfig{
+			ReadHeaderTimeout: 5 * time.Second,
+			ReadTimeout:       15 * time.Second,
+			WriteTimeout:      60 * time.Second,
+			IdleTimeout:       2 * time.Minute,
+			ShutdownTimeout:   30 * time.Second,
+		},
+		Tmdb: TmdbConfig{
+			Auth:     tmdb.AuthBearer,
+			BaseUrl:  tmdb.DefaultBaseUrl,
+			Language: "en-US",
+			Region:   "US",
+		},
+		Cache: CacheConfig{
+			TTL:  10 * time.Minute,
+			Size: 1000,
+		},
+		RateLimit: RateLimitConfig{
+			RequestsPerSecond: 40,
+			Burst:             20,
+		},
+		Clients: ClientsConfig{
+			RateLimit: true,
+			Search:    BudgetConfig{PerMinute: 60, Burst: 20},
+			Detail:    BudgetConfig{PerMinute: 120, Burst: 40},
+			Graph:     BudgetConfig{PerMinute: 60, Burst: 30},
+			Login:     BudgetConfig{PerMinute: 10, Burst: 5},
+			Images:    BudgetConfig{PerMinute: 600, Burst: 200},
+		},
+		Graph: GraphConfig{
+			FanOut:     5,
+			MaxDepth:   4,
+			MaxLookups: 200,
+		},
+		Images: ImagesConfig{
+			Proxy:      true,
+			CacheDir:   defaultCacheDir("images"),
+			MaxCacheMB: 512,
+			Resize:     true,
+		},
+		GraphQL: GraphQLConfig{
+			MaxDepth:      10,
+			MaxComplexity: 200,
+		},
+		Metrics: MetricsConfig{
+			Enabled: true,
+		},
+		Tracing: TracingConfig{
+			Exporter:    tracing.ExporterNone,
+			ServiceName: "blunt",
+			SampleRatio: 1,
+		},
+		Database: defaultDataDir("blunt.db"),
+		Auth: AuthConfig{
+			Mode:         users.ModeLocal,
+			Registration: true,
+			SessionTTL:   30 * 24 * time.Hour,
+			ProxyHeader:  "X-Forwarded-User",
+		},
+		Follow: FollowConfig{
+			Interval: 6 * time.Hour,
+		},
+		Family: FamilyConfig{
+			MaxCertification: "PG-13",
+			AllowUnrated:     true,
+		},
+		LogLevel:  "info",
+		LogFormat: logging.FormatText,
+	}
+}
+
+func defaultCacheDir(name string) string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+
+	return filepath.Join(dir, "blunt", name)
+}
+
+// defaultDataDir follows the XDG base directory spec, there's no portable
+// data directory in the standard library.
+func defaultDataDir(name string) string {
+	dir := os.Getenv("XDG_DATA_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return name
+		}
+
+		dir = filepath.Join(home, ".local", "share")
+	}
+
+	return filepath.Join(dir, "blunt", name)
+}
+
+// loadConfig layers defaults, the config file, environment variables and
+// command line flags, in that order. It returns the arguments left after the
+// global flags.
+func loadConfig(args []string) (Config, []string, error) {
+	c := defaultConfig()
+
+	fs := flag.NewFlagSet("blunt", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	configPath := fs.String("config", envOr("BLUNT_CONFIG", "config.yaml"), "path to the config file")
+	listen := fs.String("listen", "", "address the web server listens on")
+	token := fs.String("token", "", "TMDB access token")
+	tokenFile := fs.String("token-file", "", "file containing the TMDB access token")
+	apiKey := fs.String("api-key", "", "TMDB v3 api key")
+	auth := fs.String("auth", "", "TMDB authentication: bearer or api_key")
+	baseUrl := fs.String("tmdb-base-url", "", "TMDB API base URL")
+	language := fs.String("language", "", "default TMDB language, e.g. en-US")
+	region := fs.String("region", "", "default TMDB region, e.g. US")
+	cacheTTL := fs.Duration("cache-ttl", 0, "how long TMDB responses are cached")
+	cacheSize := fs.Int("cache-size", -1, "number of cached TMDB responses, 0 disables the cache")
+	rateLimit := fs.Float64("rate-limit", -1, "TMDB requests per second, 0 disables rate limiting")
+	rateBurst := fs.Int("rate-burst", 0, "TMDB request burst size")
+	fanOut := fs.Int("graph-fanout", 0, "credits expanded per graph node")
+	maxDepth := fs.Int("graph-max-depth", 0, "maximum graph and path depth")
+	imageProxy := fs.Bool("image-proxy", false, "serve images through the local image proxy")
+	imageCacheDir := fs.String("image-cache-dir", "", "directory of the image proxy cache")
+	logLevel := fs.String("log-level", "", "log level: debug, info, warn or error")
+	logFormat := fs.String("log-format", "", "log format: text or json")
+
+	err := fs.Parse(args)
+	if err != nil {
+		return c, nil, err
+	}
+
+	err = c.layer(func() error {
+		return c.loadFile(*configPath, isFlagSet(fs, "config") || os.Getenv("BLUNT_CONFIG") != "")
+	})
+	if err != nil {
+		return c, nil, err
+	}
+
+	err = c.layer(c.loadEnv)
+	if err != nil {
+		return c, nil, err
+	}
+
+	err = c.layer(func() error {
+		fs.Visit(func(f *flag.Flag) {
+			switch f.Name {
+			case "listen":
+				c.Listen = *listen
+			case "token":
+				c.Token = *token
+			case "token-file":
+				c.TokenFile = *tokenFile
+			case "api-key":
+				c.ApiKey = *apiKey
+			case "auth":
+				c.Tmdb.Auth = *auth
+			case "tmdb-base-url":
+				c.Tmdb.BaseUrl = *baseUrl
+			case "language":
+				c.Tmdb.Language = *language
+			case "region":
+				c.Tmdb.Region = *region
+			case "cache-ttl":
+				c.Cache.TTL = *cacheTTL
+			case "cache-size":
+				c.Cache.Size = *cacheSize
+			case "rate-limit":
+				c.RateLimit.RequestsPerSecond = *rateLimit
+			case "rate-burst":
+				c.RateLimit.Burst = *rateBurst
+			case "graph-fanout":
+				c.Graph.FanOut = *fanOut
+			case "graph-max-depth":
+				c.Graph.MaxDepth = *maxDepth
+			case "image-proxy":
+				c.Images.Proxy = *imageProxy
+			case "image-cache-dir":
+				c.Images.CacheDir = *imageCacheDir
+			case "log-level":
+				c.LogLevel = *logLevel
+			case "log-format":
+				c.LogFormat = *logFormat
+			}
+		})
+
+		return nil
+	})
+	if err != nil {
+		return c, nil, err
+	}
+
+	return c, fs.Args(), nil
+}
+
+// loadFile reads the YAML config file. A missing file is only an error when
+// its path was given explicitly.
+func (c *Config) loadFile(path string, required bool) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && !required {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+
+	decoder := yaml.NewDecoder(strings.NewReader(string(data)))
+	decoder.KnownFields(true)
+
+	err = decoder.Decode(c)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("config: %s: %w", path, err)
+	}
+
+	return nil
+}
+
+func (c *Config) loadEnv() error {
+	stringVars := map[string]*string{
+		"BLUNT_LISTEN":            &c.Listen,
+		"TMDB_TOKEN":              &c.Token,
+		"TMDB_TOKEN_FILE":         &c.TokenFile,
+		"TMDB_API_KEY":            &c.ApiKey,
+		"BLUNT_TMDB_AUTH":         &c.Tmdb.Auth,
+		"BLUNT_TMDB_BASE_URL":     &c.Tmdb.BaseUrl,
+		"BLUNT_LANGUAGE":          &c.Tmdb.Language,
+		"BLUNT_REGION":            &c.Tmdb.Region,
+		"BLUNT_LOG_LEVEL":         &c.LogLevel,
+		"BLUNT_LOG_FORMAT":        &c.LogFormat,
+		"BLUNT_TRACING_EXPORTER":  &c.Tracing.Exporter,
+		"BLUNT_TRACING_ENDPOINT":  &c.Tracing.Endpoint,
+		"BLUNT_TLS_CERT":          &c.Server.TLSCert,
+		"BLUNT_TLS_KEY":           &c.Server.TLSKey,
+		"BLUNT_IMAGE_CACHE_DIR":   &c.Images.CacheDir,
+		"BLUNT_DATABASE":          &c.Database,
+		"BLUNT_AUTH_MODE":         &c.Auth.Mode,
+		"BLUNT_AUTH_PROXY_HEADER": &c.Auth.ProxyHeader,
+		"BLUNT_FAMILY_REGION":     &c.Family.Region,
+		"BLUNT_MAX_CERTIFICATION": &c.Family.MaxCertification,
+	}
+
+	for name, target := range stringVars {
+		value, ok := os.LookupEnv(name)
+		if ok {
+			*target = value
+		}
+	}
+
+	intVars := map[string]*int{
+		"BLUNT_CACHE_SIZE":             &c.Cache.Size,
+		"BLUNT_RATE_BURST":             &c.RateLimit.Burst,
+		"BLUNT_GRAPH_FANOUT":           &c.Graph.FanOut,
+		"BLUNT_GRAPH_MAX_DEPTH":        &c.Graph.MaxDepth,
+		"BLUNT_GRAPH_MAX_LOOKUPS":      &c.Graph.MaxLookups,
+		"BLUNT_GRAPHQL_MAX_DEPTH":      &c.GraphQL.MaxDepth,
+		"BLUNT_GRAPHQL_MAX_COMPLEXITY": &c.GraphQL.MaxComplexity,
+	}
+
+	for name, target := range intVars {
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("config: %s: %q is not an integer", name, value)
+		}
+
+		*target = parsed
+	}
+
+	boolVars := map[string]*bool{
+		"BLUNT_IMAGE_PROXY":       &c.Images.Proxy,
+		"BLUNT_IMAGE_RESIZE":      &c.Images.Resize,
+		"BLUNT_IMAGE_WEBP":        &c.Images.WebP,
+		"BLUNT_METRICS":           &c.Metrics.Enabled,
+		"BLUNT_CLIENT_RATE_LIMIT": &c.Clients.RateLimit,
+		"BLUNT_REGISTRATION":      &c.Auth.Registration,
+		"BLUNT_WEBHOOKS":          &c.Follow.Webhooks,
+		"BLUNT_FAMILY":            &c.Family.Enabled,
+		"BLUNT_INCLUDE_ADULT":     &c.Tmdb.IncludeAdult,
+		"BLUNT_SECURE_COOKIES":    &c.Server.SecureCookies,
+	}
+
+	for name, target := range boolVars {
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("config: %s: %q is not a boolean", name, value)
+		}
+
+		*target = parsed
+	}
+
+	value, ok := os.LookupEnv("BLUNT_CACHE_TTL")
+	if ok {
+		ttl, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("config: BLUNT_CACHE_TTL: %q is not a duration", value)
+		}
+
+		c.Cache.TTL = ttl
+	}
+
+	value, ok = os.LookupEnv("BLUNT_RATE_LIMIT")
+	if ok {
+		limit, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("config: BLUNT_RATE_LIMIT: %q is not a number", value)
+		}
+
+		c.RateLimit.RequestsPerSecond = limit
+	}
+
+	return nil
+}
+
+// layer applies one layer of the configuration and resolves its token
+// file right away, so a later layer's token file replaces an earlier
+// layer's token and the other way around. Within a layer, a token given
+// directly wins over the file.
+func (c *Config) layer(load func() error) error {
+	token, tokenFile := c.Token, c.TokenFile
+	c.Token, c.TokenFile = "", ""
+
+	err := load()
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case c.Token != "":
+		// given directly
+	case c.TokenFile != "":
+		data, err := os.ReadFile(c.TokenFile)
+		if err != nil {
+			return fmt.Errorf("config: token file: %w", err)
+		}
+
+		c.Token = strings.TrimSpace(string(data))
+	default:
+		c.Token, c.TokenFile = token, tokenFile
+	}
+
+	return nil
+}
+
+// Validate reports every invalid setting at once.
+func (c *Config) Validate() error {
+	errs := make([]error, 0)
+
+	switch c.Tmdb.Auth {
+	case tmdb.AuthBearer:
+		if c.Token == "" {
+			errs = append(errs, errors.New("token is required (set token, token_file, TMDB_TOKEN or --token)"))
+		}
+	case tmdb.AuthApiKey:
+		if c.ApiKey == "" {
+			errs = append(errs, errors.New("api_key is required when tmdb.auth is api_key (set api_key, TMDB_API_KEY or --api-key)"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("tmdb.auth: %q is not one of bearer or api_key", c.Tmdb.Auth))
+	}
+
+	_, _, err := net.SplitHostPort(c.Listen)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("listen: %q is not a valid address: %w", c.Listen, err))
+	}
+
+	timeouts := map[string]time.Duration{
+		"server.read_header_timeout": c.Server.ReadHeaderTimeout,
+		"server.read_timeout":        c.Server.ReadTimeout,
+		"server.write_timeout":       c.Server.WriteTimeout,
+		"server.idle_timeout":        c.Server.IdleTimeout,
+		"server.shutdown_timeout":    c.Server.ShutdownTimeout,
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(timeouts)) {
+		if timeouts[name] <= 0 {
+			errs = append(errs, fmt.Errorf("%s must be positive", name))
+		}
+	}
+
+	if (c.Server.TLSCert == "") != (c.Server.TLSKey == "") {
+		errs = append(errs, errors.New("server.tls_cert and server.tls_key must be set together"))
+	}
+
+	baseUrl, err := url.Parse(c.Tmdb.BaseUrl)
+	if err != nil || (baseUrl.Scheme != "http" && baseUrl.Scheme != "https") || baseUrl.Host == "" {
+		errs = append(errs, fmt.Errorf("tmdb.base_url: %q is not an http(s) URL", c.Tmdb.BaseUrl))
+	}
+
+	if c.Tmdb.Language == "" {
+		errs = append(errs, errors.New("tmdb.language must not be empty"))
+	}
+
+	if len(c.Tmdb.Region) != 2 {
+		errs = append(errs, fmt.Errorf("tmdb.region: %q is not a two letter country code", c.Tmdb.Region))
+	}
+
+	if c.Cache.TTL < 0 {
+		errs = append(errs, errors.New("cache.ttl must not be negative"))
+	}
+
+	if c.Cache.Size < 0 {
+		errs = append(errs, errors.New("cache.size must not be negative"))
+	}
+
+	if c.RateLimit.RequestsPerSecond < 0 {
+		errs = append(errs, errors.New("rate_limit.requests_per_second must not be negative"))
+	}
+
+	if c.RateLimit.RequestsPerSecond > 0 && c.RateLimit.Burst < 1 {
+		errs = append(errs, errors.New("rate_limit.burst must be at least 1"))
+	}
+
+	budgets := map[string]BudgetConfig{
+		"clients.search": c.Clients.Search,
+		"clients.detail": c.Clients.Detail,
+		"clients.graph":  c.Clients.Graph,
+		"clients.login":  c.Clients.Login,
+		"clients.images": c.Clients.Images,
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(budgets)) {
+		if c.Clients.RateLimit && budgets[name].PerMinute <= 0 {
+			errs = append(errs, fmt.Errorf("%s.per_minute must be positive", name))
+		}
+
+		if c.Clients.RateLimit && budgets[name].Burst < 1 {
+			errs = append(errs, fmt.Errorf("%s.burst must be at least 1", name))
+		}
+	}
+
+	_, err = c.RateLimitOptions()
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	if c.Graph.FanOut < 1 {
+		errs = append(errs, errors.New("graph.fan_out must be at least 1"))
+	}
+
+	if c.Graph.MaxDepth < 1 {
+		errs = append(errs, errors.New("graph.max_depth must be at least 1"))
+	}
+
+	if c.Graph.MaxLookups < 1 {
+		errs = append(errs, errors.New("graph.max_lookups must be at least 1"))
+	}
+
+	if c.GraphQL.MaxDepth < 1 {
+		errs = append(errs, errors.New("graphql.max_depth must be at least 1"))
+	}
+
+	if c.GraphQL.MaxComplexity < 1 {
+		errs = append(errs, errors.New("graphql.max_complexity must be at least 1"))
+	}
+
+	if c.Images.Proxy && c.Images.CacheDir == "" {
+		errs = append(errs, errors.New("images.cache_dir must not be empty when images.proxy is enabled"))
+	}
+
+	if c.Images.Proxy && c.Images.MaxCacheMB < 1 {
+		errs = append(errs, errors.New("images.max_cache_mb must be at least 1"))
+	}
+
+	_, err = c.SlogLevel()
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	switch c.Tracing.Exporter {
+	case tracing.ExporterNone, tracing.ExporterStdout, tracing.ExporterOtlp:
+	default:
+		errs = append(errs, fmt.Errorf("tracing.exporter: %q is not one of none, stdout or otlp", c.Tracing.Exporter))
+	}
+
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		errs = append(errs, errors.New("tracing.sample_ratio must be between 0 and 1"))
+	}
+
+	if c.LogFormat != logging.FormatText && c.LogFormat != logging.FormatJson {
+		errs = append(errs, fmt.Errorf("log_format: %q is not one of text or json", c.LogFormat))
+	}
+
+	if c.Database == "" {
+		errs = append(errs, errors.New("database must not be empty"))
+	}
+
+	switch c.Auth.Mode {
+	case users.ModeLocal:
+	case users.ModeProxy:
+		if c.Auth.ProxyHeader == "" {
+			errs = append(errs, errors.New("auth.proxy_header must not be empty in proxy mode"))
+		}
+
+		// anybody could claim to be anybody otherwise
+		if len(c.Clients.TrustedProxies) == 0 {
+			errs = append(errs, errors.New("clients.trusted_proxies must list the SSO gateway in auth proxy mode"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("auth.mode: %q is not one of local or proxy", c.Auth.Mode))
+	}
+
+	if c.Auth.SessionTTL <= 0 {
+		errs = append(errs, errors.New("auth.session_ttl must be positive"))
+	}
+
+	if c.Family.Enabled {
+		if c.Family.Region != "" && len(c.Family.Region) != 2 {
+			errs = append(errs, fmt.Errorf("family.region: %q is not a two letter country code", c.Family.Region))
+		}
+
+		if c.Family.MaxCertification == "" {
+			errs = append(errs, errors.New("family.max_certification must not be empty in family mode"))
+		}
+
+		if c.Tmdb.IncludeAdult {
+			errs = append(errs, errors.New("tmdb.include_adult can't be enabled in family mode"))
+		}
+	}
+
+	// TMDB is asked for every followed person once per interval
+	if c.Follow.Interval < time.Minute {
+		errs = append(errs, errors.New("follow.interval must be at least 1m"))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (c *Config) SlogLevel() (slog.Level, error) {
+	var level slog.Level
+
+	err := level.UnmarshalText([]byte(c.LogLevel))
+	if err != nil {
+		return level, fmt.Errorf("log_level: %q is not one of debug, info, warn or error", c.LogLevel)
+	}
+
+	return level, nil
+}
+
+// Logger creates the logger selected by log_level and log_format.
+func (c *Config) Logger(w io.Writer) (*slog.Logger, error) {
+	level, err := c.SlogLevel()
+	if err != nil {
+		return nil, err
+	}
+
+	return logging.New(w, c.LogFormat, level)
+}
+
+func (c *Config) TracingOptions() tracing.Options {
+	return tracing.Options{
+		Exporter:    c.Tracing.Exporter,
+		Endpoint:    c.Tracing.Endpoint,
+		ServiceName: c.Tracing.ServiceName,
+		SampleRatio: c.Tracing.SampleRatio,
+	}
+}
+
+// RateLimitOptions returns nil options when clients aren't rate limited.
+func (c *Config) RateLimitOptions() (*ratelimit.Options, error) {
+	allow, err := parsePrefixes("clients.allow", c.Clients.Allow)
+	if err != nil {
+		return nil, err
+	}
+
+	trustedProxies, err := parsePrefixes("clients.trusted_proxies", c.Clients.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+
+	if !c.Clients.RateLimit {
+		return nil, nil
+	}
+
+	return &ratelimit.Options{
+		Budgets: map[ratelimit.Class]ratelimit.Budget{
+			ratelimit.Search: ratelimit.Budget(c.Clients.Search),
+			ratelimit.Detail: ratelimit.Budget(c.Clients.Detail),
+			ratelimit.Graph:  ratelimit.Budget(c.Clients.Graph),
+			ratelimit.Login:  ratelimit.Budget(c.Clients.Login),
+			ratelimit.Images: ratelimit.Budget(c.Clients.Images),
+		},
+		Allow:          allow,
+		TrustedProxies: trustedProxies,
+	}, nil
+}
+
+// parsePrefixes accepts CIDR ranges and single addresses.
+func parsePrefixes(name string, values []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(values))
+
+	for _, value := range values {
+		prefix, err := netip.ParsePrefix(value)
+		if err != nil {
+			ip, ipErr := netip.ParseAddr(value)
+			if ipErr != nil {
+				return nil, fmt.Errorf("%s: %q is neither an address nor a CIDR range", name, value)
+			}
+
+			prefix = netip.PrefixFrom(ip.Unmap(), ip.Unmap().BitLen())
+		}
+
+		prefixes = append(prefixes, prefix.Masked())
+	}
+
+	return prefixes, nil
+}
+
+func (c *Config) AuthOptions() users.Options {
+	// validated along with the rate limit options
+	trustedProxies, _ := parsePrefixes("clients.trusted_proxies", c.Clients.TrustedProxies)
+
+	return users.Options{
+		Mode:           c.Auth.Mode,
+		SessionTTL:     c.Auth.SessionTTL,
+		ProxyHeader:    c.Auth.ProxyHeader,
+		TrustedProxies: trustedProxies,
+		SecureCookies:  c.Server.SecureCookies,
+	}
+}
+
+func (c *Config) TmdbOptions() tmdb.Options {
+	return tmdb.Options{
+		BaseUrl:      c.Tmdb.BaseUrl,
+		AuthMode:     c.Tmdb.Auth,
+		Token:        c.Token,
+		ApiKey:       c.ApiKey,
+		Language:     c.Tmdb.Language,
+		Region:       c.Tmdb.Region,
+		CacheTTL:     c.Cache.TTL,
+		CacheSize:    c.Cache.Size,
+		RateLimit:    c.RateLimit.RequestsPerSecond,
+		RateBurst:    c.RateLimit.Burst,
+		IncludeAdult: c.Tmdb.IncludeAdult,
+		Family:       c.FamilyOptions(),
+	}
+}
+
+// FamilyOptions returns nil when family mode is off.
+func (c *Config) FamilyOptions() *tmdb.FamilyOptions {
+	if !c.Family.Enabled {
+		return nil
+	}
+
+	return &tmdb.FamilyOptions{
+		Region:           strings.ToUpper(cmp.Or(c.Family.Region, c.Tmdb.Region)),
+		MaxCertification: c.Family.MaxCertification,
+		AllowUnrated:     c.Family.AllowUnrated,
+	}
+}
+
+func envOr(name string, fallback string) string {
+	value, ok := os.LookupEnv(name)
+	if ok {
+		return value
+	}
+
+	return fallback
+}
+
+func isFlagSet(fs *flag.FlagSet, name string) bool {
+	set := false
+
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+
+	return set
+}
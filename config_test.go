@@ -0,0 +1,130 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTokenPrecedence(t *testing.T) {
+	dir := t.TempDir()
+
+	write := func(name string, content string) string {
+		path := filepath.Join(dir, name)
+
+		err := os.WriteFile(path, []byte(content), 0o600)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return path
+	}
+
+	fileToken := write("file.token", "from-file-token-file\n")
+	envToken := write("env.token", "from-env-token-file\n")
+	flagToken := write("flag.token", " from-flag-token-file ")
+
+	tests := []struct {
+		name   string
+		config string
+		env    map[string]string
+		args   []string
+		want   string
+	}{
+		{
+			name:   "config file token",
+			config: "token: from-config",
+			want:   "from-config",
+		},
+		{
+			name:   "config file token file",
+			config: "token_file: " + fileToken,
+			want:   "from-file-token-file",
+		},
+		{
+			name:   "token wins over the file of the same layer",
+			config: "token: from-config\ntoken_file: " + fileToken,
+			want:   "from-config",
+		},
+		{
+			name:   "env token file replaces the config file token",
+			config: "token: from-config",
+			env:    map[string]string{"TMDB_TOKEN_FILE": envToken},
+			want:   "from-env-token-file",
+		},
+		{
+			name:   "env token replaces the config file token file",
+			config: "token_file: " + fileToken,
+			env:    map[string]string{"TMDB_TOKEN": "from-env"},
+			want:   "from-env",
+		},
+		{
+			name:   "flag token file replaces the config file token",
+			config: "token: from-config",
+			args:   []string{"--token-file", flagToken},
+			want:   "from-flag-token-file",
+		},
+		{
+			name:   "flag token file replaces the env token",
+			config: "token: from-config",
+			env:    map[string]string{"TMDB_TOKEN": "from-env"},
+			args:   []string{"--token-file", flagToken},
+			want:   "from-flag-token-file",
+		},
+		{
+			name: "flag token replaces the env token file",
+			env:  map[string]string{"TMDB_TOKEN_FILE": envToken},
+			args: []string{"--token", "from-flag"},
+			want: "from-flag",
+		},
+		{
+			name:   "layers without a token keep the earlier one",
+			config: "token_file: " + fileToken,
+			args:   []string{"--listen", "127.0.0.1:9000"},
+			want:   "from-file-token-file",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for _, name := range []string{"TMDB_TOKEN", "TMDB_TOKEN_FILE", "BLUNT_CONFIG"} {
+				t.Setenv(name, "")
+				os.Unsetenv(name)
+			}
+
+			for name, value := range test.env {
+				t.Setenv(name, value)
+			}
+
+			args := append([]string{"--config", write("config.yaml", test.config)}, test.args...)
+
+			c, _, err := loadConfig(args)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if c.Token != test.want {
+				t.Errorf("token %q, want %q", c.Token, test.want)
+			}
+		})
+	}
+}
+
+func TestTokenFileMissing(t *testing.T) {
+	t.Setenv("TMDB_TOKEN", "")
+	os.Unsetenv("TMDB_TOKEN")
+	t.Setenv("TMDB_TOKEN_FILE", filepath.Join(t.TempDir(), "missing"))
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+
+	err := os.WriteFile(path, nil, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, err = loadConfig([]string{"--config", path, "--token", "from-flag"})
+	if err == nil || !strings.Contains(err.Error(), "token file") {
+		t.Errorf("error %v, want a token file error", err)
+	}
+}
@@ -0,0 +1,174 @@
+// Package csrf keeps other sites from submitting requests to the explorer in
+// the name of its visitors, e.g. to burn through the TMDB quota.
+package csrf
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+const (
+	CookieName = "csrf"
+	HeaderName = "X-CSRF-Token"
+	// FormField carries the token in plain HTML forms.
+	FormField = "csrf_token"
+)
+
+// tokens are what crypto/rand.Text returns
+var tokenPattern = regexp.MustCompile(`^[A-Z2-7]{26}$`)
+
+type tokenKey struct{}
+
+// Token returns the token of the request, pages send it back with every
+// unsafe request.
+func Token(ctx context.Context) string {
+	token, _ := ctx.Value(tokenKey{}).(string)
+
+	return token
+}
+
+// Headers returns the token as JSON object for htmx' hx-headers attribute.
+func Headers(ctx context.Context) string {
+	headers, _ := json.Marshal(map[string]string{HeaderName: Token(ctx)})
+
+	return string(headers)
+}
+
+type Options struct {
+	// TokenExempt lists path prefixes of endpoints for scripts, which have no
+	// cookie to match a token against. Browsers are still kept from reaching
+	// them cross-site.
+	TokenExempt []string
+	// SecureCookies marks the cookie Secure on plain HTTP requests too,
+	// behind a reverse proxy that terminates TLS.
+	SecureCookies bool
+}
+
+// Middleware hands out a token in a cookie, lasting as long as the browser
+// session, and rejects unsafe requests that came from another site or don't
+// carry the token.
+func Middleware(options Options, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := ""
+
+		cookie, err := r.Cookie(CookieName)
+		if err == nil && validToken(cookie.Value) {
+			token = cookie.Value
+		} else if acceptsHtml(r) {
+			// only pages need a token, images and scripts would only lose
+			// their cacheability to the cookie
+			token = Rotate(w, r, options.SecureCookies)
+		}
+
+		if !safeMethod(r.Method) {
+			err := verify(r, token, options.TokenExempt)
+			if err != nil {
+				slog.WarnContext(r.Context(), "rejected cross-site request",
+					"method", r.Method,
+					"path", r.URL.Path,
+					"reason", err,
+				)
+				http.Error(w, "forbidden: "+err.Error(), http.StatusForbidden)
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), tokenKey{}, token)))
+	})
+}
+
+// Rotate hands out a new token, logins and logouts call it so a token
+// known before doesn't carry over to the next session. The cookie is Secure
+// on HTTPS requests, or always when secure is set.
+func Rotate(w http.ResponseWriter, r *http.Request, secure bool) string {
+	token := rand.Text()
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     CookieName,
+		Value:    token,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   secure || r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	return token
+}
+
+func verify(r *http.Request, token string, tokenExempt []string) error {
+	err := SameOrigin(r)
+	if err != nil {
+		return err
+	}
+
+	for _, prefix := range tokenExempt {
+		if strings.HasPrefix(r.URL.Path, prefix) {
+			return nil
+		}
+	}
+
+	sent := r.Header.Get(HeaderName)
+	if sent == "" {
+		sent = r.PostFormValue(FormField)
+	}
+
+	if token == "" || subtle.ConstantTimeCompare([]byte(sent), []byte(token)) != 1 {
+		return errors.New("missing or invalid CSRF token")
+	}
+
+	return nil
+}
+
+// SameOrigin relies on the browser telling where a request came from. The
+// Fetch Metadata header is preferred, older browsers only send Origin and
+// clients that send neither aren't browsers.
+func SameOrigin(r *http.Request) error {
+	site := r.Header.Get("Sec-Fetch-Site")
+	if site != "" {
+		// none means the user typed or bookmarked the URL
+		if site != "same-origin" && site != "none" {
+			return errors.New("request came from another site")
+		}
+
+		return nil
+	}
+
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return nil
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil || u.Host != r.Host {
+		return errors.New("request came from another origin")
+	}
+
+	return nil
+}
+
+func safeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+
+	return false
+}
+
+func acceptsHtml(r *http.Request) bool {
+	return safeMethod(r.Method) && strings.Contains(r.Header.Get("Accept"), "text/html")
+}
+
+// validToken only accepts tokens looking like ours, anything else in the
+// cookie is replaced.
+func validToken(token string) bool {
+	return tokenPattern.MatchString(token)
+}
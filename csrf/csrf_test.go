@@ -0,0 +1,177 @@
+package csrf
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+var ok = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte(Token(r.Context())))
+})
+
+func cookie(t *testing.T, res *http.Response) string {
+	t.Helper()
+
+	for _, c := range res.Cookies() {
+		if c.Name == CookieName {
+			return c.Value
+		}
+	}
+
+	return ""
+}
+
+func TestMiddlewareHandsOutTokens(t *testing.T) {
+	handler := Middleware(Options{}, ok)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept", "text/html")
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	token := cookie(t, rec.Result())
+	if !validToken(token) {
+		t.Fatalf("cookie %q is not a token", token)
+	}
+
+	if rec.Body.String() != token {
+		t.Errorf("context token %q, cookie %q", rec.Body.String(), token)
+	}
+
+	// images and scripts don't get one
+	req = httptest.NewRequest(http.MethodGet, "/static/app.js", nil)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if cookie(t, rec.Result()) != "" {
+		t.Error("a script got a token")
+	}
+}
+
+func TestMiddlewareVerifies(t *testing.T) {
+	const token = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
+	tests := []struct {
+		name    string
+		path    string
+		cookie  string
+		header  string
+		form    string
+		headers map[string]string
+		want    int
+	}{
+		{name: "header", path: "/me/watchlist", cookie: token, header: token, want: http.StatusOK},
+		{name: "form field", path: "/me/watchlist", cookie: token, form: token, want: http.StatusOK},
+		{name: "missing token", path: "/me/watchlist", cookie: token, want: http.StatusForbidden},
+		{name: "wrong token", path: "/me/watchlist", cookie: token, header: "ZYXWVUTSRQPONMLKJIHGFEDCBA", want: http.StatusForbidden},
+		{name: "no cookie", path: "/me/watchlist", header: token, want: http.StatusForbidden},
+		{
+			name: "other site", path: "/me/watchlist", cookie: token, header: token,
+			headers: map[string]string{"Sec-Fetch-Site": "cross-site"},
+			want:    http.StatusForbidden,
+		},
+		{
+			name: "other origin", path: "/me/watchlist", cookie: token, header: token,
+			headers: map[string]string{"Origin": "https://evil.example"},
+			want:    http.StatusForbidden,
+		},
+		{
+			name: "same origin", path: "/me/watchlist", cookie: token, header: token,
+			headers: map[string]string{"Origin": "http://example.com", "Sec-Fetch-Site": "same-origin"},
+			want:    http.StatusOK,
+		},
+		{name: "exempt", path: "/graphql", want: http.StatusOK},
+		{
+			name: "exempt but cross-site", path: "/graphql",
+			headers: map[string]string{"Sec-Fetch-Site": "cross-site"},
+			want:    http.StatusForbidden,
+		},
+	}
+
+	handler := Middleware(Options{TokenExempt: []string{"/graphql"}}, ok)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			form := url.Values{}
+			if test.form != "" {
+				form.Set(FormField, test.form)
+			}
+
+			req := httptest.NewRequest(http.MethodPost, test.path, strings.NewReader(form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+			if test.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: CookieName, Value: test.cookie})
+			}
+
+			if test.header != "" {
+				req.Header.Set(HeaderName, test.header)
+			}
+
+			for name, value := range test.headers {
+				req.Header.Set(name, value)
+			}
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != test.want {
+				t.Errorf("status %d, want %d", rec.Code, test.want)
+			}
+		})
+	}
+}
+
+func TestRotate(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/login", nil)
+
+	first := httptest.NewRecorder()
+	second := httptest.NewRecorder()
+
+	token := Rotate(first, req, false)
+	if cookie(t, first.Result()) != token || !validToken(token) {
+		t.Fatalf("token %q, cookie %q", token, cookie(t, first.Result()))
+	}
+
+	if Rotate(second, req, false) == token {
+		t.Error("rotating kept the token")
+	}
+}
+
+func TestSecureCookies(t *testing.T) {
+	tests := []struct {
+		name    string
+		options Options
+		https   bool
+		want    bool
+	}{
+		{"plain http", Options{}, false, false},
+		{"https", Options{}, true, true},
+		{"behind a TLS proxy", Options{SecureCookies: true}, false, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			target := "http://example.com/"
+			if test.https {
+				target = "https://example.com/"
+			}
+
+			req := httptest.NewRequest(http.MethodGet, target, nil)
+			req.Header.Set("Accept", "text/html")
+
+			rec := httptest.NewRecorder()
+			Middleware(test.options, ok).ServeHTTP(rec, req)
+
+			for _, c := range rec.Result().Cookies() {
+				if c.Name == CookieName && c.Secure != test.want {
+					t.Errorf("secure %v, want %v", c.Secure, test.want)
+				}
+			}
+		})
+	}
+}
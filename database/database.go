@@ -0,0 +1,101 @@
+// Package database opens the SQLite database holding accounts and everything
+// users save, and keeps its schema up to date.
+package database
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+
+	_ "modernc.org/sqlite"
+)
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// Open opens or creates the database at path and applies pending migrations.
+func Open(ctx context.Context, path string) (*sql.DB, error) {
+	err := os.MkdirAll(filepath.Dir(path), 0o700)
+	if err != nil {
+		return nil, fmt.Errorf("database: %w", err)
+	}
+
+	query := url.Values{}
+	query.Add("_pragma", "foreign_keys(1)")
+	query.Add("_pragma", "journal_mode(WAL)")
+	query.Add("_pragma", "busy_timeout(5000)")
+
+	db, err := sql.Open("sqlite", "file:"+path+"?"+query.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("database: %w", err)
+	}
+
+	err = migrate(ctx, db)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("database: %s: %w", path, err)
+	}
+
+	return db, nil
+}
+
+// migrate applies the migrations newer than the database's user_version, in
+// the order of their file names.
+func migrate(ctx context.Context, db *sql.DB) error {
+	var version int
+
+	err := db.QueryRowContext(ctx, "PRAGMA user_version").Scan(&version)
+	if err != nil {
+		return err
+	}
+
+	names, err := fs.Glob(migrations, "migrations/*.sql")
+	if err != nil {
+		return err
+	}
+
+	slices.Sort(names)
+
+	for i, name := range names[min(version, len(names)):] {
+		script, err := migrations.ReadFile(name)
+		if err != nil {
+			return err
+		}
+
+		err = apply(ctx, db, string(script), version+i+1)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+
+		slog.InfoContext(ctx, "applied database migration", "migration", name)
+	}
+
+	return nil
+}
+
+func apply(ctx context.Context, db *sql.DB, script string, version int) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, script)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, fmt.Sprintf("PRAGMA user_version = %d", version))
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,17 @@
+CREATE TABLE users (
+	id INTEGER PRIMARY KEY,
+	name TEXT NOT NULL UNIQUE COLLATE NOCASE,
+	-- NULL for users signed in by the reverse proxy
+	password_hash TEXT,
+	created_at INTEGER NOT NULL
+);
+
+CREATE TABLE sessions (
+	-- SHA-256 of the cookie value, a leaked database can't be used to log in
+	token_hash TEXT PRIMARY KEY,
+	user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	created_at INTEGER NOT NULL,
+	expires_at INTEGER NOT NULL
+);
+
+CREATE INDEX sessions_user_id ON sessions (user_id);
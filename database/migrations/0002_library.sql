@@ -0,0 +1,30 @@
+-- Movies are stored with what the lists show, so they can be rendered and
+-- summed up without asking TMDB.
+CREATE TABLE watchlist (
+	user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	movie_id INTEGER NOT NULL,
+	title TEXT NOT NULL,
+	poster_path TEXT NOT NULL,
+	release_date TEXT NOT NULL,
+	runtime INTEGER NOT NULL,
+	added_at INTEGER NOT NULL,
+	PRIMARY KEY (user_id, movie_id)
+);
+
+-- One row per viewing, rewatching a movie adds another one.
+CREATE TABLE viewings (
+	id INTEGER PRIMARY KEY,
+	user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	movie_id INTEGER NOT NULL,
+	title TEXT NOT NULL,
+	poster_path TEXT NOT NULL,
+	release_date TEXT NOT NULL,
+	runtime INTEGER NOT NULL,
+	-- YYYY-MM-DD, viewings have no time of day
+	watched_on TEXT NOT NULL,
+	-- 1 to 10, NULL when not rated
+	rating INTEGER,
+	created_at INTEGER NOT NULL
+);
+
+CREATE INDEX viewings_user_id_movie_id ON viewings (user_id, movie_id);
@@ -0,0 +1,23 @@
+-- IMDb ids let the history be exported in IMDb's format.
+ALTER TABLE watchlist ADD COLUMN imdb_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE viewings ADD COLUMN imdb_id TEXT NOT NULL DEFAULT '';
+
+-- Rows of imported files that couldn't be matched to a TMDB movie, kept
+-- until the user resolves or dismisses them.
+CREATE TABLE import_issues (
+	id INTEGER PRIMARY KEY,
+	user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	format TEXT NOT NULL,
+	line INTEGER NOT NULL,
+	kind TEXT NOT NULL,
+	title TEXT NOT NULL,
+	year INTEGER NOT NULL,
+	imdb_id TEXT NOT NULL,
+	-- YYYY-MM-DD, empty when the row has no date
+	date TEXT NOT NULL,
+	rating INTEGER NOT NULL,
+	reason TEXT NOT NULL,
+	created_at INTEGER NOT NULL
+);
+
+CREATE INDEX import_issues_user_id ON import_issues (user_id);
@@ -0,0 +1,42 @@
+-- Named lists of movies and people. share_token is NULL until the owner
+-- shares the list by link.
+CREATE TABLE lists (
+	id INTEGER PRIMARY KEY,
+	user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	name TEXT NOT NULL,
+	description TEXT NOT NULL,
+	share_token TEXT UNIQUE,
+	created_at INTEGER NOT NULL,
+	updated_at INTEGER NOT NULL
+);
+
+CREATE INDEX lists_user_id ON lists (user_id);
+
+-- Items are stored with what the list shows, like the library's movies.
+CREATE TABLE list_items (
+	id INTEGER PRIMARY KEY,
+	list_id INTEGER NOT NULL REFERENCES lists (id) ON DELETE CASCADE,
+	-- movie or person
+	kind TEXT NOT NULL,
+	tmdb_id INTEGER NOT NULL,
+	title TEXT NOT NULL,
+	image_path TEXT NOT NULL,
+	-- empty for people
+	release_date TEXT NOT NULL,
+	note TEXT NOT NULL,
+	position INTEGER NOT NULL,
+	-- NULL once the account that added the item is gone
+	added_by INTEGER REFERENCES users (id) ON DELETE SET NULL,
+	added_at INTEGER NOT NULL,
+	UNIQUE (list_id, kind, tmdb_id)
+);
+
+-- Collaborators may change the items of a list, but not the list itself.
+CREATE TABLE list_members (
+	list_id INTEGER NOT NULL REFERENCES lists (id) ON DELETE CASCADE,
+	user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	added_at INTEGER NOT NULL,
+	PRIMARY KEY (list_id, user_id)
+);
+
+CREATE INDEX list_members_user_id ON list_members (user_id);
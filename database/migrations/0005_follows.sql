@@ -0,0 +1,61 @@
+-- People users follow, stored with what the follow list shows.
+CREATE TABLE follows (
+	user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	person_id INTEGER NOT NULL,
+	name TEXT NOT NULL,
+	profile_path TEXT NOT NULL,
+	created_at INTEGER NOT NULL,
+	PRIMARY KEY (user_id, person_id)
+);
+
+CREATE INDEX follows_person_id ON follows (person_id);
+
+-- The credits of followed people as of their last check, shared by all
+-- followers. checked_people has a row once a person has a snapshot.
+CREATE TABLE checked_people (
+	person_id INTEGER PRIMARY KEY,
+	checked_at INTEGER NOT NULL
+);
+
+CREATE TABLE credit_snapshots (
+	person_id INTEGER NOT NULL,
+	movie_id INTEGER NOT NULL,
+	title TEXT NOT NULL,
+	-- YYYY-MM-DD, empty while TMDB knows no date
+	release_date TEXT NOT NULL,
+	PRIMARY KEY (person_id, movie_id)
+);
+
+CREATE TABLE notifications (
+	id INTEGER PRIMARY KEY,
+	user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	-- announced or released
+	kind TEXT NOT NULL,
+	person_id INTEGER NOT NULL,
+	person_name TEXT NOT NULL,
+	movie_id INTEGER NOT NULL,
+	title TEXT NOT NULL,
+	poster_path TEXT NOT NULL,
+	release_date TEXT NOT NULL,
+	created_at INTEGER NOT NULL,
+	read INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX notifications_user_id ON notifications (user_id, created_at);
+
+-- Feed readers can't log in, feeds are found by a secret token instead.
+CREATE TABLE feed_tokens (
+	user_id INTEGER PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+	token TEXT NOT NULL UNIQUE
+);
+
+CREATE TABLE webhooks (
+	id INTEGER PRIMARY KEY,
+	user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	url TEXT NOT NULL,
+	-- signs deliveries, so receivers can tell them from forgeries
+	secret TEXT NOT NULL,
+	created_at INTEGER NOT NULL
+);
+
+CREATE INDEX webhooks_user_id ON webhooks (user_id);
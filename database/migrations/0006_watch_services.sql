@@ -0,0 +1,6 @@
+-- The streaming and rental services a user has, by TMDB provider id.
+CREATE TABLE watch_services (
+	user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	provider_id INTEGER NOT NULL,
+	PRIMARY KEY (user_id, provider_id)
+);
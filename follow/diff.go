@@ -0,0 +1,73 @@
+package follow
+
+import (
+	"time"
+
+	"github.com/m4tthewde/blunt/library"
+	"github.com/m4tthewde/blunt/tmdb"
+)
+
+// Kinds of changes to a person's credits, and of notifications about them.
+const (
+	// Announced credits are new and not released yet, or have no date.
+	Announced = "announced"
+	// Released credits got a release date that has passed.
+	Released = "released"
+)
+
+// recentRelease is how long ago a credit that only just appeared may have
+// been released to be news. Older ones are TMDB filling in the back catalog.
+const recentRelease = 30 * 24 * time.Hour
+
+// Credit is what a snapshot remembers of a credit.
+type Credit struct {
+	MovieId     int64
+	Title       string
+	ReleaseDate string
+}
+
+type Change struct {
+	Kind   string
+	Credit tmdb.PeopleCredit
+}
+
+// Diff compares a person's current credits with the snapshot taken at the
+// last check. A credit is released once its date is today or earlier.
+func Diff(snapshot map[int64]Credit, checked time.Time, credits []tmdb.PeopleCredit, today time.Time) []Change {
+	now := today.Format(library.DateLayout)
+	then := checked.Format(library.DateLayout)
+	recent := today.Add(-recentRelease).Format(library.DateLayout)
+
+	changes := make([]Change, 0)
+	seen := make(map[int64]bool)
+
+	for _, credit := range credits {
+		// people playing several roles in a movie have a credit for each
+		if seen[credit.Id] {
+			continue
+		}
+
+		seen[credit.Id] = true
+
+		released := credit.ReleaseDate != "" && credit.ReleaseDate <= now
+
+		old, ok := snapshot[credit.Id]
+		if !ok {
+			switch {
+			case !released:
+				changes = append(changes, Change{Kind: Announced, Credit: credit})
+			case credit.ReleaseDate >= recent:
+				changes = append(changes, Change{Kind: Released, Credit: credit})
+			}
+
+			continue
+		}
+
+		wasReleased := old.ReleaseDate != "" && old.ReleaseDate <= then
+		if released && !wasReleased {
+			changes = append(changes, Change{Kind: Released, Credit: credit})
+		}
+	}
+
+	return changes
+}
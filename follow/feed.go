@@ -0,0 +1,87 @@
+package follow
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// Feed describes a user's Atom feed. Atom needs absolute links, so BaseUrl
+// is the scheme and host the feed was requested at.
+type Feed struct {
+	BaseUrl string
+	Token   string
+	Title   string
+	Author  string
+	// EntryTitle words a notification in the reader's language.
+	EntryTitle    func(notification Notification) string
+	Notifications []Notification
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Id      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Author  atomAuthor  `xml:"author"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type atomEntry struct {
+	Id      string   `xml:"id"`
+	Title   string   `xml:"title"`
+	Updated string   `xml:"updated"`
+	Link    atomLink `xml:"link"`
+}
+
+// Write writes the feed as Atom. A feed without entries was updated when
+// the feed was created, there's nothing better to tell readers.
+func (f Feed) Write(w io.Writer) error {
+	updated := time.Unix(0, 0)
+	if len(f.Notifications) != 0 {
+		updated = f.Notifications[0].Created
+	}
+
+	feed := atomFeed{
+		Id:      f.BaseUrl + "/feeds/" + f.Token + ".atom",
+		Title:   f.Title,
+		Updated: updated.UTC().Format(time.RFC3339),
+		Author:  atomAuthor{Name: f.Author},
+		Links: []atomLink{
+			{Rel: "self", Href: f.BaseUrl + "/feeds/" + f.Token + ".atom"},
+			{Rel: "alternate", Href: f.BaseUrl + "/me/notifications"},
+		},
+		Entries: make([]atomEntry, 0, len(f.Notifications)),
+	}
+
+	for _, notification := range f.Notifications {
+		feed.Entries = append(feed.Entries, atomEntry{
+			Id:      fmt.Sprintf("%s/me/notifications#%d", f.BaseUrl, notification.Id),
+			Title:   f.EntryTitle(notification),
+			Updated: notification.Created.UTC().Format(time.RFC3339),
+			Link:    atomLink{Href: f.BaseUrl + "/movie/" + strconv.FormatInt(notification.MovieId, 10)},
+		})
+	}
+
+	_, err := io.WriteString(w, xml.Header)
+	if err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+
+	return encoder.Encode(feed)
+}
@@ -0,0 +1,106 @@
+package follow
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFeedWrite(t *testing.T) {
+	older := testNotification()
+	older.Id = 6
+	older.MovieId = 13
+	older.Title = "Forrest Gump & Co"
+	older.Created = older.Created.Add(-time.Hour)
+
+	feed := Feed{
+		BaseUrl: "https://blunt.example",
+		Token:   "abc",
+		Title:   "New movies",
+		Author:  "alice",
+		EntryTitle: func(notification Notification) string {
+			return notification.Title + " – " + notification.PersonName
+		},
+		Notifications: []Notification{testNotification(), older},
+	}
+
+	var out strings.Builder
+
+	err := feed.Write(&out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(out.String(), xml.Header) {
+		t.Error("feed lacks the XML header")
+	}
+
+	var parsed atomFeed
+
+	err = xml.Unmarshal([]byte(out.String()), &parsed)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if parsed.XMLName.Space != "http://www.w3.org/2005/Atom" {
+		t.Errorf("namespace %q", parsed.XMLName.Space)
+	}
+
+	if parsed.Id != "https://blunt.example/feeds/abc.atom" || parsed.Title != "New movies" || parsed.Author.Name != "alice" {
+		t.Errorf("feed %q %q %q", parsed.Id, parsed.Title, parsed.Author.Name)
+	}
+
+	if parsed.Updated != "2025-03-01T12:00:00Z" {
+		t.Errorf("updated %q, want the newest notification", parsed.Updated)
+	}
+
+	if len(parsed.Links) != 2 || parsed.Links[0].Rel != "self" || parsed.Links[0].Href != parsed.Id {
+		t.Errorf("links %+v", parsed.Links)
+	}
+
+	want := []atomEntry{
+		{
+			Id:      "https://blunt.example/me/notifications#7",
+			Title:   "Saving Private Ryan – Tom Hanks",
+			Updated: "2025-03-01T12:00:00Z",
+			Link:    atomLink{Href: "https://blunt.example/movie/857"},
+		},
+		{
+			Id:      "https://blunt.example/me/notifications#6",
+			Title:   "Forrest Gump & Co – Tom Hanks",
+			Updated: "2025-03-01T11:00:00Z",
+			Link:    atomLink{Href: "https://blunt.example/movie/13"},
+		},
+	}
+
+	if len(parsed.Entries) != len(want) {
+		t.Fatalf("%d entries, want %d", len(parsed.Entries), len(want))
+	}
+
+	for i, entry := range parsed.Entries {
+		if entry != want[i] {
+			t.Errorf("entry %d is %+v, want %+v", i, entry, want[i])
+		}
+	}
+}
+
+func TestFeedWriteEmpty(t *testing.T) {
+	var out strings.Builder
+
+	err := Feed{BaseUrl: "http://localhost", Token: "abc"}.Write(&out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var parsed atomFeed
+
+	err = xml.Unmarshal([]byte(out.String()), &parsed)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if parsed.Updated != "1970-01-01T00:00:00Z" || len(parsed.Entries) != 0 {
+		t.Errorf("updated %q with %d entries", parsed.Updated, len(parsed.Entries))
+	}
+}
@@ -0,0 +1,525 @@
+// Package follow lets users follow people and tells them about their new
+// movies, in an inbox, an Atom feed and by webhook.
+package follow
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/netip"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/m4tthewde/blunt/tmdb"
+)
+
+var (
+	ErrNotFound        = errors.New("follow: not found")
+	ErrInvalidUrl      = errors.New("follow: webhook URLs are http or https URLs of public hosts")
+	ErrTooManyWebhooks = errors.New("follow: too many webhooks")
+)
+
+// maxWebhooks per user, every delivery is a request the server makes.
+const maxWebhooks = 5
+
+type Person struct {
+	Id          int64
+	Name        string
+	ProfilePath string
+}
+
+type Followed struct {
+	Person
+	Since time.Time
+}
+
+type Notification struct {
+	Id          int64
+	UserId      int64
+	Kind        string
+	PersonId    int64
+	PersonName  string
+	MovieId     int64
+	Title       string
+	PosterPath  string
+	ReleaseDate string
+	Created     time.Time
+	Read        bool
+}
+
+type Webhook struct {
+	Id      int64
+	UserId  int64
+	Url     string
+	Secret  string
+	Created time.Time
+}
+
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+func (s *Store) Follow(ctx context.Context, userId int64, person Person) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO follows (user_id, person_id, name, profile_path, created_at) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (user_id, person_id) DO NOTHING`,
+		userId, person.Id, person.Name, person.ProfilePath, time.Now().Unix(),
+	)
+	if err != nil {
+		return fmt.Errorf("follow: %w", err)
+	}
+
+	return nil
+}
+
+// Unfollow also drops the snapshot of people nobody follows anymore.
+func (s *Store) Unfollow(ctx context.Context, userId int64, personId int64) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("follow: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM follows WHERE user_id = ? AND person_id = ?", userId, personId)
+	if err != nil {
+		return fmt.Errorf("follow: %w", err)
+	}
+
+	var followed bool
+
+	err = tx.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM follows WHERE person_id = ?)", personId).Scan(&followed)
+	if err != nil {
+		return fmt.Errorf("follow: %w", err)
+	}
+
+	if !followed {
+		for _, query := range []string{
+			"DELETE FROM credit_snapshots WHERE person_id = ?",
+			"DELETE FROM checked_people WHERE person_id = ?",
+		} {
+			_, err = tx.ExecContext(ctx, query, personId)
+			if err != nil {
+				return fmt.Errorf("follow: %w", err)
+			}
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("follow: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Store) IsFollowing(ctx context.Context, userId int64, personId int64) (bool, error) {
+	var following bool
+
+	err := s.db.QueryRowContext(ctx,
+		"SELECT EXISTS (SELECT 1 FROM follows WHERE user_id = ? AND person_id = ?)", userId, personId,
+	).Scan(&following)
+	if err != nil {
+		return false, fmt.Errorf("follow: %w", err)
+	}
+
+	return following, nil
+}
+
+func (s *Store) Following(ctx context.Context, userId int64) ([]Followed, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT person_id, name, profile_path, created_at FROM follows
+		WHERE user_id = ? ORDER BY name COLLATE NOCASE`,
+		userId,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("follow: %w", err)
+	}
+	defer rows.Close()
+
+	following := make([]Followed, 0)
+
+	for rows.Next() {
+		var followed Followed
+		var created int64
+
+		err := rows.Scan(&followed.Id, &followed.Name, &followed.ProfilePath, &created)
+		if err != nil {
+			return nil, fmt.Errorf("follow: %w", err)
+		}
+
+		followed.Since = time.Unix(created, 0)
+		following = append(following, followed)
+	}
+
+	return following, rows.Err()
+}
+
+// Due returns the followed people not checked since before, never checked
+// ones first.
+func (s *Store) Due(ctx context.Context, before time.Time) ([]int64, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT DISTINCT follows.person_id, coalesce(checked_people.checked_at, 0) AS checked
+		FROM follows LEFT JOIN checked_people ON checked_people.person_id = follows.person_id
+		WHERE checked_people.checked_at IS NULL OR checked_people.checked_at < ?
+		ORDER BY checked, follows.person_id`,
+		before.Unix(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("follow: %w", err)
+	}
+	defer rows.Close()
+
+	due := make([]int64, 0)
+
+	for rows.Next() {
+		var personId, checked int64
+
+		err := rows.Scan(&personId, &checked)
+		if err != nil {
+			return nil, fmt.Errorf("follow: %w", err)
+		}
+
+		due = append(due, personId)
+	}
+
+	return due, rows.Err()
+}
+
+// Snapshot returns the credits of the person's last check and when it was,
+// ok is false before the first check.
+func (s *Store) Snapshot(ctx context.Context, personId int64) (map[int64]Credit, time.Time, bool, error) {
+	var checked int64
+
+	err := s.db.QueryRowContext(ctx,
+		"SELECT checked_at FROM checked_people WHERE person_id = ?", personId,
+	).Scan(&checked)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, time.Time{}, false, nil
+	}
+	if err != nil {
+		return nil, time.Time{}, false, fmt.Errorf("follow: %w", err)
+	}
+
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT movie_id, title, release_date FROM credit_snapshots WHERE person_id = ?", personId,
+	)
+	if err != nil {
+		return nil, time.Time{}, false, fmt.Errorf("follow: %w", err)
+	}
+	defer rows.Close()
+
+	snapshot := make(map[int64]Credit)
+
+	for rows.Next() {
+		var credit Credit
+
+		err := rows.Scan(&credit.MovieId, &credit.Title, &credit.ReleaseDate)
+		if err != nil {
+			return nil, time.Time{}, false, fmt.Errorf("follow: %w", err)
+		}
+
+		snapshot[credit.MovieId] = credit
+	}
+
+	return snapshot, time.Unix(checked, 0), true, rows.Err()
+}
+
+// SaveCheck replaces the person's snapshot with credits and notifies every
+// follower of changes. It returns the notifications it created.
+func (s *Store) SaveCheck(ctx context.Context, personId int64, credits []tmdb.PeopleCredit, changes []Change, checked time.Time) ([]Notification, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("follow: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM credit_snapshots WHERE person_id = ?", personId)
+	if err != nil {
+		return nil, fmt.Errorf("follow: %w", err)
+	}
+
+	for _, credit := range credits {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO credit_snapshots (person_id, movie_id, title, release_date) VALUES (?, ?, ?, ?)
+			ON CONFLICT (person_id, movie_id) DO NOTHING`,
+			personId, credit.Id, credit.Title, credit.ReleaseDate,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("follow: %w", err)
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO checked_people (person_id, checked_at) VALUES (?, ?)
+		ON CONFLICT (person_id) DO UPDATE SET checked_at = excluded.checked_at`,
+		personId, checked.Unix(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("follow: %w", err)
+	}
+
+	notifications := make([]Notification, 0)
+
+	for _, change := range changes {
+		rows, err := tx.QueryContext(ctx, `
+			INSERT INTO notifications (user_id, kind, person_id, person_name, movie_id, title, poster_path, release_date, created_at)
+			SELECT user_id, ?, person_id, name, ?, ?, ?, ?, ? FROM follows WHERE person_id = ?
+			RETURNING id, user_id, person_name`,
+			change.Kind, change.Credit.Id, change.Credit.Title, change.Credit.PosterPath, change.Credit.ReleaseDate,
+			checked.Unix(), personId,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("follow: %w", err)
+		}
+
+		for rows.Next() {
+			notification := Notification{
+				Kind:        change.Kind,
+				PersonId:    personId,
+				MovieId:     change.Credit.Id,
+				Title:       change.Credit.Title,
+				PosterPath:  change.Credit.PosterPath,
+				ReleaseDate: change.Credit.ReleaseDate,
+				Created:     checked,
+			}
+
+			err = rows.Scan(&notification.Id, &notification.UserId, &notification.PersonName)
+			if err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("follow: %w", err)
+			}
+
+			notifications = append(notifications, notification)
+		}
+
+		err = rows.Close()
+		if err != nil {
+			return nil, fmt.Errorf("follow: %w", err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, fmt.Errorf("follow: %w", err)
+	}
+
+	return notifications, nil
+}
+
+// MarkChecked postpones the next check of a person without touching the
+// snapshot, for people TMDB doesn't know anymore.
+func (s *Store) MarkChecked(ctx context.Context, personId int64, checked time.Time) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO checked_people (person_id, checked_at) VALUES (?, ?)
+		ON CONFLICT (person_id) DO UPDATE SET checked_at = excluded.checked_at`,
+		personId, checked.Unix(),
+	)
+	if err != nil {
+		return fmt.Errorf("follow: %w", err)
+	}
+
+	return nil
+}
+
+// Notifications returns the user's latest notifications, newest first.
+func (s *Store) Notifications(ctx context.Context, userId int64, limit int) ([]Notification, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, user_id, kind, person_id, person_name, movie_id, title, poster_path, release_date, created_at, read
+		FROM notifications WHERE user_id = ? ORDER BY created_at DESC, id DESC LIMIT ?`,
+		userId, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("follow: %w", err)
+	}
+	defer rows.Close()
+
+	notifications := make([]Notification, 0)
+
+	for rows.Next() {
+		var notification Notification
+		var created int64
+
+		err := rows.Scan(&notification.Id, &notification.UserId, &notification.Kind, &notification.PersonId,
+			&notification.PersonName, &notification.MovieId, &notification.Title, &notification.PosterPath,
+			&notification.ReleaseDate, &created, &notification.Read)
+		if err != nil {
+			return nil, fmt.Errorf("follow: %w", err)
+		}
+
+		notification.Created = time.Unix(created, 0)
+		notifications = append(notifications, notification)
+	}
+
+	return notifications, rows.Err()
+}
+
+func (s *Store) MarkRead(ctx context.Context, userId int64) error {
+	_, err := s.db.ExecContext(ctx, "UPDATE notifications SET read = 1 WHERE user_id = ? AND read = 0", userId)
+	if err != nil {
+		return fmt.Errorf("follow: %w", err)
+	}
+
+	return nil
+}
+
+// FeedToken returns the token of the user's feed, creating one on first
+// use.
+func (s *Store) FeedToken(ctx context.Context, userId int64) (string, error) {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO feed_tokens (user_id, token) VALUES (?, ?)
+		ON CONFLICT (user_id) DO NOTHING`,
+		userId, rand.Text(),
+	)
+	if err != nil {
+		return "", fmt.Errorf("follow: %w", err)
+	}
+
+	var token string
+
+	err = s.db.QueryRowContext(ctx, "SELECT token FROM feed_tokens WHERE user_id = ?", userId).Scan(&token)
+	if err != nil {
+		return "", fmt.Errorf("follow: %w", err)
+	}
+
+	return token, nil
+}
+
+// ResetFeedToken replaces the token, the old feed URL stops working.
+func (s *Store) ResetFeedToken(ctx context.Context, userId int64) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO feed_tokens (user_id, token) VALUES (?, ?)
+		ON CONFLICT (user_id) DO UPDATE SET token = excluded.token`,
+		userId, rand.Text(),
+	)
+	if err != nil {
+		return fmt.Errorf("follow: %w", err)
+	}
+
+	return nil
+}
+
+// FeedUser returns the user whose feed token it is.
+func (s *Store) FeedUser(ctx context.Context, token string) (int64, string, error) {
+	var userId int64
+	var name string
+
+	err := s.db.QueryRowContext(ctx, `
+		SELECT users.id, users.name FROM feed_tokens JOIN users ON users.id = feed_tokens.user_id
+		WHERE feed_tokens.token = ?`,
+		token,
+	).Scan(&userId, &name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, "", ErrNotFound
+	}
+	if err != nil {
+		return 0, "", fmt.Errorf("follow: %w", err)
+	}
+
+	return userId, name, nil
+}
+
+func (s *Store) Webhooks(ctx context.Context, userId int64) ([]Webhook, error) {
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT id, user_id, url, secret, created_at FROM webhooks WHERE user_id = ? ORDER BY id", userId,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("follow: %w", err)
+	}
+	defer rows.Close()
+
+	webhooks := make([]Webhook, 0)
+
+	for rows.Next() {
+		var webhook Webhook
+		var created int64
+
+		err := rows.Scan(&webhook.Id, &webhook.UserId, &webhook.Url, &webhook.Secret, &created)
+		if err != nil {
+			return nil, fmt.Errorf("follow: %w", err)
+		}
+
+		webhook.Created = time.Unix(created, 0)
+		webhooks = append(webhooks, webhook)
+	}
+
+	return webhooks, rows.Err()
+}
+
+func (s *Store) Webhook(ctx context.Context, userId int64, id int64) (Webhook, error) {
+	var webhook Webhook
+	var created int64
+
+	err := s.db.QueryRowContext(ctx,
+		"SELECT id, user_id, url, secret, created_at FROM webhooks WHERE id = ? AND user_id = ?", id, userId,
+	).Scan(&webhook.Id, &webhook.UserId, &webhook.Url, &webhook.Secret, &created)
+	if errors.Is(err, sql.ErrNoRows) {
+		return webhook, ErrNotFound
+	}
+	if err != nil {
+		return webhook, fmt.Errorf("follow: %w", err)
+	}
+
+	webhook.Created = time.Unix(created, 0)
+
+	return webhook, nil
+}
+
+func (s *Store) AddWebhook(ctx context.Context, userId int64, rawUrl string) error {
+	u, err := url.Parse(rawUrl)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return ErrInvalidUrl
+	}
+
+	// host names are checked when delivering, they may resolve elsewhere
+	// by then
+	addr, err := netip.ParseAddr(u.Hostname())
+	if (err == nil && !Public(addr)) || strings.EqualFold(u.Hostname(), "localhost") {
+		return ErrInvalidUrl
+	}
+
+	var count int
+
+	err = s.db.QueryRowContext(ctx, "SELECT count(*) FROM webhooks WHERE user_id = ?", userId).Scan(&count)
+	if err != nil {
+		return fmt.Errorf("follow: %w", err)
+	}
+
+	if count >= maxWebhooks {
+		return ErrTooManyWebhooks
+	}
+
+	_, err = s.db.ExecContext(ctx,
+		"INSERT INTO webhooks (user_id, url, secret, created_at) VALUES (?, ?, ?, ?)",
+		userId, u.String(), rand.Text(), time.Now().Unix(),
+	)
+	if err != nil {
+		return fmt.Errorf("follow: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Store) DeleteWebhook(ctx context.Context, userId int64, id int64) error {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM webhooks WHERE id = ? AND user_id = ?", id, userId)
+	if err != nil {
+		return fmt.Errorf("follow: %w", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("follow: %w", err)
+	}
+
+	if deleted == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,115 @@
+package follow
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/m4tthewde/blunt/tmdb"
+)
+
+// poll is how often the watcher looks for people due for a check. People
+// are checked every interval, newly followed ones within a poll.
+const poll = time.Minute
+
+type Watcher struct {
+	store  *Store
+	client *tmdb.Client
+	// sender is nil when webhooks are disabled.
+	sender   *Sender
+	interval time.Duration
+}
+
+func NewWatcher(store *Store, client *tmdb.Client, sender *Sender, interval time.Duration) *Watcher {
+	return &Watcher{store: store, client: client, sender: sender, interval: interval}
+}
+
+// Run checks the followed people until ctx is done.
+func (w *Watcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(poll)
+	defer ticker.Stop()
+
+	for {
+		w.checkDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *Watcher) checkDue(ctx context.Context) {
+	due, err := w.store.Due(ctx, time.Now().Add(-w.interval))
+	if err != nil {
+		slog.ErrorContext(ctx, "finding people to check failed", "error", err)
+		return
+	}
+
+	for _, personId := range due {
+		if ctx.Err() != nil {
+			return
+		}
+
+		err := w.Check(ctx, personId)
+		if err != nil {
+			// the person is retried on the next poll
+			slog.WarnContext(ctx, "checking credits failed", "person", personId, "error", err)
+		}
+	}
+}
+
+// Check diffs a person's credits against the snapshot, notifies the
+// followers of changes and takes a new snapshot. The first check of a
+// person only takes the snapshot, everything would be news otherwise.
+func (w *Watcher) Check(ctx context.Context, personId int64) error {
+	now := time.Now()
+
+	snapshot, checked, ok, err := w.store.Snapshot(ctx, personId)
+	if err != nil {
+		return err
+	}
+
+	credits, err := w.client.PeopleCredits(ctx, strconv.FormatInt(personId, 10))
+	if errors.Is(err, tmdb.ErrNotFound) {
+		return w.store.MarkChecked(ctx, personId, now)
+	}
+	if err != nil {
+		return err
+	}
+
+	changes := make([]Change, 0)
+	if ok {
+		changes = Diff(snapshot, checked, credits.Cast, now)
+	}
+
+	notifications, err := w.store.SaveCheck(ctx, personId, credits.Cast, changes, now)
+	if err != nil {
+		return err
+	}
+
+	if len(notifications) != 0 {
+		slog.InfoContext(ctx, "credits changed", "person", personId, "changes", len(changes), "notifications", len(notifications))
+	}
+
+	if w.sender == nil {
+		return nil
+	}
+
+	for _, notification := range notifications {
+		webhooks, err := w.store.Webhooks(ctx, notification.UserId)
+		if err != nil {
+			return err
+		}
+
+		for _, webhook := range webhooks {
+			// a slow receiver mustn't hold up everybody else's checks
+			go w.sender.Deliver(ctx, webhook, NewPayload(notification))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,223 @@
+package follow
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+const (
+	// SignatureHeader carries "sha256=" and the hex HMAC-SHA256 of the body,
+	// keyed with the webhook's secret.
+	SignatureHeader = "X-Blunt-Signature"
+	// EventHeader carries the payload's type.
+	EventHeader = "X-Blunt-Event"
+	// TestEvent is sent when a user tests a webhook.
+	TestEvent = "test"
+)
+
+const (
+	deliveryTimeout  = 10 * time.Second
+	deliveryAttempts = 3
+	deliveryBackoff  = time.Second
+)
+
+// ErrForbiddenAddress is returned for webhooks that resolve to loopback,
+// private or otherwise internal addresses.
+var ErrForbiddenAddress = errors.New("webhook: address is not public")
+
+// internal are the special purpose ranges netip doesn't tell apart from
+// public addresses.
+var internal = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("192.0.2.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("198.51.100.0/24"),
+	netip.MustParsePrefix("203.0.113.0/24"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("64:ff9b:1::/48"),
+	netip.MustParsePrefix("2001:db8::/32"),
+	netip.MustParsePrefix("2002::/16"),
+}
+
+// Public reports whether addr is a public unicast address webhooks may be
+// delivered to.
+func Public(addr netip.Addr) bool {
+	addr = addr.Unmap()
+
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+
+	for _, prefix := range internal {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// publicOnly is a net.Dialer control function, it runs after DNS
+// resolution and so also catches host names pointing inwards.
+func publicOnly(network string, address string, conn syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return ErrForbiddenAddress
+	}
+
+	addr, err := netip.ParseAddr(host)
+	if err != nil || !Public(addr) {
+		return ErrForbiddenAddress
+	}
+
+	return nil
+}
+
+// Payload is the JSON body of a delivery.
+type Payload struct {
+	// Type is announced, released or test.
+	Type    string         `json:"type"`
+	Person  *PayloadPerson `json:"person,omitempty"`
+	Movie   *PayloadMovie  `json:"movie,omitempty"`
+	Created time.Time      `json:"created"`
+}
+
+type PayloadPerson struct {
+	Id   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+type PayloadMovie struct {
+	Id          int64  `json:"id"`
+	Title       string `json:"title"`
+	ReleaseDate string `json:"releaseDate,omitempty"`
+	Url         string `json:"url"`
+}
+
+func NewPayload(notification Notification) Payload {
+	return Payload{
+		Type:   notification.Kind,
+		Person: &PayloadPerson{Id: notification.PersonId, Name: notification.PersonName},
+		Movie: &PayloadMovie{
+			Id:          notification.MovieId,
+			Title:       notification.Title,
+			ReleaseDate: notification.ReleaseDate,
+			Url:         "https://www.themoviedb.org/movie/" + strconv.FormatInt(notification.MovieId, 10),
+		},
+		Created: notification.Created.UTC(),
+	}
+}
+
+// Sign returns the signature header value of body.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Sender delivers payloads to webhooks.
+type Sender struct {
+	client  *http.Client
+	backoff time.Duration
+}
+
+// NewSender returns a Sender that only connects to public addresses.
+func NewSender() *Sender {
+	return newSender(publicOnly)
+}
+
+func newSender(control func(network string, address string, conn syscall.RawConn) error) *Sender {
+	dialer := &net.Dialer{Timeout: deliveryTimeout, Control: control}
+
+	return &Sender{
+		client: &http.Client{
+			Timeout: deliveryTimeout,
+			// no proxy from the environment, it would dial in our place
+			Transport: &http.Transport{
+				DialContext:         dialer.DialContext,
+				TLSHandshakeTimeout: deliveryTimeout,
+				MaxIdleConns:        10,
+				IdleConnTimeout:     time.Minute,
+			},
+			// a redirect would send the payload somewhere the user didn't
+			// configure
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		backoff: deliveryBackoff,
+	}
+}
+
+// Send makes one delivery attempt, any status but 2xx is an error.
+func (s *Sender) Send(ctx context.Context, webhook Webhook, payload Payload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("webhook: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.Url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("webhook: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "blunt")
+	req.Header.Set(EventHeader, payload.Type)
+	req.Header.Set(SignatureHeader, Sign(webhook.Secret, body))
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("webhook: %s answered %s", webhook.Url, res.Status)
+	}
+
+	return nil
+}
+
+// Deliver retries failed attempts with backoff and logs deliveries that
+// failed for good.
+func (s *Sender) Deliver(ctx context.Context, webhook Webhook, payload Payload) {
+	backoff := s.backoff
+
+	for attempt := 1; ; attempt++ {
+		err := s.Send(ctx, webhook, payload)
+		if err == nil {
+			return
+		}
+
+		if attempt == deliveryAttempts {
+			slog.WarnContext(ctx, "webhook delivery failed", "user", webhook.UserId, "webhook", webhook.Id, "err", err)
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+
+		backoff *= 4
+	}
+}
@@ -0,0 +1,211 @@
+package follow
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func testNotification() Notification {
+	return Notification{
+		Id:          7,
+		Kind:        "announced",
+		PersonId:    31,
+		PersonName:  "Tom Hanks",
+		MovieId:     857,
+		Title:       "Saving Private Ryan",
+		ReleaseDate: "1998-07-24",
+		Created:     time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestSend(t *testing.T) {
+	webhook := Webhook{Id: 1, UserId: 2, Secret: "secret"}
+
+	var body []byte
+	var header http.Header
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		header = r.Header
+	}))
+	defer server.Close()
+
+	webhook.Url = server.URL
+
+	err := newSender(nil).Send(context.Background(), webhook, NewPayload(testNotification()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := header.Get(SignatureHeader), Sign(webhook.Secret, body); got != want {
+		t.Errorf("signature %q, want %q", got, want)
+	}
+
+	if got := header.Get(EventHeader); got != "announced" {
+		t.Errorf("event %q, want announced", got)
+	}
+
+	if got := header.Get("Content-Type"); got != "application/json" {
+		t.Errorf("content type %q, want application/json", got)
+	}
+
+	var payload Payload
+
+	err = json.Unmarshal(body, &payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if payload.Type != "announced" || payload.Person.Id != 31 || payload.Person.Name != "Tom Hanks" {
+		t.Errorf("payload %+v, person %+v", payload, payload.Person)
+	}
+
+	if payload.Movie.Id != 857 || payload.Movie.Title != "Saving Private Ryan" || payload.Movie.ReleaseDate != "1998-07-24" {
+		t.Errorf("movie %+v", payload.Movie)
+	}
+
+	if payload.Movie.Url != "https://www.themoviedb.org/movie/857" {
+		t.Errorf("movie url %q", payload.Movie.Url)
+	}
+
+	if !payload.Created.Equal(testNotification().Created) {
+		t.Errorf("created %v", payload.Created)
+	}
+}
+
+func TestSign(t *testing.T) {
+	// echo -n '{"type":"test"}' | openssl dgst -sha256 -hmac secret
+	got := Sign("secret", []byte(`{"type":"test"}`))
+	want := "sha256=e0c6dc0edbeee535e9560c6876404637e75d912703f2cf36863b2220daa18af8"
+
+	if got != want {
+		t.Errorf("signature %q, want %q", got, want)
+	}
+}
+
+func TestSendStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	err := newSender(nil).Send(context.Background(), Webhook{Url: server.URL}, Payload{Type: TestEvent})
+	if err == nil {
+		t.Fatal("500 was a successful delivery")
+	}
+}
+
+func TestSendRedirect(t *testing.T) {
+	var redirected atomic.Bool
+
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		redirected.Store(true)
+	}))
+	defer target.Close()
+
+	server := httptest.NewServer(http.RedirectHandler(target.URL, http.StatusTemporaryRedirect))
+	defer server.Close()
+
+	err := newSender(nil).Send(context.Background(), Webhook{Url: server.URL}, Payload{Type: TestEvent})
+	if err == nil {
+		t.Error("redirect was a successful delivery")
+	}
+
+	if redirected.Load() {
+		t.Error("redirect was followed")
+	}
+}
+
+func TestDeliverRetries(t *testing.T) {
+	tests := []struct {
+		name     string
+		failures int32
+		want     int32
+	}{
+		{"first attempt", 0, 1},
+		{"after a failure", 1, 2},
+		{"last attempt", deliveryAttempts - 1, deliveryAttempts},
+		{"gives up", deliveryAttempts + 5, deliveryAttempts},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var attempts atomic.Int32
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if attempts.Add(1) <= test.failures {
+					w.WriteHeader(http.StatusServiceUnavailable)
+				}
+			}))
+			defer server.Close()
+
+			sender := newSender(nil)
+			sender.backoff = time.Millisecond
+
+			sender.Deliver(context.Background(), Webhook{Url: server.URL}, NewPayload(testNotification()))
+
+			if got := attempts.Load(); got != test.want {
+				t.Errorf("%d attempts, want %d", got, test.want)
+			}
+		})
+	}
+}
+
+func TestSenderRefusesInternalAddresses(t *testing.T) {
+	var reached atomic.Bool
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached.Store(true)
+	}))
+	defer server.Close()
+
+	err := NewSender().Send(context.Background(), Webhook{Url: server.URL}, Payload{Type: TestEvent})
+	if !errors.Is(err, ErrForbiddenAddress) {
+		t.Errorf("error %v, want ErrForbiddenAddress", err)
+	}
+
+	if reached.Load() {
+		t.Error("loopback server was reached")
+	}
+}
+
+func TestPublic(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"93.184.215.14", true},
+		{"2606:2800:21f:cb07:6820:80da:af6b:8b2c", true},
+		{"127.0.0.1", false},
+		{"127.1.2.3", false},
+		{"::1", false},
+		{"10.0.0.1", false},
+		{"172.16.5.4", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"fd00::1", false},
+		{"100.64.0.1", false},
+		{"0.0.0.0", false},
+		{"::", false},
+		{"224.0.0.1", false},
+		{"255.255.255.255", false},
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:169.254.169.254", false},
+		{"64:ff9b::a9fe:a9fe", false},
+	}
+
+	for _, test := range tests {
+		if got := Public(netip.MustParseAddr(test.addr)); got != test.want {
+			t.Errorf("Public(%s) = %v, want %v", test.addr, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/m4tthewde/blunt/components"
+	"github.com/m4tthewde/blunt/follow"
+	"github.com/m4tthewde/blunt/i18n"
+	"github.com/m4tthewde/blunt/tmdb"
+	"github.com/m4tthewde/blunt/users"
+)
+
+var (
+	follows *follow.Store
+	// webhookSender is nil when webhooks are disabled.
+	webhookSender *follow.Sender
+)
+
+// maxNotifications is how many of the latest notifications the inbox and
+// the feed show.
+const maxNotifications = 100
+
+// webhookErrors maps the webhook errors of the follow store to message
+// keys.
+var webhookErrors = map[error]string{
+	follow.ErrInvalidUrl:      "follow.error.invalidUrl",
+	follow.ErrTooManyWebhooks: "follow.error.tooManyWebhooks",
+}
+
+// followState returns whether the user follows the person, nil for
+// anonymous visitors.
+func followState(r *http.Request, personId int64) (*bool, error) {
+	user := users.FromContext(r.Context())
+	if user == nil {
+		return nil, nil
+	}
+
+	following, err := follows.IsFollowing(r.Context(), user.Id, personId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &following, nil
+}
+
+func followPerson(w http.ResponseWriter, r *http.Request, user *users.User) {
+	id, ok := pathId(w, r, "id")
+	if !ok {
+		return
+	}
+
+	person, err := client.People(r.Context(), strconv.FormatInt(id, 10))
+	if errors.Is(err, tmdb.ErrNotFound) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	err = follows.Follow(r.Context(), user.Id, follow.Person{Id: person.Id, Name: person.Name, ProfilePath: person.ProfilePath})
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	render(w, r, components.FollowButton(id, true))
+}
+
+func unfollowPerson(w http.ResponseWriter, r *http.Request, user *users.User) {
+	id, ok := pathId(w, r, "id")
+	if !ok {
+		return
+	}
+
+	err := follows.Unfollow(r.Context(), user.Id, id)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	render(w, r, components.FollowButton(id, false))
+}
+
+func notifications(w http.ResponseWriter, r *http.Request, user *users.User) {
+	inbox, err := follows.Notifications(r.Context(), user.Id, maxNotifications)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	following, err := follows.Following(r.Context(), user.Id)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	token, err := follows.FeedToken(r.Context(), user.Id)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	page := components.NotificationsPage{
+		Notifications: inbox,
+		Following:     following,
+		FeedUrl:       baseUrl(r) + "/feeds/" + token + ".atom",
+	}
+
+	if webhookSender != nil {
+		webhooks, err := follows.Webhooks(r.Context(), user.Id)
+		if err != nil {
+			serverError(w, r, err)
+			return
+		}
+
+		page.Webhooks = &components.Webhooks{Webhooks: webhooks}
+	}
+
+	render(w, r, components.Notifications(page))
+}
+
+func markNotificationsRead(w http.ResponseWriter, r *http.Request, user *users.User) {
+	err := follows.MarkRead(r.Context(), user.Id)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	inbox, err := follows.Notifications(r.Context(), user.Id, maxNotifications)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	render(w, r, components.NotificationList(inbox))
+}
+
+// resetFeed replaces the feed token, for feed URLs that got out.
+func resetFeed(w http.ResponseWriter, r *http.Request, user *users.User) {
+	err := follows.ResetFeedToken(r.Context(), user.Id)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	if r.Header.Get("HX-Request") == "true" {
+		w.Header().Set("HX-Redirect", "/me/notifications")
+		return
+	}
+
+	http.Redirect(w, r, "/me/notifications", http.StatusSeeOther)
+}
+
+// feed serves a user's notifications as Atom. Feed readers can't sign in,
+// the token in the file name stands in for the session.
+func feed(w http.ResponseWriter, r *http.Request) {
+	token, ok := strings.CutSuffix(r.PathValue("file"), ".atom")
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	userId, name, err := follows.FeedUser(r.Context(), token)
+	if errors.Is(err, follow.ErrNotFound) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	inbox, err := follows.Notifications(r.Context(), userId, maxNotifications)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+
+	atom := follow.Feed{
+		BaseUrl: baseUrl(r),
+		Token:   token,
+		Title:   i18n.T(ctx, "follow.feedTitle") + " " + name,
+		Author:  name,
+		EntryTitle: func(notification follow.Notification) string {
+			return notification.Title + " – " + notification.PersonName + " " + i18n.T(ctx, "follow.kind."+notification.Kind)
+		},
+		Notifications: inbox,
+	}
+
+	w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+
+	err = atom.Write(w)
+	if err != nil {
+		serverError(w, r, err)
+	}
+}
+
+// baseUrl is the scheme and host the request was made to, as far as a
+// reverse proxy lets us know.
+func baseUrl(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+
+	return scheme + "://" + r.Host
+}
+
+func renderWebhooks(w http.ResponseWriter, r *http.Request, user *users.User, webhooks components.Webhooks) {
+	list, err := follows.Webhooks(r.Context(), user.Id)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	webhooks.Webhooks = list
+
+	render(w, r, components.WebhookList(webhooks))
+}
+
+func addWebhook(w http.ResponseWriter, r *http.Request, user *users.User) {
+	rawUrl := strings.TrimSpace(r.PostFormValue("url"))
+
+	err := follows.AddWebhook(r.Context(), user.Id, rawUrl)
+	if errors.Is(err, follow.ErrInvalidUrl) || errors.Is(err, follow.ErrTooManyWebhooks) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		renderWebhooks(w, r, user, components.Webhooks{Url: rawUrl, Error: webhookErrors[err]})
+		return
+	}
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	renderWebhooks(w, r, user, components.Webhooks{})
+}
+
+func deleteWebhook(w http.ResponseWriter, r *http.Request, user *users.User) {
+	id, ok := pathId(w, r, "id")
+	if !ok {
+		return
+	}
+
+	err := follows.DeleteWebhook(r.Context(), user.Id, id)
+	if errors.Is(err, follow.ErrNotFound) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	renderWebhooks(w, r, user, components.Webhooks{})
+}
+
+// testWebhook sends a single test delivery and says how it went, without
+// retries the user would wait for nothing.
+func testWebhook(w http.ResponseWriter, r *http.Request, user *users.User) {
+	id, ok := pathId(w, r, "id")
+	if !ok {
+		return
+	}
+
+	webhook, err := follows.Webhook(r.Context(), user.Id, id)
+	if errors.Is(err, follow.ErrNotFound) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	err = webhookSender.Send(r.Context(), webhook, follow.Payload{Type: follow.TestEvent, Created: time.Now().UTC()})
+	if err != nil {
+		slog.InfoContext(r.Context(), "webhook test failed", "user", user.Id, "webhook", webhook.Id, "error", err)
+	}
+
+	render(w, r, components.WebhookTest(err == nil))
+}
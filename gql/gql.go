@@ -0,0 +1,137 @@
+// Package gql serves the movie and person graph over GraphQL at /graphql.
+package gql
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/a-h/templ"
+	"github.com/graph-gophers/graphql-go"
+	"github.com/m4tthewde/blunt/components"
+	"github.com/m4tthewde/blunt/csrf"
+	"github.com/m4tthewde/blunt/ratelimit"
+	"github.com/m4tthewde/blunt/tmdb"
+)
+
+//go:embed schema.graphql
+var schema string
+
+// maxBodyBytes bounds the size of a request, queries are much smaller.
+const maxBodyBytes = 1 << 20
+
+type Options struct {
+	// MaxDepth limits how deeply selections can be nested.
+	MaxDepth int
+	// MaxComplexity limits the distinct TMDB lookups a single request may
+	// cause. Lookups answered from the request's loaders don't count.
+	MaxComplexity int
+	// Limiter charges every TMDB lookup of a query to the client's graph
+	// budget, it may be nil.
+	Limiter *ratelimit.Limiter
+}
+
+type Handler struct {
+	schema   *graphql.Schema
+	client   *tmdb.Client
+	options  Options
+	graphiql http.Handler
+}
+
+type request struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+func New(client *tmdb.Client, options Options) *Handler {
+	parsed := graphql.MustParseSchema(schema, &resolver{client: client},
+		graphql.UseStringDescriptions(),
+		graphql.MaxDepth(options.MaxDepth),
+		graphql.MaxQueryLength(maxBodyBytes),
+	)
+
+	// GET runs queries without a CSRF token, which is only fine as long as
+	// nothing can be changed
+	for _, operation := range []string{"mutation", "subscription"} {
+		if _, ok := parsed.ASTSchema().RootOperationTypes[operation]; ok {
+			panic("gql: GET requests must not run " + operation + "s")
+		}
+	}
+
+	return &Handler{
+		schema:   parsed,
+		client:   client,
+		options:  options,
+		graphiql: templ.Handler(components.GraphiQL()),
+	}
+}
+
+// ServeHTTP executes queries sent with POST, or with GET as query
+// parameters. Browsers opening /graphql get the GraphiQL IDE instead, other
+// sites can't make them run queries with GET.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req request
+
+	switch r.Method {
+	case http.MethodGet:
+		req.Query = r.URL.Query().Get("query")
+		req.OperationName = r.URL.Query().Get("operationName")
+
+		if req.Query == "" {
+			h.graphiql.ServeHTTP(w, r)
+			return
+		}
+
+		err := csrf.SameOrigin(r)
+		if err != nil {
+			http.Error(w, "forbidden: "+err.Error(), http.StatusForbidden)
+			return
+		}
+
+		variables := r.URL.Query().Get("variables")
+		if variables != "" {
+			err := json.Unmarshal([]byte(variables), &req.Variables)
+			if err != nil {
+				http.Error(w, "variables must be a JSON object", http.StatusBadRequest)
+				return
+			}
+		}
+	case http.MethodPost:
+		err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodyBytes)).Decode(&req)
+		if err != nil {
+			http.Error(w, "request body must be a GraphQL request in JSON", http.StatusBadRequest)
+			return
+		}
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	delay, ok := h.options.Limiter.Allow(r, ratelimit.Graph, 1)
+	if !ok {
+		w.Header().Set("Retry-After", strconv.Itoa(ratelimit.RetryAfter(delay)))
+		http.Error(w, "too many requests, slow down", http.StatusTooManyRequests)
+		return
+	}
+
+	loaders := newLoaders(h.client, h.options.MaxComplexity)
+	ctx := context.WithValue(r.Context(), loadersKey{}, loaders)
+
+	response := h.schema.Exec(ctx, req.Query, req.OperationName, req.Variables)
+
+	// the cost of a query is only known once it ran, the request itself
+	// already paid for one lookup
+	h.options.Limiter.Charge(r, ratelimit.Graph, loaders.budget.used()-1)
+
+	w.Header().Set("Content-Type", "application/json")
+
+	err := json.NewEncoder(w).Encode(response)
+	if err != nil {
+		slog.DebugContext(ctx, "graphql: writing response failed", "error", err)
+	}
+}
@@ -0,0 +1,58 @@
+package gql
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/m4tthewde/blunt/tmdb"
+)
+
+func TestGetQueries(t *testing.T) {
+	handler := New(tmdb.NewClient(tmdb.Options{}), Options{MaxDepth: 10, MaxComplexity: 10})
+
+	tests := []struct {
+		name string
+		site string
+		want int
+	}{
+		{"script", "", http.StatusOK},
+		{"same origin", "same-origin", http.StatusOK},
+		{"typed in", "none", http.StatusOK},
+		{"other site", "cross-site", http.StatusForbidden},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/graphql?query="+url.QueryEscape("{ __typename }"), nil)
+			if test.site != "" {
+				req.Header.Set("Sec-Fetch-Site", test.site)
+			}
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != test.want {
+				t.Fatalf("status %d, want %d: %s", rec.Code, test.want, rec.Body)
+			}
+
+			if test.want == http.StatusOK && !strings.Contains(rec.Body.String(), `"__typename":"Query"`) {
+				t.Errorf("response %s", rec.Body)
+			}
+		})
+	}
+}
+
+func TestGetRefusesMutations(t *testing.T) {
+	handler := New(tmdb.NewClient(tmdb.Options{}), Options{MaxDepth: 10, MaxComplexity: 10})
+
+	req := httptest.NewRequest(http.MethodGet, "/graphql?query="+url.QueryEscape("mutation { __typename }"), nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if !strings.Contains(rec.Body.String(), `"errors"`) || strings.Contains(rec.Body.String(), `"data":{`) {
+		t.Errorf("mutation ran: %s", rec.Body)
+	}
+}
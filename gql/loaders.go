@@ -0,0 +1,102 @@
+package gql
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/graph-gophers/dataloader/v7"
+	"github.com/m4tthewde/blunt/tmdb"
+)
+
+// ErrTooComplex is returned once a query needs more TMDB lookups than
+// allowed.
+type ErrTooComplex struct {
+	Limit int
+}
+
+func (e *ErrTooComplex) Error() string {
+	return fmt.Sprintf("query is too complex: it needs more than %d TMDB lookups", e.Limit)
+}
+
+// budget counts the distinct TMDB lookups of a request. Repeated lookups are
+// answered by the loaders' cache and don't count.
+type budget struct {
+	mu        sync.Mutex
+	remaining int
+	limit     int
+}
+
+func (b *budget) take() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.remaining == 0 {
+		return &ErrTooComplex{Limit: b.limit}
+	}
+
+	b.remaining--
+
+	return nil
+}
+
+// used returns the number of lookups taken so far.
+func (b *budget) used() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.limit - b.remaining
+}
+
+// loaders batch and cache the TMDB lookups of a single request, so a movie
+// appearing several times in a query is only fetched once.
+type loaders struct {
+	movie         *dataloader.Loader[int64, *tmdb.MovieDetailsResponse]
+	movieCredits  *dataloader.Loader[int64, *tmdb.MovieCreditsResponse]
+	person        *dataloader.Loader[int64, *tmdb.PeopleResponse]
+	personCredits *dataloader.Loader[int64, *tmdb.PeopleCreditsResponse]
+	budget        *budget
+}
+
+func newLoaders(client *tmdb.Client, maxComplexity int) *loaders {
+	b := budget{remaining: maxComplexity, limit: maxComplexity}
+
+	return &loaders{
+		movie:         dataloader.NewBatchedLoader(batch(&b, client.MovieDetails)),
+		movieCredits:  dataloader.NewBatchedLoader(batch(&b, client.Credits)),
+		person:        dataloader.NewBatchedLoader(batch(&b, client.People)),
+		personCredits: dataloader.NewBatchedLoader(batch(&b, client.PeopleCredits)),
+		budget:        &b,
+	}
+}
+
+// batch fetches every key of a batch concurrently, TMDB has no endpoints to
+// fetch several movies or people at once.
+func batch[V any](b *budget, fetch func(ctx context.Context, id string) (V, error)) dataloader.BatchFunc[int64, V] {
+	return func(ctx context.Context, keys []int64) []*dataloader.Result[V] {
+		results := make([]*dataloader.Result[V], len(keys))
+
+		var wg sync.WaitGroup
+
+		for i, key := range keys {
+			err := b.take()
+			if err != nil {
+				results[i] = &dataloader.Result[V]{Error: err}
+				continue
+			}
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				data, err := fetch(ctx, strconv.FormatInt(key, 10))
+				results[i] = &dataloader.Result[V]{Data: data, Error: err}
+			}()
+		}
+
+		wg.Wait()
+
+		return results
+	}
+}
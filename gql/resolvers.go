@@ -0,0 +1,491 @@
+package gql
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/m4tthewde/blunt/catalog"
+	"github.com/m4tthewde/blunt/tmdb"
+)
+
+// maxListLimit bounds the limit argument of list fields.
+const maxListLimit = 50
+
+type resolver struct {
+	client *tmdb.Client
+}
+
+type loadersKey struct{}
+
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
+
+func parseId(id graphql.ID) (int64, error) {
+	n, err := strconv.ParseInt(string(id), 10, 64)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("id %q is not a positive integer", id)
+	}
+
+	return n, nil
+}
+
+func checkLimit(limit int32) (int, error) {
+	if limit < 1 || limit > maxListLimit {
+		return 0, fmt.Errorf("limit must be between 1 and %d", maxListLimit)
+	}
+
+	return int(limit), nil
+}
+
+func optional(s string) *string {
+	if s == "" {
+		return nil
+	}
+
+	return &s
+}
+
+func (r *resolver) Search(ctx context.Context, args struct {
+	Query string
+	Limit int32
+}) ([]*searchResultResolver, error) {
+	limit, err := checkLimit(args.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	err = loadersFrom(ctx).budget.take()
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := catalog.Search(ctx, r.client, args.Query)
+	if err != nil {
+		return nil, err
+	}
+
+	resolvers := make([]*searchResultResolver, 0)
+	for _, result := range results[:min(limit, len(results))] {
+		resolvers = append(resolvers, &searchResultResolver{result: result})
+	}
+
+	return resolvers, nil
+}
+
+func (r *resolver) Movie(ctx context.Context, args struct{ Id graphql.ID }) (*movieResolver, error) {
+	id, err := parseId(args.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = loadersFrom(ctx).movie.Load(ctx, id)()
+	if errors.Is(err, tmdb.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &movieResolver{id: id}, nil
+}
+
+func (r *resolver) Person(ctx context.Context, args struct{ Id graphql.ID }) (*personResolver, error) {
+	id, err := parseId(args.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = loadersFrom(ctx).person.Load(ctx, id)()
+	if errors.Is(err, tmdb.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &personResolver{id: id}, nil
+}
+
+type searchResultResolver struct {
+	result catalog.SearchResult
+}
+
+func (r *searchResultResolver) Type() string {
+	if r.result.Type == catalog.Movie {
+		return "MOVIE"
+	}
+
+	return "PERSON"
+}
+
+func (r *searchResultResolver) Id() graphql.ID {
+	return graphql.ID(strconv.FormatInt(r.result.Id, 10))
+}
+
+func (r *searchResultResolver) Name() string {
+	return r.result.Name
+}
+
+func (r *searchResultResolver) OriginalName() *string {
+	return optional(r.result.OriginalName)
+}
+
+func (r *searchResultResolver) Year() *string {
+	return optional(r.result.Year)
+}
+
+func (r *searchResultResolver) ImageUrl(args struct{ Width int32 }) string {
+	return tmdb.ImageUrl(r.result.ImageKind, r.result.ImagePath, int(args.Width))
+}
+
+func (r *searchResultResolver) Popularity() float64 {
+	return r.result.Popularity
+}
+
+func (r *searchResultResolver) Movie() *movieResolver {
+	if r.result.Type != catalog.Movie {
+		return nil
+	}
+
+	return &movieResolver{id: r.result.Id, summary: &movieSummary{
+		title:         r.result.Name,
+		originalTitle: r.result.OriginalName,
+		posterPath:    r.result.ImagePath,
+		popularity:    r.result.Popularity,
+	}}
+}
+
+func (r *searchResultResolver) Person() *personResolver {
+	if r.result.Type != catalog.Person {
+		return nil
+	}
+
+	return &personResolver{id: r.result.Id, summary: &personSummary{
+		name:        r.result.Name,
+		profilePath: r.result.ImagePath,
+	}}
+}
+
+// movieSummary holds what a credit or search result already tells about a
+// movie, so common fields resolve without another TMDB lookup.
+type movieSummary struct {
+	title         string
+	originalTitle string
+	posterPath    string
+	releaseDate   string
+	popularity    float64
+}
+
+type movieResolver struct {
+	id      int64
+	summary *movieSummary
+}
+
+func (r *movieResolver) details(ctx context.Context) (*tmdb.MovieDetailsResponse, error) {
+	return loadersFrom(ctx).movie.Load(ctx, r.id)()
+}
+
+// field returns a field from the summary if there is one, otherwise from the
+// movie details.
+func (r *movieResolver) field(ctx context.Context, fromSummary func(*movieSummary) string, fromDetails func(*tmdb.MovieDetailsResponse) string) (string, error) {
+	if r.summary != nil && fromSummary(r.summary) != "" {
+		return fromSummary(r.summary), nil
+	}
+
+	movie, err := r.details(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return fromDetails(movie), nil
+}
+
+func (r *movieResolver) Id() graphql.ID {
+	return graphql.ID(strconv.FormatInt(r.id, 10))
+}
+
+func (r *movieResolver) Title(ctx context.Context) (string, error) {
+	return r.field(ctx,
+		func(s *movieSummary) string { return s.title },
+		func(m *tmdb.MovieDetailsResponse) string { return m.Title },
+	)
+}
+
+func (r *movieResolver) OriginalTitle(ctx context.Context) (string, error) {
+	return r.field(ctx,
+		func(s *movieSummary) string { return s.originalTitle },
+		func(m *tmdb.MovieDetailsResponse) string { return m.OriginalTitle },
+	)
+}
+
+func (r *movieResolver) OriginalLanguage(ctx context.Context) (string, error) {
+	movie, err := r.details(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return movie.OriginalLanguage, nil
+}
+
+func (r *movieResolver) Tagline(ctx context.Context) (*string, error) {
+	movie, err := r.details(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return optional(movie.Tagline), nil
+}
+
+func (r *movieResolver) Overview(ctx context.Context) (*string, error) {
+	movie, err := r.details(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return optional(movie.Overview), nil
+}
+
+func (r *movieResolver) ReleaseDate(ctx context.Context) (*string, error) {
+	releaseDate, err := r.field(ctx,
+		func(s *movieSummary) string { return s.releaseDate },
+		func(m *tmdb.MovieDetailsResponse) string { return m.ReleaseDate },
+	)
+
+	return optional(releaseDate), err
+}
+
+func (r *movieResolver) Year(ctx context.Context) (*string, error) {
+	releaseDate, err := r.ReleaseDate(ctx)
+	if err != nil || releaseDate == nil {
+		return nil, err
+	}
+
+	return optional(tmdb.GetReleaseYear(*releaseDate)), nil
+}
+
+func (r *movieResolver) RuntimeMinutes(ctx context.Context) (*int32, error) {
+	movie, err := r.details(ctx)
+	if err != nil || movie.Runtime == 0 {
+		return nil, err
+	}
+
+	runtime := int32(movie.Runtime)
+
+	return &runtime, nil
+}
+
+func (r *movieResolver) Revenue(ctx context.Context) (*float64, error) {
+	movie, err := r.details(ctx)
+	if err != nil || movie.Revenue == 0 {
+		return nil, err
+	}
+
+	revenue := float64(movie.Revenue)
+
+	return &revenue, nil
+}
+
+func (r *movieResolver) Popularity(ctx context.Context) (float64, error) {
+	if r.summary != nil {
+		return r.summary.popularity, nil
+	}
+
+	movie, err := r.details(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return movie.Popularity, nil
+}
+
+func (r *movieResolver) PosterUrl(ctx context.Context, args struct{ Width int32 }) (string, error) {
+	posterPath := ""
+
+	// an empty path in the summary means there is no poster at all
+	if r.summary != nil {
+		posterPath = r.summary.posterPath
+	} else {
+		movie, err := r.details(ctx)
+		if err != nil {
+			return "", err
+		}
+
+		posterPath = movie.PosterPath
+	}
+
+	return tmdb.ImageUrl(tmdb.PosterImage, posterPath, int(args.Width)), nil
+}
+
+func (r *movieResolver) Cast(ctx context.Context, args struct{ Limit int32 }) ([]*creditResolver, error) {
+	limit, err := checkLimit(args.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	credits, err := loadersFrom(ctx).movieCredits.Load(ctx, r.id)()
+	if err != nil {
+		return nil, err
+	}
+
+	resolvers := make([]*creditResolver, 0)
+	for _, castMember := range credits.Cast[:min(limit, len(credits.Cast))] {
+		resolvers = append(resolvers, &creditResolver{
+			character: castMember.Character,
+			movie:     r,
+			person: &personResolver{id: castMember.Id, summary: &personSummary{
+				name:        castMember.Name,
+				profilePath: castMember.ProfilePath,
+			}},
+		})
+	}
+
+	return resolvers, nil
+}
+
+// personSummary holds what a credit or search result already tells about a
+// person.
+type personSummary struct {
+	name        string
+	profilePath string
+}
+
+type personResolver struct {
+	id      int64
+	summary *personSummary
+}
+
+func (r *personResolver) details(ctx context.Context) (*tmdb.PeopleResponse, error) {
+	return loadersFrom(ctx).person.Load(ctx, r.id)()
+}
+
+func (r *personResolver) Id() graphql.ID {
+	return graphql.ID(strconv.FormatInt(r.id, 10))
+}
+
+func (r *personResolver) Name(ctx context.Context) (string, error) {
+	if r.summary != nil && r.summary.name != "" {
+		return r.summary.name, nil
+	}
+
+	person, err := r.details(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return person.Name, nil
+}
+
+// detail resolves an optional field that is only part of the person details.
+func (r *personResolver) detail(ctx context.Context, field func(*tmdb.PeopleResponse) string) (*string, error) {
+	person, err := r.details(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return optional(field(person)), nil
+}
+
+func (r *personResolver) Birthday(ctx context.Context) (*string, error) {
+	return r.detail(ctx, func(p *tmdb.PeopleResponse) string { return p.Birthday })
+}
+
+func (r *personResolver) Deathday(ctx context.Context) (*string, error) {
+	return r.detail(ctx, func(p *tmdb.PeopleResponse) string { return p.Deathday })
+}
+
+func (r *personResolver) PlaceOfBirth(ctx context.Context) (*string, error) {
+	return r.detail(ctx, func(p *tmdb.PeopleResponse) string { return p.PlaceOfBirth })
+}
+
+func (r *personResolver) KnownForDepartment(ctx context.Context) (*string, error) {
+	return r.detail(ctx, func(p *tmdb.PeopleResponse) string { return p.KnownForDepartment })
+}
+
+func (r *personResolver) Homepage(ctx context.Context) (*string, error) {
+	return r.detail(ctx, func(p *tmdb.PeopleResponse) string { return p.Homepage })
+}
+
+func (r *personResolver) Biography(ctx context.Context) (*string, error) {
+	return r.detail(ctx, func(p *tmdb.PeopleResponse) string { return p.Biography })
+}
+
+func (r *personResolver) ProfileUrl(ctx context.Context, args struct{ Width int32 }) (string, error) {
+	profilePath := ""
+
+	if r.summary != nil {
+		profilePath = r.summary.profilePath
+	} else {
+		person, err := r.details(ctx)
+		if err != nil {
+			return "", err
+		}
+
+		profilePath = person.ProfilePath
+	}
+
+	return tmdb.ImageUrl(tmdb.ProfileImage, profilePath, int(args.Width)), nil
+}
+
+func (r *personResolver) Movies(ctx context.Context, args struct {
+	Limit   int32
+	OrderBy string
+}) ([]*creditResolver, error) {
+	limit, err := checkLimit(args.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	credits, err := loadersFrom(ctx).personCredits.Load(ctx, r.id)()
+	if err != nil {
+		return nil, err
+	}
+
+	cast := catalog.ReleasedCredits(credits.Cast)
+
+	if args.OrderBy == "POPULAR" {
+		slices.SortStableFunc(cast, func(a, b tmdb.PeopleCredit) int {
+			return cmp.Compare(b.Popularity, a.Popularity)
+		})
+	}
+
+	resolvers := make([]*creditResolver, 0)
+	for _, credit := range cast[:min(limit, len(cast))] {
+		resolvers = append(resolvers, &creditResolver{
+			character: credit.Character,
+			movie: &movieResolver{id: credit.Id, summary: &movieSummary{
+				title:         credit.Title,
+				originalTitle: credit.OriginalTitle,
+				posterPath:    credit.PosterPath,
+				releaseDate:   credit.ReleaseDate,
+				popularity:    credit.Popularity,
+			}},
+			person: r,
+		})
+	}
+
+	return resolvers, nil
+}
+
+type creditResolver struct {
+	character string
+	movie     *movieResolver
+	person    *personResolver
+}
+
+func (r *creditResolver) Character() *string {
+	return optional(r.character)
+}
+
+func (r *creditResolver) Movie() *movieResolver {
+	return r.movie
+}
+
+func (r *creditResolver) Person() *personResolver {
+	return r.person
+}
@@ -0,0 +1,32 @@
+package graph
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteDot renders the graph in Graphviz DOT format.
+func (g *Graph) WriteDot(w io.Writer) error {
+	var b strings.Builder
+
+	b.WriteString("digraph blunt {\n")
+
+	for _, node := range g.Nodes {
+		shape := "box"
+		if node.Type == Person {
+			shape = "ellipse"
+		}
+
+		fmt.Fprintf(&b, "\t%q [label=%q, shape=%s];\n", node.Key(), node.Name, shape)
+	}
+
+	for _, edge := range g.Edges {
+		fmt.Fprintf(&b, "\t%q -> %q;\n", edge.From, edge.To)
+	}
+
+	b.WriteString("}\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,358 @@
+package graph
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+
+	"github.com/m4tthewde/blunt/tmdb"
+)
+
+const (
+	Movie  = "movie"
+	Person = "person"
+)
+
+var ErrNoPath = errors.New("no path found")
+
+// ErrTooManyLookups is returned once a search needs more TMDB lookups than its
+// Lookups allow.
+type ErrTooManyLookups struct {
+	Limit int
+}
+
+func (e *ErrTooManyLookups) Error() string {
+	return fmt.Sprintf("search needs more than %d TMDB lookups", e.Limit)
+}
+
+// Lookups counts the TMDB lookups of a search and stops it after Limit of
+// them. A nil Lookups or a Limit of 0 counts nothing and doesn't limit.
+type Lookups struct {
+	Limit int
+	used  int
+}
+
+func (l *Lookups) take() error {
+	if l == nil || l.Limit == 0 {
+		return nil
+	}
+
+	if l.used == l.Limit {
+		return &ErrTooManyLookups{Limit: l.Limit}
+	}
+
+	l.used++
+
+	return nil
+}
+
+// Used returns the number of lookups made so far.
+func (l *Lookups) Used() int {
+	if l == nil {
+		return 0
+	}
+
+	return l.used
+}
+
+type Node struct {
+	Type      string `json:"type"`
+	Id        int64  `json:"id"`
+	Name      string `json:"name"`
+	ImagePath string `json:"image_path"`
+}
+
+type Edge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type Graph struct {
+	Root  string `json:"root"`
+	Nodes []Node `json:"nodes"`
+	Edges []Edge `json:"edges"`
+}
+
+func (n Node) Key() string {
+	return Key(n.Type, n.Id)
+}
+
+func Key(nodeType string, id int64) string {
+	return fmt.Sprintf("%s:%d", nodeType, id)
+}
+
+// NeighbourhoodLookups returns the most TMDB lookups Neighbourhood needs: the
+// root and the credits of every node above the last level.
+func NeighbourhoodLookups(depth int, fanOut int) int {
+	total, nodes := 1, 1
+	for range depth {
+		total = saturatingAdd(total, nodes)
+		nodes = saturatingMul(nodes, fanOut)
+	}
+
+	return total
+}
+
+// PathLookups returns the most TMDB lookups Path needs: both people and the
+// target's credits, then the credits of every person on a level and, but on
+// the last level, of every movie they lead to.
+func PathLookups(maxDepth int, fanOut int) int {
+	total, people := 3, 1
+	for level := range maxDepth {
+		total = saturatingAdd(total, people)
+
+		movies := saturatingMul(people, fanOut)
+		if level < maxDepth-1 {
+			total = saturatingAdd(total, movies)
+		}
+
+		people = saturatingMul(movies, fanOut)
+	}
+
+	return total
+}
+
+func saturatingAdd(a int, b int) int {
+	if a > math.MaxInt-b {
+		return math.MaxInt
+	}
+
+	return a + b
+}
+
+func saturatingMul(a int, b int) int {
+	if b != 0 && a > math.MaxInt/b {
+		return math.MaxInt
+	}
+
+	return a * b
+}
+
+// Neighbourhood expands the graph around a movie or person up to depth levels,
+// following at most fanOut credits per node just like the graph page does.
+// Every TMDB lookup is counted by lookups, which may be nil.
+func Neighbourhood(ctx context.Context, client *tmdb.Client, lookups *Lookups, nodeType string, id int64, depth int, fanOut int) (*Graph, error) {
+	root, err := rootNode(ctx, client, lookups, nodeType, id)
+	if err != nil {
+		return nil, err
+	}
+
+	graph := Graph{Root: root.Key(), Nodes: []Node{root}, Edges: make([]Edge, 0)}
+	seen := map[string]bool{root.Key(): true}
+	frontier := []Node{root}
+
+	for level := 0; level < depth; level++ {
+		next := make([]Node, 0)
+
+		for _, node := range frontier {
+			children, err := Children(ctx, client, lookups, node, fanOut)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, child := range children {
+				graph.Edges = append(graph.Edges, Edge{From: node.Key(), To: child.Key()})
+
+				if seen[child.Key()] {
+					continue
+				}
+
+				seen[child.Key()] = true
+				graph.Nodes = append(graph.Nodes, child)
+				next = append(next, child)
+			}
+		}
+
+		frontier = next
+	}
+
+	return &graph, nil
+}
+
+// Children returns the first fanOut credits of a node. Movies keep TMDB's
+// billing order, people are ordered by the popularity of their movies.
+func Children(ctx context.Context, client *tmdb.Client, lookups *Lookups, node Node, fanOut int) ([]Node, error) {
+	idString := strconv.FormatInt(node.Id, 10)
+	children := make([]Node, 0)
+
+	err := lookups.take()
+	if err != nil {
+		return nil, err
+	}
+
+	switch node.Type {
+	case Movie:
+		credits, err := client.Credits(ctx, idString)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, credit := range credits.Cast[:min(fanOut, len(credits.Cast))] {
+			children = append(children, Node{
+				Type:      Person,
+				Id:        credit.Id,
+				Name:      credit.Name,
+				ImagePath: tmdb.ImageUrl(tmdb.ProfileImage, credit.ProfilePath, 500),
+			})
+		}
+	case Person:
+		credits, err := client.PeopleCredits(ctx, idString)
+		if err != nil {
+			return nil, err
+		}
+
+		sortByPopularity(credits.Cast)
+
+		for _, credit := range credits.Cast[:min(fanOut, len(credits.Cast))] {
+			children = append(children, Node{
+				Type:      Movie,
+				Id:        credit.Id,
+				Name:      credit.Title,
+				ImagePath: tmdb.ImageUrl(tmdb.PosterImage, credit.PosterPath, 500),
+			})
+		}
+	default:
+		return nil, fmt.Errorf("unknown node type %q", node.Type)
+	}
+
+	return children, nil
+}
+
+// Path searches for the shortest chain of shared movies between two people,
+// visiting at most maxDepth movies and fanOut credits per node. Every TMDB
+// lookup is counted by lookups, which may be nil.
+func Path(ctx context.Context, client *tmdb.Client, lookups *Lookups, fromId int64, toId int64, maxDepth int, fanOut int) ([]Node, error) {
+	from, err := rootNode(ctx, client, lookups, Person, fromId)
+	if err != nil {
+		return nil, err
+	}
+
+	to, err := rootNode(ctx, client, lookups, Person, toId)
+	if err != nil {
+		return nil, err
+	}
+
+	if fromId == toId {
+		return []Node{from}, nil
+	}
+
+	err = lookups.take()
+	if err != nil {
+		return nil, err
+	}
+
+	targetCredits, err := client.PeopleCredits(ctx, strconv.FormatInt(toId, 10))
+	if err != nil {
+		return nil, err
+	}
+
+	targetMovies := make(map[int64]bool)
+	for _, credit := range targetCredits.Cast {
+		targetMovies[credit.Id] = true
+	}
+
+	parents := map[string]Node{}
+	seen := map[string]bool{from.Key(): true}
+	frontier := []Node{from}
+
+	for level := 0; level < maxDepth && len(frontier) > 0; level++ {
+		next := make([]Node, 0)
+
+		for _, person := range frontier {
+			movies, err := Children(ctx, client, lookups, person, fanOut)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, movie := range movies {
+				if seen[movie.Key()] {
+					continue
+				}
+
+				seen[movie.Key()] = true
+				parents[movie.Key()] = person
+
+				if targetMovies[movie.Id] {
+					parents[to.Key()] = movie
+					return walkBack(parents, from, to), nil
+				}
+
+				if level == maxDepth-1 {
+					continue
+				}
+
+				people, err := Children(ctx, client, lookups, movie, fanOut)
+				if err != nil {
+					return nil, err
+				}
+
+				for _, p := range people {
+					if seen[p.Key()] {
+						continue
+					}
+
+					seen[p.Key()] = true
+					parents[p.Key()] = movie
+					next = append(next, p)
+				}
+			}
+		}
+
+		frontier = next
+	}
+
+	return nil, ErrNoPath
+}
+
+func walkBack(parents map[string]Node, from Node, to Node) []Node {
+	path := []Node{to}
+
+	for current := to; current.Key() != from.Key(); {
+		current = parents[current.Key()]
+		path = append(path, current)
+	}
+
+	slices.Reverse(path)
+
+	return path
+}
+
+func rootNode(ctx context.Context, client *tmdb.Client, lookups *Lookups, nodeType string, id int64) (Node, error) {
+	idString := strconv.FormatInt(id, 10)
+
+	err := lookups.take()
+	if err != nil {
+		return Node{}, err
+	}
+
+	switch nodeType {
+	case Movie:
+		movie, err := client.MovieDetails(ctx, idString)
+		if err != nil {
+			return Node{}, err
+		}
+
+		return Node{Type: Movie, Id: movie.Id, Name: movie.Title, ImagePath: tmdb.ImageUrl(tmdb.PosterImage, movie.PosterPath, 500)}, nil
+	case Person:
+		person, err := client.People(ctx, idString)
+		if err != nil {
+			return Node{}, err
+		}
+
+		return Node{Type: Person, Id: person.Id, Name: person.Name, ImagePath: tmdb.ImageUrl(tmdb.ProfileImage, person.ProfilePath, 500)}, nil
+	}
+
+	return Node{}, fmt.Errorf("unknown node type %q", nodeType)
+}
+
+func sortByPopularity(credits []tmdb.PeopleCredit) {
+	slices.SortFunc(credits,
+		func(a, b tmdb.PeopleCredit) int {
+			return cmp.Compare(b.Popularity, a.Popularity)
+		},
+	)
+}
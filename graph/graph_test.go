@@ -0,0 +1,259 @@
+package graph
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/m4tthewde/blunt/tmdb"
+)
+
+func TestLookupEstimates(t *testing.T) {
+	tests := []struct {
+		depth         int
+		fanOut        int
+		neighbourhood int
+		path          int
+	}{
+		{1, 5, 2, 4},
+		{2, 5, 7, 34},
+		{4, 5, 157, 19534},
+		{3, 1, 4, 8},
+		{100, 100, math.MaxInt, math.MaxInt},
+	}
+
+	for _, test := range tests {
+		if got := NeighbourhoodLookups(test.depth, test.fanOut); got != test.neighbourhood {
+			t.Errorf("NeighbourhoodLookups(%d, %d) = %d, want %d", test.depth, test.fanOut, got, test.neighbourhood)
+		}
+
+		if got := PathLookups(test.depth, test.fanOut); got != test.path {
+			t.Errorf("PathLookups(%d, %d) = %d, want %d", test.depth, test.fanOut, got, test.path)
+		}
+	}
+}
+
+// fakeWorld serves a few people and the movies they share:
+//
+//	person 1 stars in movie 10 with 2, in 11 with 3 and in 12 with 4
+//	person 5 stars in movie 13 with 2 and in 14 with 3
+//	person 6 only stars in movie 15
+//
+// Movie 11 is the most popular one of person 1, then 10 and 12.
+func fakeWorld(t *testing.T) *tmdb.Client {
+	t.Helper()
+
+	casts := map[int64][]int64{10: {1, 2}, 11: {1, 3}, 12: {1, 4}, 13: {2, 5}, 14: {3, 5}, 15: {6}}
+	popularity := map[int64]float64{10: 5, 11: 9, 12: 1, 13: 2, 14: 3, 15: 1}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+		id, _ := strconv.ParseInt(parts[1], 10, 64)
+
+		var response any
+
+		switch {
+		case len(parts) == 2 && parts[0] == "person" && id >= 1 && id <= 6:
+			response = tmdb.PeopleResponse{Id: id, Name: fmt.Sprintf("Person %d", id)}
+		case len(parts) == 2 && parts[0] == "movie" && casts[id] != nil:
+			response = tmdb.MovieDetailsResponse{Id: id, Title: fmt.Sprintf("Movie %d", id)}
+		case len(parts) == 3 && parts[0] == "person" && parts[2] == "movie_credits" && id >= 1 && id <= 6:
+			cast := make([]tmdb.PeopleCredit, 0)
+			for movie := int64(10); movie <= 15; movie++ {
+				if slices.Contains(casts[movie], id) {
+					cast = append(cast, tmdb.PeopleCredit{Id: movie, Title: fmt.Sprintf("Movie %d", movie), Popularity: popularity[movie]})
+				}
+			}
+
+			response = tmdb.PeopleCreditsResponse{Cast: cast}
+		case len(parts) == 3 && parts[0] == "movie" && parts[2] == "credits" && casts[id] != nil:
+			cast := make([]tmdb.MovieCastMember, 0)
+			for _, person := range casts[id] {
+				cast = append(cast, tmdb.MovieCastMember{Id: person, Name: fmt.Sprintf("Person %d", person)})
+			}
+
+			response = tmdb.MovieCreditsResponse{Id: id, Cast: cast}
+		default:
+			http.NotFound(w, r)
+			return
+		}
+
+		json.NewEncoder(w).Encode(response)
+	}))
+	t.Cleanup(server.Close)
+
+	return tmdb.NewClient(tmdb.Options{BaseUrl: server.URL})
+}
+
+func keys(nodes []Node) []string {
+	result := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		result = append(result, node.Key())
+	}
+
+	return result
+}
+
+func TestPath(t *testing.T) {
+	client := fakeWorld(t)
+
+	tests := []struct {
+		name     string
+		from, to int64
+		maxDepth int
+		fanOut   int
+		want     []string
+		err      error
+	}{
+		{
+			name: "one movie apart", from: 1, to: 2, maxDepth: 1, fanOut: 3,
+			want: []string{"person:1", "movie:10", "person:2"},
+		},
+		{
+			// both ways take two movies, the more popular one is tried first
+			name: "two movies apart", from: 1, to: 5, maxDepth: 2, fanOut: 3,
+			want: []string{"person:1", "movie:11", "person:3", "movie:14", "person:5"},
+		},
+		{
+			name: "reversed", from: 5, to: 1, maxDepth: 2, fanOut: 3,
+			want: []string{"person:5", "movie:14", "person:3", "movie:11", "person:1"},
+		},
+		{name: "same person", from: 1, to: 1, maxDepth: 1, fanOut: 1, want: []string{"person:1"}},
+		{name: "too deep", from: 1, to: 5, maxDepth: 1, fanOut: 3, err: ErrNoPath},
+		{
+			// movie 11 is followed, but only to person 1 billed first
+			name: "fanout", from: 1, to: 5, maxDepth: 2, fanOut: 1, err: ErrNoPath,
+		},
+		{name: "not connected", from: 1, to: 6, maxDepth: 4, fanOut: 3, err: ErrNoPath},
+		{name: "unknown person", from: 1, to: 99, maxDepth: 2, fanOut: 3, err: tmdb.ErrNotFound},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path, err := Path(context.Background(), client, nil, test.from, test.to, test.maxDepth, test.fanOut)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("error %v, want %v", err, test.err)
+			}
+
+			if got := keys(path); test.err == nil && !slices.Equal(got, test.want) {
+				t.Errorf("path %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestPathLookups(t *testing.T) {
+	client := fakeWorld(t)
+
+	// both people, the target's credits and the credits of person 1
+	lookups := Lookups{Limit: 10}
+
+	_, err := Path(context.Background(), client, &lookups, 1, 2, 1, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if lookups.Used() != 4 {
+		t.Errorf("%d lookups, want 4", lookups.Used())
+	}
+
+	lookups = Lookups{Limit: 3}
+
+	_, err = Path(context.Background(), client, &lookups, 1, 2, 1, 3)
+	if !errors.As(err, new(*ErrTooManyLookups)) {
+		t.Errorf("error %v, want ErrTooManyLookups", err)
+	}
+
+	// searching everything stays within the estimate
+	lookups = Lookups{Limit: PathLookups(2, 3)}
+
+	_, err = Path(context.Background(), client, &lookups, 1, 6, 2, 3)
+	if !errors.Is(err, ErrNoPath) {
+		t.Fatalf("error %v, want ErrNoPath", err)
+	}
+
+	// a limit of 0 neither limits nor counts
+	lookups = Lookups{}
+
+	_, err = Path(context.Background(), client, &lookups, 1, 5, 2, 3)
+	if err != nil || lookups.Used() != 0 {
+		t.Errorf("error %v, %d lookups counted", err, lookups.Used())
+	}
+}
+
+func TestNeighbourhood(t *testing.T) {
+	client := fakeWorld(t)
+
+	tests := []struct {
+		name     string
+		nodeType string
+		id       int64
+		depth    int
+		fanOut   int
+		nodes    []string
+		edges    int
+	}{
+		{
+			name: "person", nodeType: Person, id: 1, depth: 1, fanOut: 3,
+			nodes: []string{"person:1", "movie:11", "movie:10", "movie:12"},
+			edges: 3,
+		},
+		{
+			name: "fanout", nodeType: Person, id: 1, depth: 1, fanOut: 2,
+			nodes: []string{"person:1", "movie:11", "movie:10"},
+			edges: 2,
+		},
+		{
+			// every movie leads back to person 1, which is an edge but no
+			// new node
+			name: "two levels", nodeType: Person, id: 1, depth: 2, fanOut: 3,
+			nodes: []string{"person:1", "movie:11", "movie:10", "movie:12", "person:3", "person:2", "person:4"},
+			edges: 9,
+		},
+		{
+			name: "movie", nodeType: Movie, id: 13, depth: 2, fanOut: 3,
+			nodes: []string{"movie:13", "person:2", "person:5", "movie:10", "movie:14"},
+			edges: 6,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			lookups := Lookups{Limit: 100}
+
+			g, err := Neighbourhood(context.Background(), client, &lookups, test.nodeType, test.id, test.depth, test.fanOut)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := keys(g.Nodes); !slices.Equal(got, test.nodes) {
+				t.Errorf("nodes %v, want %v", got, test.nodes)
+			}
+
+			if len(g.Edges) != test.edges {
+				t.Errorf("%d edges, want %d", len(g.Edges), test.edges)
+			}
+
+			if g.Root != test.nodes[0] {
+				t.Errorf("root %s", g.Root)
+			}
+
+			if lookups.Used() > NeighbourhoodLookups(test.depth, test.fanOut) {
+				t.Errorf("%d lookups, estimated at most %d", lookups.Used(), NeighbourhoodLookups(test.depth, test.fanOut))
+			}
+		})
+	}
+
+	_, err := Neighbourhood(context.Background(), client, nil, "series", 1, 1, 1)
+	if err == nil {
+		t.Error("unknown node type was expanded")
+	}
+}
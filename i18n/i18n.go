@@ -0,0 +1,65 @@
+package i18n
+
+import (
+	"context"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/language/display"
+)
+
+// Supported lists the languages the UI is translated to, the first one is
+// the fallback.
+var Supported = []language.Tag{
+	language.English,
+	language.German,
+	language.French,
+	language.Spanish,
+}
+
+var matcher = language.NewMatcher(Supported)
+
+type languageKey struct{}
+
+// Match returns the best supported UI language for the given preferences.
+func Match(preferred ...language.Tag) language.Tag {
+	_, index, _ := matcher.Match(preferred...)
+
+	return Supported[index]
+}
+
+func WithLanguage(ctx context.Context, tag language.Tag) context.Context {
+	return context.WithValue(ctx, languageKey{}, tag)
+}
+
+// Language returns the UI language of ctx, e.g. "de".
+func Language(ctx context.Context) string {
+	tag, ok := ctx.Value(languageKey{}).(language.Tag)
+	if !ok {
+		tag = Supported[0]
+	}
+
+	base, _ := tag.Base()
+
+	return base.String()
+}
+
+// T translates key into the UI language of ctx, falling back to English and
+// finally to the key itself.
+func T(ctx context.Context, key string) string {
+	message, ok := messages[Language(ctx)][key]
+	if ok {
+		return message
+	}
+
+	message, ok = messages["en"][key]
+	if ok {
+		return message
+	}
+
+	return key
+}
+
+// DisplayName returns the name of tag in its own language, e.g. "Deutsch".
+func DisplayName(tag language.Tag) string {
+	return display.Self.Name(tag)
+}
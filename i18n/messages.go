@@ -0,0 +1,784 @@
+package i18n
+
+var messages = map[string]map[string]string{
+	"en": {
+		"index.title":                          "Movie Explorer",
+		"index.search":                         "Search Movies/People...",
+		"index.language":                       "Language",
+		"movie.date":                           "Date:",
+		"movie.tagline":                        "Tagline:",
+		"movie.runtime":                        "Runtime:",
+		"movie.language":                       "Language:",
+		"movie.revenue":                        "Revenue:",
+		"movie.certification":                  "Certification:",
+		"movie.releases":                       "Releases",
+		"movie.cast":                           "Cast",
+		"graph.button":                         "Graph",
+		"person.birthday":                      "Birthday:",
+		"person.deathday":                      "Deathday:",
+		"person.placeOfBirth":                  "Place of birth:",
+		"person.knownFor":                      "Known for:",
+		"person.homepage":                      "Homepage:",
+		"person.credits":                       "Credits",
+		"family.pending":                       "more titles are still being checked for family mode, reload the page to see the suitable ones.",
+		"rateLimit.title":                      "Too many requests",
+		"rateLimit.message":                    "You are sending requests faster than this explorer can answer them.",
+		"rateLimit.retry":                      "Try again in",
+		"account.login":                        "Log in",
+		"account.logout":                       "Log out",
+		"account.register":                     "Register",
+		"account.name":                         "Name",
+		"account.password":                     "Password",
+		"account.passwordRepeat":               "Repeat password",
+		"account.signedInAs":                   "Signed in as",
+		"account.noAccount":                    "No account yet?",
+		"account.haveAccount":                  "Already registered?",
+		"account.error.invalidName":            "Names are 3 to 32 letters, digits, dots, dashes or underscores.",
+		"account.error.weakPassword":           "Passwords need at least 10 characters.",
+		"account.error.passwordMismatch":       "The passwords don't match.",
+		"account.error.nameTaken":              "This name is already taken.",
+		"account.error.invalidCredentials":     "Unknown name or wrong password.",
+		"library.watchlist":                    "Watchlist",
+		"library.history":                      "History",
+		"library.loginToSave":                  "Log in to keep a watchlist and history",
+		"library.addToWatchlist":               "Add to watchlist",
+		"library.removeFromWatchlist":          "Remove from watchlist",
+		"library.markWatched":                  "Mark watched",
+		"library.rating":                       "Rating",
+		"library.noRating":                     "No rating",
+		"library.watchedOn":                    "Watched on",
+		"library.delete":                       "Delete",
+		"library.added":                        "Added",
+		"library.empty":                        "Nothing here yet.",
+		"library.stats.viewings":               "Viewings:",
+		"library.stats.movies":                 "Movies:",
+		"library.stats.runtime":                "Time watched:",
+		"library.stats.unknown":                "runtime unknown:",
+		"library.stats.rating":                 "Average rating:",
+		"library.filter.search":                "Title",
+		"library.filter.year":                  "Year",
+		"library.filter.rating":                "Minimum rating",
+		"library.filter.anyRating":             "Any rating",
+		"library.filter.sort":                  "Sort by",
+		"library.filter.order":                 "Order",
+		"library.filter.apply":                 "Apply",
+		"library.sort.added":                   "Date added",
+		"library.sort.title":                   "Title",
+		"library.sort.release":                 "Release date",
+		"library.sort.runtime":                 "Runtime",
+		"library.sort.watched":                 "Date watched",
+		"library.sort.rating":                  "Rating",
+		"library.order.desc":                   "Descending",
+		"library.order.asc":                    "Ascending",
+		"transfer.title":                       "Import & export",
+		"transfer.import":                      "Import",
+		"transfer.importHelp":                  "Import the CSV files of a Letterboxd or IMDb export. Movies are matched by their IMDb id, or by title and year. Importing a file again only adds what is new.",
+		"transfer.format":                      "File",
+		"transfer.format.letterboxd-diary":     "Letterboxd diary",
+		"transfer.format.letterboxd-watched":   "Letterboxd watched",
+		"transfer.format.letterboxd-ratings":   "Letterboxd ratings",
+		"transfer.format.letterboxd-watchlist": "Letterboxd watchlist",
+		"transfer.format.imdb-ratings":         "IMDb ratings",
+		"transfer.file":                        "CSV file",
+		"transfer.running":                     "Importing…",
+		"transfer.finished":                    "Import finished",
+		"transfer.stopped":                     "The import stopped early. Importing the file again continues where it stopped.",
+		"transfer.count.processed":             "Rows processed:",
+		"transfer.count.imported":              "Imported:",
+		"transfer.count.unchanged":             "Already in the library:",
+		"transfer.count.unmatched":             "Unmatched:",
+		"transfer.count.skipped":               "Skipped, not movies:",
+		"transfer.error.running":               "An import is already running.",
+		"transfer.error.file":                  "Choose a CSV file of at most 10 MB.",
+		"transfer.error.parse":                 "The file couldn't be read:",
+		"transfer.issues":                      "Unmatched rows",
+		"transfer.issuesHelp":                  "Enter the TMDB id, a TMDB link or the IMDb id of the movie, or dismiss the row.",
+		"transfer.line":                        "line",
+		"transfer.kind.watchlist":              "Watchlist",
+		"transfer.kind.viewing":                "Watched on",
+		"transfer.kind.watched":                "Watched",
+		"transfer.kind.rating":                 "Rated",
+		"transfer.reason.not-found":            "No movie found",
+		"transfer.reason.ambiguous":            "Several movies match",
+		"transfer.reason.invalid":              "Invalid date or rating",
+		"transfer.movie":                       "TMDB id, TMDB link or IMDb id",
+		"transfer.dismiss":                     "Dismiss",
+		"transfer.searchTmdb":                  "Search on TMDB",
+		"transfer.error.movie":                 "No movie with this id.",
+		"transfer.export":                      "Export",
+		"transfer.exportHelp":                  "Download the library as CSV files in the formats of Letterboxd and IMDb.",
+		"details.links":                        "Links:",
+		"lists.title":                          "Lists",
+		"lists.create":                         "Create list",
+		"lists.edit":                           "Edit list",
+		"lists.name":                           "Name",
+		"lists.description":                    "Description",
+		"lists.save":                           "Save",
+		"lists.delete":                         "Delete list",
+		"lists.deleteConfirm":                  "Delete this list for everyone?",
+		"lists.itemCount":                      "items",
+		"lists.by":                             "by",
+		"lists.shared":                         "shared by link",
+		"lists.export":                         "Export:",
+		"lists.note":                           "Note",
+		"lists.saveNote":                       "Save note",
+		"lists.moveUp":                         "Move up",
+		"lists.moveDown":                       "Move down",
+		"lists.remove":                         "Remove",
+		"lists.addedBy":                        "added by",
+		"lists.members":                        "Collaborators",
+		"lists.memberName":                     "Account name",
+		"lists.addMember":                      "Invite",
+		"lists.leave":                          "Leave list",
+		"lists.leaveConfirm":                   "Stop collaborating on this list?",
+		"lists.sharing":                        "Sharing",
+		"lists.share":                          "Share read-only by link",
+		"lists.shareLink":                      "Anyone with this link can view the list:",
+		"lists.unshare":                        "Stop sharing",
+		"lists.addTo":                          "Add to list",
+		"lists.addedTo":                        "Added to",
+		"lists.createFirst":                    "Create a list to collect movies and people",
+		"lists.error.invalidName":              "List names are 1 to 100 characters.",
+		"lists.error.invalidNote":              "Notes are at most 1000 characters.",
+		"lists.error.noUser":                   "There is no other account with this name.",
+		"follow.title":                         "Notifications",
+		"follow.follow":                        "Follow",
+		"follow.unfollow":                      "Unfollow",
+		"follow.following":                     "Following",
+		"follow.noneFollowed":                  "Follow people on their page to hear about their new movies.",
+		"follow.noNotifications":               "No notifications yet.",
+		"follow.markRead":                      "Mark all as read",
+		"follow.kind.announced":                "is in a newly announced movie",
+		"follow.kind.released":                 "is in a movie that was just released",
+		"follow.feed":                          "Feed",
+		"follow.feedLink":                      "Subscribe to your notifications in a feed reader:",
+		"follow.feedTitle":                     "Notifications of",
+		"follow.resetFeed":                     "Reset feed link",
+		"follow.resetFeedConfirm":              "The current feed link will stop working. Continue?",
+		"follow.webhooks":                      "Webhooks",
+		"follow.webhooksHelp":                  "Notifications are posted as JSON, signed with the secret in the X-Blunt-Signature header.",
+		"follow.webhookUrl":                    "Webhook URL",
+		"follow.addWebhook":                    "Add webhook",
+		"follow.secret":                        "Secret:",
+		"follow.test":                          "Send test",
+		"follow.testDelivered":                 "Delivered.",
+		"follow.testFailed":                    "Delivery failed, check the URL and that it answers with a 2xx status.",
+		"follow.error.invalidUrl":              "Webhook URLs start with http:// or https:// and point to a public host.",
+		"follow.error.tooManyWebhooks":         "You have reached the limit of webhooks.",
+		"releases.title":                       "Upcoming releases",
+		"releases.subscribe":                   "Calendar of upcoming releases",
+		"releases.type.premiere":               "Premiere",
+		"releases.type.theatricalLimited":      "Limited theatrical release",
+		"releases.type.theatrical":             "Theatrical release",
+		"releases.type.digital":                "Digital release",
+		"releases.type.physical":               "Physical release",
+		"releases.type.tv":                     "TV premiere",
+		"releases.type.other":                  "Release",
+		"settings.title":                       "Settings",
+		"settings.saved":                       "Saved.",
+		"watch.title":                          "Where to watch",
+		"watch.services":                       "My services",
+		"watch.servicesHelp":                   "Search and filmographies can show only movies you can stream, rent or buy on these services.",
+		"watch.noServices":                     "Select your services to filter by them:",
+		"watch.onlyAvailable":                  "Only on my services",
+		"watch.showAvailable":                  "Show only movies on my services",
+		"watch.showAll":                        "Show all movies",
+		"watch.flatrate":                       "Stream",
+		"watch.rent":                           "Rent",
+		"watch.buy":                            "Buy",
+		"watch.none":                           "Not available to stream, rent or buy.",
+		"watch.more":                           "All offers",
+		"watch.unchecked":                      "more movies weren't checked for your services and are left out.",
+		"watch.attribution":                    "Streaming data by JustWatch.",
+		"recommend.title":                      "Recommendations",
+		"recommend.noHistory":                  "Recommendations are based on what you watched and rated. Start your",
+		"recommend.reason.cast":                "actors from movies you rated highly appear in this:",
+		"recommend.reason.recommended":         "Recommended to fans of",
+		"recommend.reason.similar":             "Similar to",
+	},
+	"de": {
+		"index.title":                          "Film-Explorer",
+		"index.search":                         "Filme/Personen suchen...",
+		"index.language":                       "Sprache",
+		"movie.date":                           "Datum:",
+		"movie.tagline":                        "Slogan:",
+		"movie.runtime":                        "Laufzeit:",
+		"movie.language":                       "Sprache:",
+		"movie.revenue":                        "Einnahmen:",
+		"movie.certification":                  "Altersfreigabe:",
+		"movie.releases":                       "Veröffentlichungen",
+		"movie.cast":                           "Besetzung",
+		"graph.button":                         "Graph",
+		"person.birthday":                      "Geburtstag:",
+		"person.deathday":                      "Todestag:",
+		"person.placeOfBirth":                  "Geburtsort:",
+		"person.knownFor":                      "Bekannt für:",
+		"person.homepage":                      "Homepage:",
+		"person.credits":                       "Filmografie",
+		"family.pending":                       "weitere Titel werden noch für den Familienmodus geprüft, lade die Seite neu, um die geeigneten zu sehen.",
+		"rateLimit.title":                      "Zu viele Anfragen",
+		"rateLimit.message":                    "Du sendest Anfragen schneller, als der Explorer sie beantworten kann.",
+		"rateLimit.retry":                      "Versuche es erneut in",
+		"account.login":                        "Anmelden",
+		"account.logout":                       "Abmelden",
+		"account.register":                     "Registrieren",
+		"account.name":                         "Name",
+		"account.password":                     "Passwort",
+		"account.passwordRepeat":               "Passwort wiederholen",
+		"account.signedInAs":                   "Angemeldet als",
+		"account.noAccount":                    "Noch kein Konto?",
+		"account.haveAccount":                  "Bereits registriert?",
+		"account.error.invalidName":            "Namen bestehen aus 3 bis 32 Buchstaben, Ziffern, Punkten, Binde- oder Unterstrichen.",
+		"account.error.weakPassword":           "Passwörter brauchen mindestens 10 Zeichen.",
+		"account.error.passwordMismatch":       "Die Passwörter stimmen nicht überein.",
+		"account.error.nameTaken":              "Dieser Name ist bereits vergeben.",
+		"account.error.invalidCredentials":     "Unbekannter Name oder falsches Passwort.",
+		"library.watchlist":                    "Merkliste",
+		"library.history":                      "Verlauf",
+		"library.loginToSave":                  "Melde dich an, um eine Merkliste und einen Verlauf zu führen",
+		"library.addToWatchlist":               "Auf die Merkliste",
+		"library.removeFromWatchlist":          "Von der Merkliste entfernen",
+		"library.markWatched":                  "Als gesehen markieren",
+		"library.rating":                       "Bewertung",
+		"library.noRating":                     "Keine Bewertung",
+		"library.watchedOn":                    "Gesehen am",
+		"library.delete":                       "Löschen",
+		"library.added":                        "Hinzugefügt",
+		"library.empty":                        "Noch nichts hier.",
+		"library.stats.viewings":               "Sichtungen:",
+		"library.stats.movies":                 "Filme:",
+		"library.stats.runtime":                "Gesehene Zeit:",
+		"library.stats.unknown":                "Laufzeit unbekannt:",
+		"library.stats.rating":                 "Durchschnittliche Bewertung:",
+		"library.filter.search":                "Titel",
+		"library.filter.year":                  "Jahr",
+		"library.filter.rating":                "Mindestbewertung",
+		"library.filter.anyRating":             "Jede Bewertung",
+		"library.filter.sort":                  "Sortieren nach",
+		"library.filter.order":                 "Reihenfolge",
+		"library.filter.apply":                 "Anwenden",
+		"library.sort.added":                   "Hinzugefügt am",
+		"library.sort.title":                   "Titel",
+		"library.sort.release":                 "Erscheinungsdatum",
+		"library.sort.runtime":                 "Laufzeit",
+		"library.sort.watched":                 "Gesehen am",
+		"library.sort.rating":                  "Bewertung",
+		"library.order.desc":                   "Absteigend",
+		"library.order.asc":                    "Aufsteigend",
+		"transfer.title":                       "Import & Export",
+		"transfer.import":                      "Importieren",
+		"transfer.importHelp":                  "Importiere die CSV-Dateien eines Letterboxd- oder IMDb-Exports. Filme werden über ihre IMDb-ID oder über Titel und Jahr zugeordnet. Ein erneuter Import fügt nur Neues hinzu.",
+		"transfer.format":                      "Datei",
+		"transfer.format.letterboxd-diary":     "Letterboxd-Tagebuch",
+		"transfer.format.letterboxd-watched":   "Letterboxd gesehen",
+		"transfer.format.letterboxd-ratings":   "Letterboxd-Bewertungen",
+		"transfer.format.letterboxd-watchlist": "Letterboxd-Merkliste",
+		"transfer.format.imdb-ratings":         "IMDb-Bewertungen",
+		"transfer.file":                        "CSV-Datei",
+		"transfer.running":                     "Import läuft…",
+		"transfer.finished":                    "Import abgeschlossen",
+		"transfer.stopped":                     "Der Import wurde vorzeitig beendet. Ein erneuter Import der Datei macht dort weiter.",
+		"transfer.count.processed":             "Verarbeitete Zeilen:",
+		"transfer.count.imported":              "Importiert:",
+		"transfer.count.unchanged":             "Bereits in der Bibliothek:",
+		"transfer.count.unmatched":             "Nicht zugeordnet:",
+		"transfer.count.skipped":               "Übersprungen, keine Filme:",
+		"transfer.error.running":               "Es läuft bereits ein Import.",
+		"transfer.error.file":                  "Wähle eine CSV-Datei von höchstens 10 MB.",
+		"transfer.error.parse":                 "Die Datei konnte nicht gelesen werden:",
+		"transfer.issues":                      "Nicht zugeordnete Zeilen",
+		"transfer.issuesHelp":                  "Gib die TMDB-ID, einen TMDB-Link oder die IMDb-ID des Films ein, oder verwirf die Zeile.",
+		"transfer.line":                        "Zeile",
+		"transfer.kind.watchlist":              "Merkliste",
+		"transfer.kind.viewing":                "Gesehen am",
+		"transfer.kind.watched":                "Gesehen",
+		"transfer.kind.rating":                 "Bewertet",
+		"transfer.reason.not-found":            "Kein Film gefunden",
+		"transfer.reason.ambiguous":            "Mehrere Filme passen",
+		"transfer.reason.invalid":              "Ungültiges Datum oder ungültige Bewertung",
+		"transfer.movie":                       "TMDB-ID, TMDB-Link oder IMDb-ID",
+		"transfer.dismiss":                     "Verwerfen",
+		"transfer.searchTmdb":                  "Auf TMDB suchen",
+		"transfer.error.movie":                 "Kein Film mit dieser ID.",
+		"transfer.export":                      "Exportieren",
+		"transfer.exportHelp":                  "Lade die Bibliothek als CSV-Dateien in den Formaten von Letterboxd und IMDb herunter.",
+		"details.links":                        "Links:",
+		"lists.title":                          "Listen",
+		"lists.create":                         "Liste anlegen",
+		"lists.edit":                           "Liste bearbeiten",
+		"lists.name":                           "Name",
+		"lists.description":                    "Beschreibung",
+		"lists.save":                           "Speichern",
+		"lists.delete":                         "Liste löschen",
+		"lists.deleteConfirm":                  "Diese Liste für alle löschen?",
+		"lists.itemCount":                      "Einträge",
+		"lists.by":                             "von",
+		"lists.shared":                         "per Link geteilt",
+		"lists.export":                         "Exportieren:",
+		"lists.note":                           "Notiz",
+		"lists.saveNote":                       "Notiz speichern",
+		"lists.moveUp":                         "Nach oben",
+		"lists.moveDown":                       "Nach unten",
+		"lists.remove":                         "Entfernen",
+		"lists.addedBy":                        "hinzugefügt von",
+		"lists.members":                        "Mitwirkende",
+		"lists.memberName":                     "Kontoname",
+		"lists.addMember":                      "Einladen",
+		"lists.leave":                          "Liste verlassen",
+		"lists.leaveConfirm":                   "Nicht mehr an dieser Liste mitwirken?",
+		"lists.sharing":                        "Teilen",
+		"lists.share":                          "Schreibgeschützt per Link teilen",
+		"lists.shareLink":                      "Jeder mit diesem Link kann die Liste ansehen:",
+		"lists.unshare":                        "Nicht mehr teilen",
+		"lists.addTo":                          "Zur Liste hinzufügen",
+		"lists.addedTo":                        "Hinzugefügt zu",
+		"lists.createFirst":                    "Lege eine Liste an, um Filme und Personen zu sammeln",
+		"lists.error.invalidName":              "Listennamen haben 1 bis 100 Zeichen.",
+		"lists.error.invalidNote":              "Notizen haben höchstens 1000 Zeichen.",
+		"lists.error.noUser":                   "Es gibt kein anderes Konto mit diesem Namen.",
+		"follow.title":                         "Benachrichtigungen",
+		"follow.follow":                        "Folgen",
+		"follow.unfollow":                      "Nicht mehr folgen",
+		"follow.following":                     "Du folgst",
+		"follow.noneFollowed":                  "Folge Personen auf ihrer Seite, um von ihren neuen Filmen zu erfahren.",
+		"follow.noNotifications":               "Noch keine Benachrichtigungen.",
+		"follow.markRead":                      "Alle als gelesen markieren",
+		"follow.kind.announced":                "spielt in einem neu angekündigten Film mit",
+		"follow.kind.released":                 "spielt in einem gerade erschienenen Film mit",
+		"follow.feed":                          "Feed",
+		"follow.feedLink":                      "Abonniere deine Benachrichtigungen in einem Feedreader:",
+		"follow.feedTitle":                     "Benachrichtigungen von",
+		"follow.resetFeed":                     "Feed-Link zurücksetzen",
+		"follow.resetFeedConfirm":              "Der aktuelle Feed-Link funktioniert dann nicht mehr. Fortfahren?",
+		"follow.webhooks":                      "Webhooks",
+		"follow.webhooksHelp":                  "Benachrichtigungen werden als JSON gesendet, signiert mit dem Secret im Header X-Blunt-Signature.",
+		"follow.webhookUrl":                    "Webhook-URL",
+		"follow.addWebhook":                    "Webhook hinzufügen",
+		"follow.secret":                        "Secret:",
+		"follow.test":                          "Test senden",
+		"follow.testDelivered":                 "Zugestellt.",
+		"follow.testFailed":                    "Zustellung fehlgeschlagen, prüfe die URL und ob sie mit einem 2xx-Status antwortet.",
+		"follow.error.invalidUrl":              "Webhook-URLs beginnen mit http:// oder https:// und zeigen auf einen öffentlichen Host.",
+		"follow.error.tooManyWebhooks":         "Du hast die maximale Anzahl an Webhooks erreicht.",
+		"releases.title":                       "Kommende Veröffentlichungen",
+		"releases.subscribe":                   "Kalender der kommenden Veröffentlichungen",
+		"releases.type.premiere":               "Premiere",
+		"releases.type.theatricalLimited":      "Kinostart (limitiert)",
+		"releases.type.theatrical":             "Kinostart",
+		"releases.type.digital":                "Digitale Veröffentlichung",
+		"releases.type.physical":               "DVD/Blu-ray",
+		"releases.type.tv":                     "TV-Premiere",
+		"releases.type.other":                  "Veröffentlichung",
+		"settings.title":                       "Einstellungen",
+		"settings.saved":                       "Gespeichert.",
+		"watch.title":                          "Verfügbarkeit",
+		"watch.services":                       "Meine Dienste",
+		"watch.servicesHelp":                   "Suche und Filmografien können nur Filme zeigen, die du bei diesen Diensten streamen, leihen oder kaufen kannst.",
+		"watch.noServices":                     "Wähle deine Dienste aus, um danach zu filtern:",
+		"watch.onlyAvailable":                  "Nur bei meinen Diensten",
+		"watch.showAvailable":                  "Nur Filme bei meinen Diensten zeigen",
+		"watch.showAll":                        "Alle Filme zeigen",
+		"watch.flatrate":                       "Streamen",
+		"watch.rent":                           "Leihen",
+		"watch.buy":                            "Kaufen",
+		"watch.none":                           "Weder zum Streamen, Leihen noch Kaufen verfügbar.",
+		"watch.more":                           "Alle Angebote",
+		"watch.unchecked":                      "weitere Filme wurden nicht für deine Dienste geprüft und fehlen.",
+		"watch.attribution":                    "Streaming-Daten von JustWatch.",
+		"recommend.title":                      "Empfehlungen",
+		"recommend.noHistory":                  "Empfehlungen beruhen auf dem, was du gesehen und bewertet hast. Beginne deinen",
+		"recommend.reason.cast":                "Schauspieler aus Filmen, die du hoch bewertet hast, spielen mit:",
+		"recommend.reason.recommended":         "Empfohlen für Fans von",
+		"recommend.reason.similar":             "Ähnlich wie",
+	},
+	"fr": {
+		"index.title":                          "Explorateur de films",
+		"index.search":                         "Rechercher films/personnes...",
+		"index.language":                       "Langue",
+		"movie.date":                           "Date :",
+		"movie.tagline":                        "Slogan :",
+		"movie.runtime":                        "Durée :",
+		"movie.language":                       "Langue :",
+		"movie.revenue":                        "Recettes :",
+		"movie.certification":                  "Classification :",
+		"movie.releases":                       "Sorties",
+		"movie.cast":                           "Distribution",
+		"graph.button":                         "Graphe",
+		"person.birthday":                      "Date de naissance :",
+		"person.deathday":                      "Date de décès :",
+		"person.placeOfBirth":                  "Lieu de naissance :",
+		"person.knownFor":                      "Connu pour :",
+		"person.homepage":                      "Site web :",
+		"person.credits":                       "Filmographie",
+		"family.pending":                       "autres titres sont encore vérifiés pour le mode famille, rechargez la page pour voir ceux qui conviennent.",
+		"rateLimit.title":                      "Trop de requêtes",
+		"rateLimit.message":                    "Vous envoyez des requêtes plus vite que l'explorateur ne peut y répondre.",
+		"rateLimit.retry":                      "Réessayez dans",
+		"account.login":                        "Se connecter",
+		"account.logout":                       "Se déconnecter",
+		"account.register":                     "S'inscrire",
+		"account.name":                         "Nom",
+		"account.password":                     "Mot de passe",
+		"account.passwordRepeat":               "Répéter le mot de passe",
+		"account.signedInAs":                   "Connecté en tant que",
+		"account.noAccount":                    "Pas encore de compte ?",
+		"account.haveAccount":                  "Déjà inscrit ?",
+		"account.error.invalidName":            "Les noms comptent 3 à 32 lettres, chiffres, points, tirets ou tirets bas.",
+		"account.error.weakPassword":           "Les mots de passe doivent comporter au moins 10 caractères.",
+		"account.error.passwordMismatch":       "Les mots de passe ne correspondent pas.",
+		"account.error.nameTaken":              "Ce nom est déjà pris.",
+		"account.error.invalidCredentials":     "Nom inconnu ou mot de passe erroné.",
+		"library.watchlist":                    "À voir",
+		"library.history":                      "Historique",
+		"library.loginToSave":                  "Connectez-vous pour tenir une liste à voir et un historique",
+		"library.addToWatchlist":               "Ajouter à voir",
+		"library.removeFromWatchlist":          "Retirer de la liste à voir",
+		"library.markWatched":                  "Marquer comme vu",
+		"library.rating":                       "Note",
+		"library.noRating":                     "Sans note",
+		"library.watchedOn":                    "Vu le",
+		"library.delete":                       "Supprimer",
+		"library.added":                        "Ajouté le",
+		"library.empty":                        "Rien pour l'instant.",
+		"library.stats.viewings":               "Visionnages :",
+		"library.stats.movies":                 "Films :",
+		"library.stats.runtime":                "Temps passé :",
+		"library.stats.unknown":                "durée inconnue :",
+		"library.stats.rating":                 "Note moyenne :",
+		"library.filter.search":                "Titre",
+		"library.filter.year":                  "Année",
+		"library.filter.rating":                "Note minimale",
+		"library.filter.anyRating":             "Toutes les notes",
+		"library.filter.sort":                  "Trier par",
+		"library.filter.order":                 "Ordre",
+		"library.filter.apply":                 "Appliquer",
+		"library.sort.added":                   "Date d'ajout",
+		"library.sort.title":                   "Titre",
+		"library.sort.release":                 "Date de sortie",
+		"library.sort.runtime":                 "Durée",
+		"library.sort.watched":                 "Date de visionnage",
+		"library.sort.rating":                  "Note",
+		"library.order.desc":                   "Décroissant",
+		"library.order.asc":                    "Croissant",
+		"transfer.title":                       "Import et export",
+		"transfer.import":                      "Importer",
+		"transfer.importHelp":                  "Importe les fichiers CSV d'un export Letterboxd ou IMDb. Les films sont reconnus par leur identifiant IMDb, ou par leur titre et leur année. Importer à nouveau un fichier n'ajoute que les nouveautés.",
+		"transfer.format":                      "Fichier",
+		"transfer.format.letterboxd-diary":     "Journal Letterboxd",
+		"transfer.format.letterboxd-watched":   "Films vus Letterboxd",
+		"transfer.format.letterboxd-ratings":   "Notes Letterboxd",
+		"transfer.format.letterboxd-watchlist": "Watchlist Letterboxd",
+		"transfer.format.imdb-ratings":         "Notes IMDb",
+		"transfer.file":                        "Fichier CSV",
+		"transfer.running":                     "Import en cours…",
+		"transfer.finished":                    "Import terminé",
+		"transfer.stopped":                     "L'import s'est arrêté avant la fin. Importer à nouveau le fichier reprend là où il s'est arrêté.",
+		"transfer.count.processed":             "Lignes traitées :",
+		"transfer.count.imported":              "Importées :",
+		"transfer.count.unchanged":             "Déjà dans la bibliothèque :",
+		"transfer.count.unmatched":             "Non reconnues :",
+		"transfer.count.skipped":               "Ignorées, pas des films :",
+		"transfer.error.running":               "Un import est déjà en cours.",
+		"transfer.error.file":                  "Choisis un fichier CSV de 10 Mo maximum.",
+		"transfer.error.parse":                 "Le fichier n'a pas pu être lu :",
+		"transfer.issues":                      "Lignes non reconnues",
+		"transfer.issuesHelp":                  "Saisis l'identifiant TMDB, un lien TMDB ou l'identifiant IMDb du film, ou ignore la ligne.",
+		"transfer.line":                        "ligne",
+		"transfer.kind.watchlist":              "Watchlist",
+		"transfer.kind.viewing":                "Vu le",
+		"transfer.kind.watched":                "Vu",
+		"transfer.kind.rating":                 "Noté",
+		"transfer.reason.not-found":            "Aucun film trouvé",
+		"transfer.reason.ambiguous":            "Plusieurs films correspondent",
+		"transfer.reason.invalid":              "Date ou note invalide",
+		"transfer.movie":                       "Identifiant TMDB, lien TMDB ou identifiant IMDb",
+		"transfer.dismiss":                     "Ignorer",
+		"transfer.searchTmdb":                  "Chercher sur TMDB",
+		"transfer.error.movie":                 "Aucun film avec cet identifiant.",
+		"transfer.export":                      "Exporter",
+		"transfer.exportHelp":                  "Télécharge la bibliothèque en fichiers CSV aux formats de Letterboxd et d'IMDb.",
+		"details.links":                        "Liens :",
+		"lists.title":                          "Listes",
+		"lists.create":                         "Créer une liste",
+		"lists.edit":                           "Modifier la liste",
+		"lists.name":                           "Nom",
+		"lists.description":                    "Description",
+		"lists.save":                           "Enregistrer",
+		"lists.delete":                         "Supprimer la liste",
+		"lists.deleteConfirm":                  "Supprimer cette liste pour tout le monde ?",
+		"lists.itemCount":                      "éléments",
+		"lists.by":                             "par",
+		"lists.shared":                         "partagée par lien",
+		"lists.export":                         "Exporter :",
+		"lists.note":                           "Note",
+		"lists.saveNote":                       "Enregistrer la note",
+		"lists.moveUp":                         "Monter",
+		"lists.moveDown":                       "Descendre",
+		"lists.remove":                         "Retirer",
+		"lists.addedBy":                        "ajouté par",
+		"lists.members":                        "Collaborateurs",
+		"lists.memberName":                     "Nom du compte",
+		"lists.addMember":                      "Inviter",
+		"lists.leave":                          "Quitter la liste",
+		"lists.leaveConfirm":                   "Ne plus collaborer à cette liste ?",
+		"lists.sharing":                        "Partage",
+		"lists.share":                          "Partager en lecture seule par lien",
+		"lists.shareLink":                      "Toute personne ayant ce lien peut voir la liste :",
+		"lists.unshare":                        "Arrêter le partage",
+		"lists.addTo":                          "Ajouter à la liste",
+		"lists.addedTo":                        "Ajouté à",
+		"lists.createFirst":                    "Créez une liste pour réunir films et personnes",
+		"lists.error.invalidName":              "Les noms de liste comptent de 1 à 100 caractères.",
+		"lists.error.invalidNote":              "Les notes comptent au plus 1000 caractères.",
+		"lists.error.noUser":                   "Aucun autre compte ne porte ce nom.",
+		"follow.title":                         "Notifications",
+		"follow.follow":                        "Suivre",
+		"follow.unfollow":                      "Ne plus suivre",
+		"follow.following":                     "Abonnements",
+		"follow.noneFollowed":                  "Suivez des personnes depuis leur page pour être informé de leurs nouveaux films.",
+		"follow.noNotifications":               "Aucune notification pour l'instant.",
+		"follow.markRead":                      "Tout marquer comme lu",
+		"follow.kind.announced":                "joue dans un film qui vient d'être annoncé",
+		"follow.kind.released":                 "joue dans un film qui vient de sortir",
+		"follow.feed":                          "Flux",
+		"follow.feedLink":                      "Abonnez-vous à vos notifications dans un lecteur de flux :",
+		"follow.feedTitle":                     "Notifications de",
+		"follow.resetFeed":                     "Réinitialiser le lien du flux",
+		"follow.resetFeedConfirm":              "Le lien actuel du flux ne fonctionnera plus. Continuer ?",
+		"follow.webhooks":                      "Webhooks",
+		"follow.webhooksHelp":                  "Les notifications sont envoyées en JSON, signées avec le secret dans l'en-tête X-Blunt-Signature.",
+		"follow.webhookUrl":                    "URL du webhook",
+		"follow.addWebhook":                    "Ajouter un webhook",
+		"follow.secret":                        "Secret :",
+		"follow.test":                          "Envoyer un test",
+		"follow.testDelivered":                 "Livré.",
+		"follow.testFailed":                    "Échec de la livraison, vérifiez l'URL et qu'elle répond avec un statut 2xx.",
+		"follow.error.invalidUrl":              "Les URL de webhook commencent par http:// ou https:// et désignent un hôte public.",
+		"follow.error.tooManyWebhooks":         "Vous avez atteint le nombre maximal de webhooks.",
+		"releases.title":                       "Sorties à venir",
+		"releases.subscribe":                   "Calendrier des sorties à venir",
+		"releases.type.premiere":               "Avant-première",
+		"releases.type.theatricalLimited":      "Sortie en salles limitée",
+		"releases.type.theatrical":             "Sortie en salles",
+		"releases.type.digital":                "Sortie numérique",
+		"releases.type.physical":               "Sortie DVD/Blu-ray",
+		"releases.type.tv":                     "Première télévisée",
+		"releases.type.other":                  "Sortie",
+		"settings.title":                       "Paramètres",
+		"settings.saved":                       "Enregistré.",
+		"watch.title":                          "Où regarder",
+		"watch.services":                       "Mes services",
+		"watch.servicesHelp":                   "La recherche et les filmographies peuvent n'afficher que les films disponibles en streaming, location ou achat sur ces services.",
+		"watch.noServices":                     "Choisissez vos services pour filtrer :",
+		"watch.onlyAvailable":                  "Seulement sur mes services",
+		"watch.showAvailable":                  "N'afficher que les films sur mes services",
+		"watch.showAll":                        "Afficher tous les films",
+		"watch.flatrate":                       "Streaming",
+		"watch.rent":                           "Location",
+		"watch.buy":                            "Achat",
+		"watch.none":                           "Indisponible en streaming, location ou achat.",
+		"watch.more":                           "Toutes les offres",
+		"watch.unchecked":                      "autres films n'ont pas été vérifiés pour vos services et sont omis.",
+		"watch.attribution":                    "Données de streaming par JustWatch.",
+		"recommend.title":                      "Recommandations",
+		"recommend.noHistory":                  "Les recommandations reposent sur ce que vous avez vu et noté. Commencez votre",
+		"recommend.reason.cast":                "acteurs de films que vous avez bien notés y jouent :",
+		"recommend.reason.recommended":         "Recommandé aux fans de",
+		"recommend.reason.similar":             "Similaire à",
+	},
+	"es": {
+		"index.title":                          "Explorador de películas",
+		"index.search":                         "Buscar películas/personas...",
+		"index.language":                       "Idioma",
+		"movie.date":                           "Fecha:",
+		"movie.tagline":                        "Eslogan:",
+		"movie.runtime":                        "Duración:",
+		"movie.language":                       "Idioma:",
+		"movie.revenue":                        "Recaudación:",
+		"movie.certification":                  "Clasificación:",
+		"movie.releases":                       "Estrenos",
+		"movie.cast":                           "Reparto",
+		"graph.button":                         "Grafo",
+		"person.birthday":                      "Fecha de nacimiento:",
+		"person.deathday":                      "Fecha de fallecimiento:",
+		"person.placeOfBirth":                  "Lugar de nacimiento:",
+		"person.knownFor":                      "Conocido por:",
+		"person.homepage":                      "Sitio web:",
+		"person.credits":                       "Filmografía",
+		"family.pending":                       "títulos más se están comprobando todavía para el modo familiar, recarga la página para ver los adecuados.",
+		"rateLimit.title":                      "Demasiadas solicitudes",
+		"rateLimit.message":                    "Estás enviando solicitudes más rápido de lo que el explorador puede responder.",
+		"rateLimit.retry":                      "Inténtalo de nuevo en",
+		"account.login":                        "Iniciar sesión",
+		"account.logout":                       "Cerrar sesión",
+		"account.register":                     "Registrarse",
+		"account.name":                         "Nombre",
+		"account.password":                     "Contraseña",
+		"account.passwordRepeat":               "Repetir contraseña",
+		"account.signedInAs":                   "Sesión iniciada como",
+		"account.noAccount":                    "¿Aún no tienes cuenta?",
+		"account.haveAccount":                  "¿Ya estás registrado?",
+		"account.error.invalidName":            "Los nombres tienen de 3 a 32 letras, dígitos, puntos, guiones o guiones bajos.",
+		"account.error.weakPassword":           "Las contraseñas necesitan al menos 10 caracteres.",
+		"account.error.passwordMismatch":       "Las contraseñas no coinciden.",
+		"account.error.nameTaken":              "Este nombre ya está en uso.",
+		"account.error.invalidCredentials":     "Nombre desconocido o contraseña incorrecta.",
+		"library.watchlist":                    "Pendientes",
+		"library.history":                      "Historial",
+		"library.loginToSave":                  "Inicia sesión para llevar una lista de pendientes y un historial",
+		"library.addToWatchlist":               "Añadir a pendientes",
+		"library.removeFromWatchlist":          "Quitar de pendientes",
+		"library.markWatched":                  "Marcar como vista",
+		"library.rating":                       "Valoración",
+		"library.noRating":                     "Sin valoración",
+		"library.watchedOn":                    "Vista el",
+		"library.delete":                       "Eliminar",
+		"library.added":                        "Añadida el",
+		"library.empty":                        "Aún no hay nada.",
+		"library.stats.viewings":               "Visionados:",
+		"library.stats.movies":                 "Películas:",
+		"library.stats.runtime":                "Tiempo visto:",
+		"library.stats.unknown":                "duración desconocida:",
+		"library.stats.rating":                 "Valoración media:",
+		"library.filter.search":                "Título",
+		"library.filter.year":                  "Año",
+		"library.filter.rating":                "Valoración mínima",
+		"library.filter.anyRating":             "Cualquier valoración",
+		"library.filter.sort":                  "Ordenar por",
+		"library.filter.order":                 "Orden",
+		"library.filter.apply":                 "Aplicar",
+		"library.sort.added":                   "Fecha de adición",
+		"library.sort.title":                   "Título",
+		"library.sort.release":                 "Fecha de estreno",
+		"library.sort.runtime":                 "Duración",
+		"library.sort.watched":                 "Fecha de visionado",
+		"library.sort.rating":                  "Valoración",
+		"library.order.desc":                   "Descendente",
+		"library.order.asc":                    "Ascendente",
+		"transfer.title":                       "Importar y exportar",
+		"transfer.import":                      "Importar",
+		"transfer.importHelp":                  "Importa los archivos CSV de una exportación de Letterboxd o IMDb. Las películas se identifican por su id de IMDb, o por título y año. Importar de nuevo un archivo solo añade lo nuevo.",
+		"transfer.format":                      "Archivo",
+		"transfer.format.letterboxd-diary":     "Diario de Letterboxd",
+		"transfer.format.letterboxd-watched":   "Vistas de Letterboxd",
+		"transfer.format.letterboxd-ratings":   "Valoraciones de Letterboxd",
+		"transfer.format.letterboxd-watchlist": "Lista de seguimiento de Letterboxd",
+		"transfer.format.imdb-ratings":         "Valoraciones de IMDb",
+		"transfer.file":                        "Archivo CSV",
+		"transfer.running":                     "Importando…",
+		"transfer.finished":                    "Importación terminada",
+		"transfer.stopped":                     "La importación se detuvo antes de tiempo. Importar de nuevo el archivo continúa donde se detuvo.",
+		"transfer.count.processed":             "Filas procesadas:",
+		"transfer.count.imported":              "Importadas:",
+		"transfer.count.unchanged":             "Ya en la biblioteca:",
+		"transfer.count.unmatched":             "Sin coincidencia:",
+		"transfer.count.skipped":               "Omitidas, no son películas:",
+		"transfer.error.running":               "Ya hay una importación en curso.",
+		"transfer.error.file":                  "Elige un archivo CSV de 10 MB como máximo.",
+		"transfer.error.parse":                 "No se pudo leer el archivo:",
+		"transfer.issues":                      "Filas sin coincidencia",
+		"transfer.issuesHelp":                  "Introduce el id de TMDB, un enlace de TMDB o el id de IMDb de la película, o descarta la fila.",
+		"transfer.line":                        "línea",
+		"transfer.kind.watchlist":              "Lista de seguimiento",
+		"transfer.kind.viewing":                "Vista el",
+		"transfer.kind.watched":                "Vista",
+		"transfer.kind.rating":                 "Valorada",
+		"transfer.reason.not-found":            "No se encontró ninguna película",
+		"transfer.reason.ambiguous":            "Varias películas coinciden",
+		"transfer.reason.invalid":              "Fecha o valoración no válida",
+		"transfer.movie":                       "Id de TMDB, enlace de TMDB o id de IMDb",
+		"transfer.dismiss":                     "Descartar",
+		"transfer.searchTmdb":                  "Buscar en TMDB",
+		"transfer.error.movie":                 "No hay ninguna película con este id.",
+		"transfer.export":                      "Exportar",
+		"transfer.exportHelp":                  "Descarga la biblioteca como archivos CSV en los formatos de Letterboxd e IMDb.",
+		"details.links":                        "Enlaces:",
+		"lists.title":                          "Listas",
+		"lists.create":                         "Crear lista",
+		"lists.edit":                           "Editar lista",
+		"lists.name":                           "Nombre",
+		"lists.description":                    "Descripción",
+		"lists.save":                           "Guardar",
+		"lists.delete":                         "Eliminar lista",
+		"lists.deleteConfirm":                  "¿Eliminar esta lista para todos?",
+		"lists.itemCount":                      "elementos",
+		"lists.by":                             "de",
+		"lists.shared":                         "compartida por enlace",
+		"lists.export":                         "Exportar:",
+		"lists.note":                           "Nota",
+		"lists.saveNote":                       "Guardar nota",
+		"lists.moveUp":                         "Subir",
+		"lists.moveDown":                       "Bajar",
+		"lists.remove":                         "Quitar",
+		"lists.addedBy":                        "añadido por",
+		"lists.members":                        "Colaboradores",
+		"lists.memberName":                     "Nombre de la cuenta",
+		"lists.addMember":                      "Invitar",
+		"lists.leave":                          "Abandonar lista",
+		"lists.leaveConfirm":                   "¿Dejar de colaborar en esta lista?",
+		"lists.sharing":                        "Compartir",
+		"lists.share":                          "Compartir en solo lectura por enlace",
+		"lists.shareLink":                      "Cualquiera con este enlace puede ver la lista:",
+		"lists.unshare":                        "Dejar de compartir",
+		"lists.addTo":                          "Añadir a la lista",
+		"lists.addedTo":                        "Añadido a",
+		"lists.createFirst":                    "Crea una lista para reunir películas y personas",
+		"lists.error.invalidName":              "Los nombres de lista tienen de 1 a 100 caracteres.",
+		"lists.error.invalidNote":              "Las notas tienen como máximo 1000 caracteres.",
+		"lists.error.noUser":                   "No hay otra cuenta con este nombre.",
+		"follow.title":                         "Notificaciones",
+		"follow.follow":                        "Seguir",
+		"follow.unfollow":                      "Dejar de seguir",
+		"follow.following":                     "Siguiendo",
+		"follow.noneFollowed":                  "Sigue a personas desde su página para enterarte de sus nuevas películas.",
+		"follow.noNotifications":               "Todavía no hay notificaciones.",
+		"follow.markRead":                      "Marcar todo como leído",
+		"follow.kind.announced":                "participa en una película recién anunciada",
+		"follow.kind.released":                 "participa en una película que acaba de estrenarse",
+		"follow.feed":                          "Feed",
+		"follow.feedLink":                      "Suscríbete a tus notificaciones en un lector de feeds:",
+		"follow.feedTitle":                     "Notificaciones de",
+		"follow.resetFeed":                     "Restablecer el enlace del feed",
+		"follow.resetFeedConfirm":              "El enlace actual del feed dejará de funcionar. ¿Continuar?",
+		"follow.webhooks":                      "Webhooks",
+		"follow.webhooksHelp":                  "Las notificaciones se envían como JSON, firmadas con el secreto en la cabecera X-Blunt-Signature.",
+		"follow.webhookUrl":                    "URL del webhook",
+		"follow.addWebhook":                    "Añadir webhook",
+		"follow.secret":                        "Secreto:",
+		"follow.test":                          "Enviar prueba",
+		"follow.testDelivered":                 "Entregado.",
+		"follow.testFailed":                    "La entrega falló, comprueba la URL y que responda con un estado 2xx.",
+		"follow.error.invalidUrl":              "Las URL de webhook empiezan por http:// o https:// y apuntan a un host público.",
+		"follow.error.tooManyWebhooks":         "Has alcanzado el límite de webhooks.",
+		"releases.title":                       "Próximos estrenos",
+		"releases.subscribe":                   "Calendario de próximos estrenos",
+		"releases.type.premiere":               "Premiere",
+		"releases.type.theatricalLimited":      "Estreno limitado en cines",
+		"releases.type.theatrical":             "Estreno en cines",
+		"releases.type.digital":                "Estreno digital",
+		"releases.type.physical":               "Lanzamiento en DVD/Blu-ray",
+		"releases.type.tv":                     "Estreno en televisión",
+		"releases.type.other":                  "Estreno",
+		"settings.title":                       "Ajustes",
+		"settings.saved":                       "Guardado.",
+		"watch.title":                          "Dónde ver",
+		"watch.services":                       "Mis servicios",
+		"watch.servicesHelp":                   "La búsqueda y las filmografías pueden mostrar solo películas que puedes ver, alquilar o comprar en estos servicios.",
+		"watch.noServices":                     "Elige tus servicios para filtrar por ellos:",
+		"watch.onlyAvailable":                  "Solo en mis servicios",
+		"watch.showAvailable":                  "Mostrar solo películas en mis servicios",
+		"watch.showAll":                        "Mostrar todas las películas",
+		"watch.flatrate":                       "Streaming",
+		"watch.rent":                           "Alquilar",
+		"watch.buy":                            "Comprar",
+		"watch.none":                           "No disponible en streaming, alquiler ni compra.",
+		"watch.more":                           "Todas las ofertas",
+		"watch.unchecked":                      "películas más no se comprobaron en tus servicios y se omiten.",
+		"watch.attribution":                    "Datos de streaming de JustWatch.",
+		"recommend.title":                      "Recomendaciones",
+		"recommend.noHistory":                  "Las recomendaciones se basan en lo que has visto y valorado. Empieza tu",
+		"recommend.reason.cast":                "actores de películas que valoraste bien aparecen en esta:",
+		"recommend.reason.recommended":         "Recomendada para fans de",
+		"recommend.reason.similar":             "Similar a",
+	},
+}
@@ -0,0 +1,180 @@
+package imageproxy
+
+import (
+	"cmp"
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sync"
+	"time"
+)
+
+// cachePattern matches the names fileName gives to cached files. Nothing
+// else in the directory is indexed or evicted, in case it's shared.
+var cachePattern = regexp.MustCompile(`^[0-9a-f]{64}\.(jpg|jpeg|png|svg|webp)$`)
+
+// diskCache stores image files in a directory and evicts the least recently
+// used ones once their total size exceeds maxBytes.
+type diskCache struct {
+	mu       sync.Mutex
+	dir      string
+	maxBytes int64
+	size     int64
+	entries  map[string]*list.Element
+	order    *list.List
+}
+
+type diskEntry struct {
+	name string
+	size int64
+}
+
+func newDiskCache(dir string, maxBytes int64) (*diskCache, error) {
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return nil, err
+	}
+
+	c := diskCache{
+		dir:      dir,
+		maxBytes: maxBytes,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	type existing struct {
+		entry    diskEntry
+		accessed time.Time
+	}
+
+	found := make([]existing, 0)
+
+	for _, file := range files {
+		if file.IsDir() || !cachePattern.MatchString(file.Name()) {
+			continue
+		}
+
+		info, err := file.Info()
+		if err != nil {
+			continue
+		}
+
+		found = append(found, existing{entry: diskEntry{name: file.Name(), size: info.Size()}, accessed: info.ModTime()})
+	}
+
+	// oldest first, so the most recently used file ends up in front
+	slices.SortFunc(found, func(a, b existing) int {
+		return a.accessed.Compare(b.accessed)
+	})
+
+	for _, f := range found {
+		entry := f.entry
+		c.entries[entry.name] = c.order.PushFront(&entry)
+		c.size += entry.size
+	}
+
+	c.mu.Lock()
+	c.evict()
+	c.mu.Unlock()
+
+	return &c, nil
+}
+
+// fileName derives the on-disk name of a cache key, keeping the extension so
+// the content type can be recovered when serving.
+func fileName(key string, ext string) string {
+	sum := sha256.Sum256([]byte(key))
+
+	return hex.EncodeToString(sum[:]) + ext
+}
+
+// get reads a cached file and marks it as recently used. The modification
+// time doubles as last access time, so the LRU order survives restarts.
+func (c *diskCache) get(name string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[name]
+	if !ok {
+		return nil, false
+	}
+
+	path := filepath.Join(c.dir, name)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		c.size -= element.Value.(*diskEntry).size
+		c.order.Remove(element)
+		delete(c.entries, name)
+		return nil, false
+	}
+
+	c.order.MoveToFront(element)
+
+	now := time.Now()
+	os.Chtimes(path, now, now)
+
+	return data, true
+}
+
+// put writes data atomically and evicts old files if needed.
+func (c *diskCache) put(name string, data []byte) error {
+	path := filepath.Join(c.dir, name)
+
+	tmp, err := os.CreateTemp(c.dir, "*.tmp")
+	if err != nil {
+		return err
+	}
+
+	_, err = tmp.Write(data)
+	closeErr := tmp.Close()
+	if err = cmp.Or(err, closeErr); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	err = os.Rename(tmp.Name(), path)
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[name]
+	if ok {
+		c.size -= element.Value.(*diskEntry).size
+		c.order.Remove(element)
+	}
+
+	c.entries[name] = c.order.PushFront(&diskEntry{name: name, size: int64(len(data))})
+	c.size += int64(len(data))
+
+	c.evict()
+
+	return nil
+}
+
+func (c *diskCache) evict() {
+	// always keep the newest entry, even if it alone exceeds the limit
+	for c.size > c.maxBytes && c.order.Len() > 1 {
+		oldest := c.order.Back()
+		entry := oldest.Value.(*diskEntry)
+
+		c.order.Remove(oldest)
+		delete(c.entries, entry.name)
+		c.size -= entry.size
+
+		os.Remove(filepath.Join(c.dir, entry.name))
+	}
+}
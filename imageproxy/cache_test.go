@@ -0,0 +1,153 @@
+package imageproxy
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// names lists the cached files, most recently used first.
+func names(c *diskCache) []string {
+	result := make([]string, 0)
+	for element := c.order.Front(); element != nil; element = element.Next() {
+		result = append(result, element.Value.(*diskEntry).name)
+	}
+
+	return result
+}
+
+func TestDiskCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	dir := t.TempDir()
+
+	c, err := newDiskCache(dir, 30)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a, b, d := fileName("a", ".jpg"), fileName("b", ".jpg"), fileName("d", ".jpg")
+
+	for _, name := range []string{a, b} {
+		err = c.put(name, make([]byte, 10))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// reading a makes b the least recently used
+	_, ok := c.get(a)
+	if !ok {
+		t.Fatal("a is not cached")
+	}
+
+	err = c.put(d, make([]byte, 15))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := names(c); !slices.Equal(got, []string{d, a}) {
+		t.Errorf("cached %v, want d and a", got)
+	}
+	if c.size != 25 {
+		t.Errorf("size %d, want 25", c.size)
+	}
+
+	_, err = os.Stat(filepath.Join(dir, b))
+	if !os.IsNotExist(err) {
+		t.Errorf("b is still on disk: %v", err)
+	}
+
+	// an entry alone over the limit stays until the next one arrives
+	large := fileName("large", ".png")
+
+	err = c.put(large, make([]byte, 100))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := names(c); !slices.Equal(got, []string{large}) {
+		t.Errorf("cached %v, want only the large file", got)
+	}
+}
+
+func TestDiskCacheIndexesExistingFiles(t *testing.T) {
+	dir := t.TempDir()
+
+	write := func(name string, size int, age time.Duration) {
+		t.Helper()
+
+		path := filepath.Join(dir, name)
+
+		err := os.WriteFile(path, make([]byte, size), 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		modified := time.Now().Add(-age)
+
+		err = os.Chtimes(path, modified, modified)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	old, recent, newest := fileName("old", ".jpg"), fileName("recent", ".webp"), fileName("newest", ".svg")
+
+	write(old, 10, 3*time.Hour)
+	write(recent, 10, 2*time.Hour)
+	write(newest, 10, time.Hour)
+
+	// files the cache didn't write, in case the directory is shared
+	unrelated := []string{"notes.txt", "photo.jpg", fileName("x", ".jpg") + ".tmp", "ABCDEF.jpg"}
+	for _, name := range unrelated {
+		write(name, 1000, 4*time.Hour)
+	}
+
+	c, err := newDiskCache(dir, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := names(c); !slices.Equal(got, []string{newest, recent}) {
+		t.Errorf("cached %v, want newest and recent", got)
+	}
+
+	_, err = os.Stat(filepath.Join(dir, old))
+	if !os.IsNotExist(err) {
+		t.Errorf("the oldest file wasn't evicted: %v", err)
+	}
+
+	for _, name := range unrelated {
+		_, err := os.Stat(filepath.Join(dir, name))
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+}
+
+func TestDiskCacheForgetsDeletedFiles(t *testing.T) {
+	dir := t.TempDir()
+
+	c, err := newDiskCache(dir, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	name := fileName("a", ".jpg")
+
+	err = c.put(name, make([]byte, 10))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.Remove(filepath.Join(dir, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, ok := c.get(name)
+	if ok || c.size != 0 || c.order.Len() != 0 {
+		t.Errorf("found %v, size %d, %d entries", ok, c.size, c.order.Len())
+	}
+}
@@ -0,0 +1,263 @@
+package imageproxy
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"log/slog"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/HugoSmits86/nativewebp"
+	"github.com/m4tthewde/blunt/tmdb"
+	"golang.org/x/image/draw"
+	"golang.org/x/sync/singleflight"
+)
+
+//go:embed placeholder.svg
+var placeholder []byte
+
+const maxUpstreamBytes = 20 << 20
+
+var (
+	filePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+\.(jpg|jpeg|png|svg)$`)
+	sizePattern = regexp.MustCompile(`^(poster|profile|backdrop|logo)-w([0-9]+)$`)
+
+	errNotFound = errors.New("image not found")
+	errTooLarge = fmt.Errorf("imageproxy: image larger than %d bytes", maxUpstreamBytes)
+)
+
+type Options struct {
+	CacheDir      string
+	MaxCacheBytes int64
+	// Resize allows the sizes named by tmdb.ResizedSize for the widths of
+	// tmdb.ResizeWidths, which are produced locally from the next larger
+	// TMDB size of the kind of image.
+	Resize bool
+	// WebP converts images to WebP for browsers that accept it.
+	WebP bool
+}
+
+// Proxy serves TMDB images from a local disk cache, so browsers never talk to
+// image.tmdb.org directly.
+type Proxy struct {
+	options    Options
+	cache      *diskCache
+	httpClient *http.Client
+	group      singleflight.Group
+	started    time.Time
+}
+
+func New(options Options) (*Proxy, error) {
+	cache, err := newDiskCache(options.CacheDir, options.MaxCacheBytes)
+	if err != nil {
+		return nil, fmt.Errorf("imageproxy: %w", err)
+	}
+
+	return &Proxy{
+		options:    options,
+		cache:      cache,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		started:    time.Now(),
+	}, nil
+}
+
+// Placeholder serves the image shown for movies and people without one.
+func Placeholder(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=86400")
+	http.ServeContent(w, r, "placeholder.svg", time.Time{}, bytes.NewReader(placeholder))
+}
+
+// ServeHTTP handles /img/{size}/{path}.
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	size := r.PathValue("size")
+	file := r.PathValue("path")
+
+	if !filePattern.MatchString(file) {
+		http.NotFound(w, r)
+		return
+	}
+
+	kind, width, ok := p.width(size)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	ext := strings.ToLower(filepath.Ext(file))
+	webp := p.options.WebP && ext != ".svg" && strings.Contains(r.Header.Get("Accept"), "image/webp")
+
+	outExt := ext
+	if webp {
+		outExt = ".webp"
+	}
+
+	name := fileName(size+"/"+file, outExt)
+
+	data, ok := p.cache.get(name)
+	if !ok {
+		// one upstream request per image, even if many browsers ask at once
+		result, err, _ := p.group.Do(name, func() (any, error) {
+			ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), time.Minute)
+			defer cancel()
+
+			data, err := p.fetch(ctx, size, file, kind, width, webp)
+			if err != nil {
+				return nil, err
+			}
+
+			return data, p.cache.put(name, data)
+		})
+
+		if errors.Is(err, errNotFound) {
+			http.NotFound(w, r)
+			return
+		}
+		if err != nil {
+			slog.WarnContext(r.Context(), "could not proxy image", "size", size, "path", file, "error", err)
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+
+		data = result.([]byte)
+	}
+
+	// TMDB never changes the image behind a path, so the cache key is a
+	// strong validator for the content.
+	w.Header().Set("ETag", `"`+name[:32]+`"`)
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	if p.options.WebP {
+		w.Header().Set("Vary", "Accept")
+	}
+
+	http.ServeContent(w, r, name, p.started, bytes.NewReader(data))
+}
+
+// width returns the kind of image and the width to resize to, zero when
+// size is served by TMDB as is.
+func (p *Proxy) width(size string) (string, int, bool) {
+	if tmdb.Images().IsImageSize(size) {
+		return "", 0, true
+	}
+
+	if !p.options.Resize {
+		return "", 0, false
+	}
+
+	match := sizePattern.FindStringSubmatch(size)
+	if match == nil {
+		return "", 0, false
+	}
+
+	// every other width would be another resize and another cached file
+	width, err := strconv.Atoi(match[2])
+	if err != nil || !slices.Contains(tmdb.ResizeWidths, width) {
+		return "", 0, false
+	}
+
+	return match[1], width, true
+}
+
+func (p *Proxy) fetch(ctx context.Context, size string, file string, kind string, width int, webp bool) ([]byte, error) {
+	upstreamSize := size
+	if width != 0 {
+		upstreamSize = tmdb.ImageSize(kind, width)
+	}
+
+	data, err := p.download(ctx, upstreamSize, file)
+	if errors.Is(err, errNotFound) && upstreamSize != size {
+		data, err = p.download(ctx, "original", file)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if (width == 0 && !webp) || filepath.Ext(file) == ".svg" {
+		return data, nil
+	}
+
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	if width != 0 && img.Bounds().Dx() > width {
+		img = resize(img, width)
+	}
+
+	var out bytes.Buffer
+
+	switch {
+	case webp:
+		err = nativewebp.Encode(&out, img, nil)
+	case format == "png":
+		err = png.Encode(&out, img)
+	default:
+		err = jpeg.Encode(&out, img, &jpeg.Options{Quality: 85})
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return out.Bytes(), nil
+}
+
+func (p *Proxy) download(ctx context.Context, size string, file string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", tmdb.Images().SecureBaseUrl+size+"/"+file, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, errNotFound
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("imageproxy: GET %s/%s: %s", size, file, resp.Status)
+	}
+
+	if resp.ContentLength > maxUpstreamBytes {
+		return nil, errTooLarge
+	}
+
+	// one byte more tells a body of exactly the limit from a longer one
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxUpstreamBytes+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) > maxUpstreamBytes {
+		return nil, errTooLarge
+	}
+
+	return data, nil
+}
+
+func resize(img image.Image, width int) image.Image {
+	bounds := img.Bounds()
+	height := bounds.Dy() * width / bounds.Dx()
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, max(height, 1)))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Over, nil)
+
+	return dst
+}
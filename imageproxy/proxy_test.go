@@ -0,0 +1,187 @@
+package imageproxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/m4tthewde/blunt/tmdb"
+)
+
+// fakeTmdb serves the image configuration and a 600 pixel wide PNG for every
+// image, and records the sizes asked for. The proxy downloads from the
+// configured image base URL, so the configuration is loaded from it.
+func fakeTmdb(t *testing.T, body func(w http.ResponseWriter)) *[]string {
+	t.Helper()
+
+	var mu sync.Mutex
+	requested := make([]string, 0)
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/configuration" {
+			json.NewEncoder(w).Encode(map[string]any{"images": map[string]any{
+				"secure_base_url": server.URL + "/t/p/",
+				"poster_sizes":    []string{"w92", "w154", "w185", "w342", "w500", "w780", "original"},
+				"profile_sizes":   []string{"w45", "w185", "h632", "original"},
+				"backdrop_sizes":  []string{"w300", "w780", "w1280", "original"},
+				"logo_sizes":      []string{"w45", "w92", "w154", "w185", "w300", "w500", "original"},
+			}})
+			return
+		}
+
+		size, _, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/t/p/"), "/")
+
+		mu.Lock()
+		requested = append(requested, size)
+		mu.Unlock()
+
+		body(w)
+	}))
+	t.Cleanup(server.Close)
+
+	err := tmdb.NewClient(tmdb.Options{BaseUrl: server.URL}).LoadImageConfiguration(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &requested
+}
+
+func pngImage(w http.ResponseWriter) {
+	png.Encode(w, image.NewRGBA(image.Rect(0, 0, 600, 900)))
+}
+
+func serve(t *testing.T, proxy *Proxy, path string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /img/{size}/{path}", proxy.ServeHTTP)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+
+	return rec
+}
+
+func TestResize(t *testing.T) {
+	requested := fakeTmdb(t, pngImage)
+
+	proxy, err := New(Options{CacheDir: t.TempDir(), MaxCacheBytes: 1 << 20, Resize: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path     string
+		upstream string
+		width    int
+	}{
+		{"/img/poster-w90/a.png", "w92", 90},
+		// profiles have no w92, the next larger size is w185
+		{"/img/profile-w90/b.png", "w185", 90},
+		{"/img/backdrop-w270/c.png", "w300", 270},
+		{"/img/logo-w120/d.png", "w154", 120},
+		// sizes of TMDB are served as they are
+		{"/img/w500/e.png", "w500", 600},
+	}
+
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			*requested = (*requested)[:0]
+
+			rec := serve(t, proxy, test.path)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status %d", rec.Code)
+			}
+
+			if len(*requested) != 1 || (*requested)[0] != test.upstream {
+				t.Errorf("downloaded %v, want %s", *requested, test.upstream)
+			}
+
+			config, _, err := image.DecodeConfig(bytes.NewReader(rec.Body.Bytes()))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if config.Width != test.width {
+				t.Errorf("width %d, want %d", config.Width, test.width)
+			}
+
+			// the second request is served from the cache
+			serve(t, proxy, test.path)
+
+			if len(*requested) != 1 {
+				t.Errorf("downloaded %d times", len(*requested))
+			}
+		})
+	}
+}
+
+func TestUnknownSizes(t *testing.T) {
+	fakeTmdb(t, pngImage)
+
+	resizing, err := New(Options{CacheDir: t.TempDir(), MaxCacheBytes: 1 << 20, Resize: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	notResizing, err := New(Options{CacheDir: t.TempDir(), MaxCacheBytes: 1 << 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		proxy *Proxy
+		path  string
+	}{
+		{"width between the fixed ones", resizing, "/img/poster-w91/a.png"},
+		{"unknown kind", resizing, "/img/still-w90/a.png"},
+		{"width without kind", resizing, "/img/w90/a.png"},
+		{"resizing disabled", notResizing, "/img/poster-w90/a.png"},
+		{"not an image", resizing, "/img/w500/a.html"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rec := serve(t, test.proxy, test.path)
+			if rec.Code != http.StatusNotFound {
+				t.Errorf("status %d, want 404", rec.Code)
+			}
+		})
+	}
+}
+
+func TestTooLarge(t *testing.T) {
+	fakeTmdb(t, func(w http.ResponseWriter) {
+		// written in parts, so there's no Content-Length to go by
+		chunk := make([]byte, 1<<20)
+		for range maxUpstreamBytes/len(chunk) + 1 {
+			w.Write(chunk)
+		}
+	})
+
+	dir := t.TempDir()
+
+	proxy, err := New(Options{CacheDir: dir, MaxCacheBytes: 1 << 30})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := serve(t, proxy, "/img/original/large.png")
+	if rec.Code != http.StatusBadGateway {
+		t.Errorf("status %d, want 502", rec.Code)
+	}
+
+	if proxy.cache.order.Len() != 0 {
+		t.Error("the cut off image was cached")
+	}
+}
@@ -0,0 +1,292 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/m4tthewde/blunt/catalog"
+	"github.com/m4tthewde/blunt/components"
+	"github.com/m4tthewde/blunt/library"
+	"github.com/m4tthewde/blunt/ratelimit"
+	"github.com/m4tthewde/blunt/tmdb"
+	"github.com/m4tthewde/blunt/transfer"
+	"github.com/m4tthewde/blunt/users"
+)
+
+var (
+	importer     *transfer.Importer
+	importIssues *transfer.Store
+)
+
+// maxImportBytes is far more than years of diary entries take.
+const maxImportBytes = 10 << 20
+
+func importPage(w http.ResponseWriter, r *http.Request, user *users.User) {
+	issues, err := importIssues.Issues(r.Context(), user.Id)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	state := components.ImportState{}
+
+	job, ok := importer.Job(user.Id)
+	if ok {
+		state.Job = &job
+	}
+
+	render(w, r, components.Transfer(state, issues))
+}
+
+func importStatus(w http.ResponseWriter, r *http.Request, user *users.User) {
+	state := components.ImportState{}
+
+	job, ok := importer.Job(user.Id)
+	if ok {
+		state.Job = &job
+
+		if !job.Running() {
+			w.Header().Set("HX-Trigger", "import-finished")
+		}
+	}
+
+	render(w, r, components.ImportStatus(state))
+}
+
+// startImport reads the uploaded file and starts importing it. htmx gets
+// the progress, browsers without it the whole page.
+func startImport(w http.ResponseWriter, r *http.Request, user *users.User) {
+	state := components.ImportState{}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportBytes)
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		state.Error = "transfer.error.file"
+		importResponse(w, r, user, state)
+		return
+	}
+	defer file.Close()
+
+	format := r.FormValue("format")
+
+	rows, skipped, err := transfer.Parse(format, file)
+	if err != nil {
+		state.Error = "transfer.error.parse"
+		state.Detail = strings.TrimPrefix(err.Error(), "transfer: ")
+		importResponse(w, r, user, state)
+		return
+	}
+
+	// every row costs as much as opening a movie page, the request itself
+	// was charged for the upload. The clone outlives the request.
+	origin := r.Clone(context.WithoutCancel(r.Context()))
+	wait := func(ctx context.Context) error {
+		return limiter.Wait(ctx, origin, ratelimit.Detail, 1)
+	}
+
+	err = importer.Start(r.Context(), user.Id, format, rows, skipped, wait)
+	if errors.Is(err, transfer.ErrRunning) {
+		state.Error = "transfer.error.running"
+	} else if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	job, ok := importer.Job(user.Id)
+	if ok {
+		state.Job = &job
+	}
+
+	importResponse(w, r, user, state)
+}
+
+func importResponse(w http.ResponseWriter, r *http.Request, user *users.User, state components.ImportState) {
+	if r.Header.Get("HX-Request") == "true" {
+		render(w, r, components.ImportStatus(state))
+		return
+	}
+
+	issues, err := importIssues.Issues(r.Context(), user.Id)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	render(w, r, components.Transfer(state, issues))
+}
+
+func importIssueList(w http.ResponseWriter, r *http.Request, user *users.User) {
+	issues, err := importIssues.Issues(r.Context(), user.Id)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	render(w, r, components.ImportIssues(issues))
+}
+
+// resolveIssue imports the row of an issue as the movie the user entered.
+// The issue is removed from the page, or shown again with what went wrong.
+func resolveIssue(w http.ResponseWriter, r *http.Request, user *users.User) {
+	id, ok := pathMovieId(w, r)
+	if !ok {
+		return
+	}
+
+	issue, err := importIssues.Issue(r.Context(), user.Id, id)
+	if errors.Is(err, transfer.ErrNoIssue) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	movieId, err := movieReference(r, r.PostFormValue("movie"))
+	if err == nil {
+		err = importer.Resolve(r.Context(), user.Id, issue.Id, movieId)
+	}
+
+	switch {
+	case err == nil:
+		return
+	case errors.Is(err, tmdb.ErrNotFound):
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		render(w, r, components.ImportIssue(issue, "transfer.error.movie"))
+	case errors.Is(err, library.ErrInvalidDate) || errors.Is(err, library.ErrInvalidRating):
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		render(w, r, components.ImportIssue(issue, "transfer.reason.invalid"))
+	default:
+		serverError(w, r, err)
+	}
+}
+
+// movieReference reads a TMDB id, a link to a movie on TMDB or an IMDb or
+// Wikidata id or link.
+func movieReference(r *http.Request, reference string) (int64, error) {
+	reference = strings.TrimSpace(reference)
+
+	externalId, ok := catalog.ParseExternalId(reference)
+	if ok {
+		result, found, err := catalog.FindExternal(r.Context(), client, externalId)
+		if err != nil {
+			return 0, err
+		}
+
+		if !found || result.Type != catalog.Movie {
+			return 0, tmdb.ErrNotFound
+		}
+
+		return result.Id, nil
+	}
+
+	// https://www.themoviedb.org/movie/603-the-matrix
+	u, err := url.Parse(reference)
+	if err == nil && u.Host != "" {
+		segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+
+		index := slices.Index(segments, "movie")
+		if index == -1 || index+1 == len(segments) {
+			return 0, tmdb.ErrNotFound
+		}
+
+		reference, _, _ = strings.Cut(segments[index+1], "-")
+	}
+
+	id, err := strconv.ParseInt(reference, 10, 64)
+	if err != nil || id < 1 {
+		return 0, tmdb.ErrNotFound
+	}
+
+	return id, nil
+}
+
+func dismissIssue(w http.ResponseWriter, r *http.Request, user *users.User) {
+	id, ok := pathMovieId(w, r)
+	if !ok {
+		return
+	}
+
+	err := importIssues.DeleteIssue(r.Context(), user.Id, id)
+	if errors.Is(err, transfer.ErrNoIssue) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		serverError(w, r, err)
+	}
+}
+
+// export downloads the library as one of the import formats.
+func export(w http.ResponseWriter, r *http.Request, user *users.User) {
+	format, ok := strings.CutSuffix(r.PathValue("file"), ".csv")
+	if !ok || !slices.ContainsFunc(transfer.Formats, func(f transfer.Format) bool { return f.Name == format }) {
+		http.NotFound(w, r)
+		return
+	}
+
+	var lib transfer.Library
+	var err error
+
+	lib.Watchlist, err = movieLibrary.Watchlist(r.Context(), user.Id, library.Query{Sort: "added"})
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	lib.History, err = movieLibrary.History(r.Context(), user.Id, library.Query{Sort: "watched"})
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	// IMDb only knows its own ids, viewings saved before the library kept
+	// them are looked up
+	if format == transfer.ImdbRatings {
+		imdbIds := make(map[int64]string)
+
+		for i, viewing := range lib.History {
+			if viewing.Rating == 0 || viewing.ImdbId != "" {
+				continue
+			}
+
+			imdbId, ok := imdbIds[viewing.Movie.Id]
+			if !ok {
+				details, err := client.MovieDetails(r.Context(), strconv.FormatInt(viewing.Movie.Id, 10))
+				if err != nil && !errors.Is(err, tmdb.ErrNotFound) {
+					serverError(w, r, err)
+					return
+				}
+
+				if err == nil {
+					imdbId = details.ImdbId
+				}
+
+				imdbIds[viewing.Movie.Id] = imdbId
+			}
+
+			lib.History[i].ImdbId = imdbId
+		}
+	}
+
+	var b bytes.Buffer
+
+	err = transfer.Export(&b, format, lib)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+transfer.FileName(format, time.Now())+`"`)
+	w.Write(b.Bytes())
+}
@@ -0,0 +1,364 @@
+// Package library keeps the watchlist and the watched history of every user.
+package library
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/m4tthewde/blunt/tmdb"
+)
+
+const DateLayout = time.DateOnly
+
+var (
+	ErrInvalidRating = errors.New("ratings are between 1 and 10")
+	ErrInvalidDate   = errors.New("viewings can't be in the future")
+	ErrNotFound      = errors.New("library: no such viewing")
+)
+
+// Movie is what the library remembers of a movie.
+type Movie struct {
+	Id          int64
+	Title       string
+	PosterPath  string
+	ReleaseDate string
+	// Runtime in minutes, zero when TMDB doesn't know it.
+	Runtime int64
+	ImdbId  string
+}
+
+func NewMovie(details *tmdb.MovieDetailsResponse) Movie {
+	return Movie{
+		Id:          details.Id,
+		Title:       details.Title,
+		PosterPath:  details.PosterPath,
+		ReleaseDate: details.ReleaseDate,
+		Runtime:     details.Runtime,
+		ImdbId:      details.ImdbId,
+	}
+}
+
+type WatchlistEntry struct {
+	Movie
+	Added time.Time
+}
+
+type Viewing struct {
+	Id int64
+	Movie
+	Date time.Time
+	// Rating from 1 to 10, zero when not rated.
+	Rating int
+}
+
+// Status is what a movie page shows about the movie's place in the library.
+type Status struct {
+	OnWatchlist bool
+	Viewings    []Viewing
+}
+
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+func (s *Store) AddToWatchlist(ctx context.Context, userId int64, movie Movie) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO watchlist (user_id, movie_id, title, poster_path, release_date, runtime, imdb_id, added_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (user_id, movie_id) DO NOTHING`,
+		userId, movie.Id, movie.Title, movie.PosterPath, movie.ReleaseDate, movie.Runtime, movie.ImdbId, time.Now().Unix(),
+	)
+	if err != nil {
+		return fmt.Errorf("library: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Store) RemoveFromWatchlist(ctx context.Context, userId int64, movieId int64) error {
+	_, err := s.db.ExecContext(ctx, "DELETE FROM watchlist WHERE user_id = ? AND movie_id = ?", userId, movieId)
+	if err != nil {
+		return fmt.Errorf("library: %w", err)
+	}
+
+	return nil
+}
+
+// AddViewing records that the user watched movie on date, which also takes
+// the movie off the watchlist.
+func (s *Store) AddViewing(ctx context.Context, userId int64, movie Movie, date time.Time, rating int) error {
+	if rating < 0 || rating > 10 {
+		return ErrInvalidRating
+	}
+
+	// a day ahead, the user may live in a later time zone
+	if date.After(time.Now().Add(24 * time.Hour)) {
+		return ErrInvalidDate
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("library: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO viewings (user_id, movie_id, title, poster_path, release_date, runtime, imdb_id, watched_on, rating, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		userId, movie.Id, movie.Title, movie.PosterPath, movie.ReleaseDate, movie.Runtime, movie.ImdbId,
+		date.Format(DateLayout), sql.NullInt64{Int64: int64(rating), Valid: rating != 0}, time.Now().Unix(),
+	)
+	if err != nil {
+		return fmt.Errorf("library: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM watchlist WHERE user_id = ? AND movie_id = ?", userId, movie.Id)
+	if err != nil {
+		return fmt.Errorf("library: %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("library: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteViewing removes a viewing and returns the movie it was of.
+func (s *Store) DeleteViewing(ctx context.Context, userId int64, id int64) (int64, error) {
+	var movieId int64
+
+	err := s.db.QueryRowContext(ctx,
+		"DELETE FROM viewings WHERE user_id = ? AND id = ? RETURNING movie_id", userId, id,
+	).Scan(&movieId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrNotFound
+	}
+	if err != nil {
+		return 0, fmt.Errorf("library: %w", err)
+	}
+
+	return movieId, nil
+}
+
+// HasViewing reports whether the user watched the movie on date, or at all
+// when date is zero.
+func (s *Store) HasViewing(ctx context.Context, userId int64, movieId int64, date time.Time) (bool, error) {
+	query := "SELECT EXISTS (SELECT 1 FROM viewings WHERE user_id = ? AND movie_id = ?"
+	args := []any{userId, movieId}
+
+	if !date.IsZero() {
+		query += " AND watched_on = ?"
+		args = append(args, date.Format(DateLayout))
+	}
+
+	var exists bool
+
+	err := s.db.QueryRowContext(ctx, query+")", args...).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("library: %w", err)
+	}
+
+	return exists, nil
+}
+
+// RateLatestViewing sets the rating of the user's most recent viewing of the
+// movie. It reports false when the user hasn't watched the movie.
+func (s *Store) RateLatestViewing(ctx context.Context, userId int64, movieId int64, rating int) (bool, error) {
+	if rating < 1 || rating > 10 {
+		return false, ErrInvalidRating
+	}
+
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE viewings SET rating = ?
+		WHERE id = (
+			SELECT id FROM viewings WHERE user_id = ? AND movie_id = ?
+			ORDER BY watched_on DESC, id DESC LIMIT 1
+		)`,
+		rating, userId, movieId,
+	)
+	if err != nil {
+		return false, fmt.Errorf("library: %w", err)
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("library: %w", err)
+	}
+
+	return updated != 0, nil
+}
+
+func (s *Store) Status(ctx context.Context, userId int64, movieId int64) (Status, error) {
+	var status Status
+
+	err := s.db.QueryRowContext(ctx,
+		"SELECT EXISTS (SELECT 1 FROM watchlist WHERE user_id = ? AND movie_id = ?)", userId, movieId,
+	).Scan(&status.OnWatchlist)
+	if err != nil {
+		return status, fmt.Errorf("library: %w", err)
+	}
+
+	status.Viewings, err = s.History(ctx, userId, Query{Sort: "watched", MovieId: movieId})
+
+	return status, err
+}
+
+// Query selects and orders the entries of a list.
+type Query struct {
+	// Sort is one of the keys of the list's sort orders, unknown values
+	// fall back to the first one.
+	Sort       string
+	Descending bool
+	// Search matches titles containing it.
+	Search string
+	// Year of release, or of the viewing for the history, zero for all.
+	Year    int
+	MovieId int64
+	// MinRating only applies to the history.
+	MinRating int
+}
+
+// WatchlistSorts and HistorySorts map sort keys to columns. The first entry
+// is the default.
+var (
+	WatchlistSorts = []SortOrder{
+		{"added", "added_at"},
+		{"title", "title COLLATE NOCASE"},
+		{"release", "release_date"},
+		{"runtime", "runtime"},
+	}
+	HistorySorts = []SortOrder{
+		{"watched", "watched_on"},
+		{"title", "title COLLATE NOCASE"},
+		{"rating", "rating"},
+		{"release", "release_date"},
+		{"runtime", "runtime"},
+	}
+)
+
+type SortOrder struct {
+	Key    string
+	column string
+}
+
+func orderBy(sorts []SortOrder, query Query) string {
+	column := sorts[0].column
+	for _, sort := range sorts {
+		if sort.Key == query.Sort {
+			column = sort.column
+		}
+	}
+
+	direction := "ASC"
+	if query.Descending {
+		direction = "DESC"
+	}
+
+	// ties, like viewings on the same day, keep the order they were added in
+	return " ORDER BY " + column + " " + direction + ", rowid " + direction
+}
+
+// filter appends the conditions of query shared by both lists.
+func filter(where []string, args []any, query Query, yearColumn string) ([]string, []any) {
+	if query.Search != "" {
+		escaped := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(query.Search)
+		where = append(where, `title LIKE ? ESCAPE '\'`)
+		args = append(args, "%"+escaped+"%")
+	}
+
+	if query.Year != 0 {
+		where = append(where, "substr("+yearColumn+", 1, 4) = ?")
+		args = append(args, fmt.Sprintf("%04d", query.Year))
+	}
+
+	if query.MovieId != 0 {
+		where = append(where, "movie_id = ?")
+		args = append(args, query.MovieId)
+	}
+
+	return where, args
+}
+
+func (s *Store) Watchlist(ctx context.Context, userId int64, query Query) ([]WatchlistEntry, error) {
+	where, args := filter([]string{"user_id = ?"}, []any{userId}, query, "release_date")
+
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT movie_id, title, poster_path, release_date, runtime, imdb_id, added_at FROM watchlist WHERE "+
+			strings.Join(where, " AND ")+orderBy(WatchlistSorts, query),
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("library: %w", err)
+	}
+	defer rows.Close()
+
+	entries := make([]WatchlistEntry, 0)
+
+	for rows.Next() {
+		var entry WatchlistEntry
+		var added int64
+
+		err := rows.Scan(&entry.Id, &entry.Title, &entry.PosterPath, &entry.ReleaseDate, &entry.Runtime, &entry.ImdbId, &added)
+		if err != nil {
+			return nil, fmt.Errorf("library: %w", err)
+		}
+
+		entry.Added = time.Unix(added, 0)
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+func (s *Store) History(ctx context.Context, userId int64, query Query) ([]Viewing, error) {
+	where, args := filter([]string{"user_id = ?"}, []any{userId}, query, "watched_on")
+
+	if query.MinRating > 0 {
+		where = append(where, "rating >= ?")
+		args = append(args, query.MinRating)
+	}
+
+	rows, err := s.db.QueryContext(ctx,
+		"SELECT id, movie_id, title, poster_path, release_date, runtime, imdb_id, watched_on, rating FROM viewings WHERE "+
+			strings.Join(where, " AND ")+orderBy(HistorySorts, query),
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("library: %w", err)
+	}
+	defer rows.Close()
+
+	viewings := make([]Viewing, 0)
+
+	for rows.Next() {
+		var viewing Viewing
+		var date string
+		var rating sql.NullInt64
+
+		err := rows.Scan(&viewing.Id, &viewing.Movie.Id, &viewing.Title, &viewing.PosterPath,
+			&viewing.ReleaseDate, &viewing.Runtime, &viewing.ImdbId, &date, &rating)
+		if err != nil {
+			return nil, fmt.Errorf("library: %w", err)
+		}
+
+		viewing.Date, err = time.Parse(DateLayout, date)
+		if err != nil {
+			return nil, fmt.Errorf("library: viewing %d: %w", viewing.Id, err)
+		}
+
+		viewing.Rating = int(rating.Int64)
+		viewings = append(viewings, viewing)
+	}
+
+	return viewings, rows.Err()
+}
@@ -0,0 +1,334 @@
+package library
+
+import (
+	"context"
+	"errors"
+	"math"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/m4tthewde/blunt/database"
+	"github.com/m4tthewde/blunt/users"
+)
+
+var (
+	heat  = Movie{Id: 949, Title: "Heat", ReleaseDate: "1995-12-15", Runtime: 170}
+	ronin = Movie{Id: 8195, Title: "Ronin", ReleaseDate: "1998-09-25", Runtime: 122}
+	wolf  = Movie{Id: 514754, Title: "100% Wolf", ReleaseDate: "2020-05-28"}
+	dune  = Movie{Id: 693134, Title: "dune: Part Two", ReleaseDate: "2024-02-27", Runtime: 167}
+)
+
+func newStore(t *testing.T) (*Store, int64, int64) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	db, err := database.Open(ctx, filepath.Join(t.TempDir(), "blunt.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	accounts := users.NewStore(db)
+
+	alice, err := accounts.Ensure(ctx, "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bob, err := accounts.Ensure(ctx, "bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return NewStore(db), alice.Id, bob.Id
+}
+
+func date(value string) time.Time {
+	parsed, err := time.Parse(DateLayout, value)
+	if err != nil {
+		panic(err)
+	}
+
+	return parsed
+}
+
+func TestWatchlist(t *testing.T) {
+	ctx := context.Background()
+	store, alice, bob := newStore(t)
+
+	for _, movie := range []Movie{ronin, heat, dune, wolf, heat} {
+		err := store.AddToWatchlist(ctx, alice, movie)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := store.AddToWatchlist(ctx, bob, ronin)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		query Query
+		want  []string
+	}{
+		// added in the same second, the order they were added in decides
+		{"added", Query{}, []string{"Ronin", "Heat", "dune: Part Two", "100% Wolf"}},
+		{"added descending", Query{Descending: true}, []string{"100% Wolf", "dune: Part Two", "Heat", "Ronin"}},
+		{"title ignores case", Query{Sort: "title"}, []string{"100% Wolf", "dune: Part Two", "Heat", "Ronin"}},
+		{"release", Query{Sort: "release", Descending: true}, []string{"dune: Part Two", "100% Wolf", "Ronin", "Heat"}},
+		{"runtime", Query{Sort: "runtime"}, []string{"100% Wolf", "Ronin", "dune: Part Two", "Heat"}},
+		// only the allowlisted columns can be sorted by
+		{"unknown sort", Query{Sort: "rowid; DROP TABLE watchlist"}, []string{"Ronin", "Heat", "dune: Part Two", "100% Wolf"}},
+		{"column name", Query{Sort: "added_at", Descending: true}, []string{"100% Wolf", "dune: Part Two", "Heat", "Ronin"}},
+		{"search", Query{Search: "HEA"}, []string{"Heat"}},
+		{"search for a wildcard", Query{Search: "%"}, []string{"100% Wolf"}},
+		{"search for an underscore", Query{Search: "_"}, []string{}},
+		{"year of release", Query{Year: 1998}, []string{"Ronin"}},
+		{"movie", Query{MovieId: heat.Id}, []string{"Heat"}},
+		{"search and year", Query{Search: "o", Year: 2020}, []string{"100% Wolf"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			entries, err := store.Watchlist(ctx, alice, test.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			titles := make([]string, 0)
+			for _, entry := range entries {
+				titles = append(titles, entry.Title)
+			}
+
+			if !slices.Equal(titles, test.want) {
+				t.Errorf("watchlist %q, want %q", titles, test.want)
+			}
+		})
+	}
+
+	err = store.RemoveFromWatchlist(ctx, alice, heat.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	status, err := store.Status(ctx, alice, heat.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if status.OnWatchlist {
+		t.Error("heat is still on the watchlist")
+	}
+}
+
+// addHistory gives alice five viewings, Heat twice, and bob one.
+func addHistory(t *testing.T, store *Store, alice int64, bob int64) {
+	t.Helper()
+
+	viewings := []struct {
+		user   int64
+		movie  Movie
+		date   string
+		rating int
+	}{
+		{alice, heat, "2024-01-05", 8},
+		{alice, ronin, "2023-12-31", 6},
+		{alice, wolf, "2024-02-02", 3},
+		{alice, heat, "2024-03-01", 0},
+		{alice, dune, "2024-03-01", 10},
+		{bob, heat, "2024-01-01", 10},
+	}
+
+	for _, viewing := range viewings {
+		err := store.AddViewing(context.Background(), viewing.user, viewing.movie, date(viewing.date), viewing.rating)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestHistory(t *testing.T) {
+	ctx := context.Background()
+	store, alice, bob := newStore(t)
+	addHistory(t, store, alice, bob)
+
+	type viewing struct {
+		title string
+		date  string
+	}
+
+	tests := []struct {
+		name  string
+		query Query
+		want  []viewing
+	}{
+		{
+			// viewings of one day keep the order they were added in
+			name:  "watched",
+			query: Query{},
+			want:  []viewing{{"Ronin", "2023-12-31"}, {"Heat", "2024-01-05"}, {"100% Wolf", "2024-02-02"}, {"Heat", "2024-03-01"}, {"dune: Part Two", "2024-03-01"}},
+		},
+		{
+			name:  "watched descending",
+			query: Query{Descending: true},
+			want:  []viewing{{"dune: Part Two", "2024-03-01"}, {"Heat", "2024-03-01"}, {"100% Wolf", "2024-02-02"}, {"Heat", "2024-01-05"}, {"Ronin", "2023-12-31"}},
+		},
+		{
+			// unrated viewings come last
+			name:  "rating",
+			query: Query{Sort: "rating", Descending: true},
+			want:  []viewing{{"dune: Part Two", "2024-03-01"}, {"Heat", "2024-01-05"}, {"Ronin", "2023-12-31"}, {"100% Wolf", "2024-02-02"}, {"Heat", "2024-03-01"}},
+		},
+		{
+			name:  "unknown sort",
+			query: Query{Sort: "watched_on DESC --"},
+			want:  []viewing{{"Ronin", "2023-12-31"}, {"Heat", "2024-01-05"}, {"100% Wolf", "2024-02-02"}, {"Heat", "2024-03-01"}, {"dune: Part Two", "2024-03-01"}},
+		},
+		{
+			// the year of the viewing, not of the release
+			name:  "year",
+			query: Query{Year: 2023},
+			want:  []viewing{{"Ronin", "2023-12-31"}},
+		},
+		{
+			name:  "minimum rating",
+			query: Query{MinRating: 6},
+			want:  []viewing{{"Ronin", "2023-12-31"}, {"Heat", "2024-01-05"}, {"dune: Part Two", "2024-03-01"}},
+		},
+		{
+			name:  "movie",
+			query: Query{MovieId: heat.Id, Descending: true},
+			want:  []viewing{{"Heat", "2024-03-01"}, {"Heat", "2024-01-05"}},
+		},
+		{
+			name:  "search and rating",
+			query: Query{Search: "e", MinRating: 7},
+			want:  []viewing{{"Heat", "2024-01-05"}, {"dune: Part Two", "2024-03-01"}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			viewings, err := store.History(ctx, alice, test.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := make([]viewing, 0)
+			for _, v := range viewings {
+				got = append(got, viewing{v.Title, v.Date.Format(DateLayout)})
+			}
+
+			if !slices.Equal(got, test.want) {
+				t.Errorf("history %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestAddViewing(t *testing.T) {
+	ctx := context.Background()
+	store, alice, _ := newStore(t)
+
+	err := store.AddToWatchlist(ctx, alice, heat)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = store.AddViewing(ctx, alice, heat, date("2024-01-05"), 11)
+	if !errors.Is(err, ErrInvalidRating) {
+		t.Errorf("error %v, want ErrInvalidRating", err)
+	}
+
+	err = store.AddViewing(ctx, alice, heat, time.Now().AddDate(0, 0, 2), 0)
+	if !errors.Is(err, ErrInvalidDate) {
+		t.Errorf("error %v, want ErrInvalidDate", err)
+	}
+
+	err = store.AddViewing(ctx, alice, heat, date("2024-01-05"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	status, err := store.Status(ctx, alice, heat.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if status.OnWatchlist || len(status.Viewings) != 1 {
+		t.Errorf("on the watchlist %v, %d viewings", status.OnWatchlist, len(status.Viewings))
+	}
+
+	ok, err := store.RateLatestViewing(ctx, alice, heat.Id, 9)
+	if err != nil || !ok {
+		t.Fatalf("rating: %v, %v", ok, err)
+	}
+
+	ok, err = store.RateLatestViewing(ctx, alice, ronin.Id, 9)
+	if err != nil || ok {
+		t.Errorf("rating an unwatched movie: %v, %v", ok, err)
+	}
+
+	movieId, err := store.DeleteViewing(ctx, alice, status.Viewings[0].Id)
+	if err != nil || movieId != heat.Id {
+		t.Errorf("deleting: %d, %v", movieId, err)
+	}
+
+	_, err = store.DeleteViewing(ctx, alice, status.Viewings[0].Id)
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("error %v, want ErrNotFound", err)
+	}
+}
+
+func TestStats(t *testing.T) {
+	ctx := context.Background()
+	store, alice, bob := newStore(t)
+	addHistory(t, store, alice, bob)
+
+	tests := []struct {
+		name  string
+		query Query
+		want  Stats
+	}{
+		{
+			name:  "everything",
+			query: Query{},
+			// Wolf has no runtime, Heat was watched twice and rated once
+			want: Stats{Viewings: 5, Movies: 4, Runtime: (170 + 122 + 170 + 167) * time.Minute, Unknown: 1, Rated: 4, AverageRating: 27.0 / 4},
+		},
+		{
+			name:  "filtered",
+			query: Query{Year: 2024, MinRating: 5},
+			want:  Stats{Viewings: 2, Movies: 2, Runtime: (170 + 167) * time.Minute, Rated: 2, AverageRating: 9},
+		},
+		{
+			name:  "nothing",
+			query: Query{Search: "Casablanca"},
+			want:  Stats{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			viewings, err := store.History(ctx, alice, test.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := NewStats(viewings)
+			if math.Abs(got.AverageRating-test.want.AverageRating) > 1e-9 {
+				t.Errorf("average rating %v, want %v", got.AverageRating, test.want.AverageRating)
+			}
+
+			got.AverageRating = test.want.AverageRating
+			if got != test.want {
+				t.Errorf("stats %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package library
+
+import "time"
+
+type Stats struct {
+	Viewings int
+	// Movies counts distinct movies, rewatches count once.
+	Movies int
+	// Runtime is the time spent watching, rewatches included.
+	Runtime time.Duration
+	// Unknown counts viewings of movies TMDB has no runtime for.
+	Unknown       int
+	Rated         int
+	AverageRating float64
+}
+
+func NewStats(viewings []Viewing) Stats {
+	stats := Stats{Viewings: len(viewings)}
+	movies := make(map[int64]bool)
+	ratings := 0
+
+	for _, viewing := range viewings {
+		movies[viewing.Movie.Id] = true
+
+		if viewing.Runtime == 0 {
+			stats.Unknown++
+		}
+
+		stats.Runtime += time.Duration(viewing.Runtime) * time.Minute
+
+		if viewing.Rating != 0 {
+			stats.Rated++
+			ratings += viewing.Rating
+		}
+	}
+
+	stats.Movies = len(movies)
+
+	if stats.Rated != 0 {
+		stats.AverageRating = float64(ratings) / float64(stats.Rated)
+	}
+
+	return stats
+}
@@ -0,0 +1,552 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/m4tthewde/blunt/catalog"
+	"github.com/m4tthewde/blunt/components"
+	"github.com/m4tthewde/blunt/lists"
+	"github.com/m4tthewde/blunt/metrics"
+	"github.com/m4tthewde/blunt/tmdb"
+	"github.com/m4tthewde/blunt/users"
+)
+
+var userLists *lists.Store
+
+// listErrors maps the errors of the list store to message keys.
+var listErrors = map[error]string{
+	lists.ErrInvalidName: "lists.error.invalidName",
+	lists.ErrInvalidNote: "lists.error.invalidNote",
+	lists.ErrNoUser:      "lists.error.noUser",
+}
+
+// listError answers with the status of the errors every list handler can
+// run into.
+func listError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, lists.ErrNotFound) || errors.Is(err, lists.ErrNoItem) || errors.Is(err, tmdb.ErrNotFound):
+		http.NotFound(w, r)
+	case errors.Is(err, lists.ErrNotOwner):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, lists.ErrInvalidNote):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		serverError(w, r, err)
+	}
+}
+
+func pathId(w http.ResponseWriter, r *http.Request, name string) (int64, bool) {
+	id, err := strconv.ParseInt(r.PathValue(name), 10, 64)
+	if err != nil || id < 1 {
+		http.Error(w, "invalid "+name, http.StatusBadRequest)
+		return 0, false
+	}
+
+	return id, true
+}
+
+// pathList returns the list of the path if the user may see it.
+func pathList(w http.ResponseWriter, r *http.Request, user *users.User) (lists.List, bool) {
+	id, ok := pathId(w, r, "id")
+	if !ok {
+		return lists.List{}, false
+	}
+
+	list, err := userLists.List(r.Context(), user.Id, id)
+	if err != nil {
+		listError(w, r, err)
+		return list, false
+	}
+
+	return list, true
+}
+
+func listsPage(w http.ResponseWriter, r *http.Request, user *users.User) {
+	renderLists(w, r, user, components.ListForm{})
+}
+
+func renderLists(w http.ResponseWriter, r *http.Request, user *users.User, form components.ListForm) {
+	overview, err := userLists.Lists(r.Context(), user.Id)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	render(w, r, components.Lists(overview, form))
+}
+
+func createList(w http.ResponseWriter, r *http.Request, user *users.User) {
+	form := components.ListForm{
+		Name:        strings.TrimSpace(r.PostFormValue("name")),
+		Description: strings.TrimSpace(r.PostFormValue("description")),
+	}
+
+	id, err := userLists.Create(r.Context(), user.Id, form.Name, form.Description)
+	if errors.Is(err, lists.ErrInvalidName) {
+		form.Error = listErrors[err]
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		renderLists(w, r, user, form)
+		return
+	}
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/me/lists/%d", id), http.StatusSeeOther)
+}
+
+func listPage(w http.ResponseWriter, r *http.Request, user *users.User) {
+	list, ok := pathList(w, r, user)
+	if !ok {
+		return
+	}
+
+	renderList(w, r, list, components.ListForm{Name: list.Name, Description: list.Description})
+}
+
+func renderList(w http.ResponseWriter, r *http.Request, list lists.List, form components.ListForm) {
+	items, err := userLists.Items(r.Context(), list.Id)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	members := make([]lists.Member, 0)
+
+	if list.Role != lists.Viewer {
+		members, err = userLists.Members(r.Context(), list.Id)
+		if err != nil {
+			serverError(w, r, err)
+			return
+		}
+	}
+
+	render(w, r, components.ListDetails(list, items, members, form))
+}
+
+// updateList renames the list or changes its description.
+func updateList(w http.ResponseWriter, r *http.Request, user *users.User) {
+	list, ok := pathList(w, r, user)
+	if !ok {
+		return
+	}
+
+	form := components.ListForm{
+		Name:        strings.TrimSpace(r.PostFormValue("name")),
+		Description: strings.TrimSpace(r.PostFormValue("description")),
+	}
+
+	err := userLists.Update(r.Context(), user.Id, list.Id, form.Name, form.Description)
+	if errors.Is(err, lists.ErrInvalidName) {
+		form.Error = listErrors[err]
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		renderList(w, r, list, form)
+		return
+	}
+	if err != nil {
+		listError(w, r, err)
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("/me/lists/%d", list.Id), http.StatusSeeOther)
+}
+
+func deleteList(w http.ResponseWriter, r *http.Request, user *users.User) {
+	id, ok := pathId(w, r, "id")
+	if !ok {
+		return
+	}
+
+	err := userLists.Delete(r.Context(), user.Id, id)
+	if err != nil {
+		listError(w, r, err)
+		return
+	}
+
+	w.Header().Set("HX-Redirect", "/me/lists")
+}
+
+// listChoice offers the user's lists on detail pages and search results,
+// it's nil for anonymous visitors.
+func listChoice(r *http.Request, kind string, id int64) (*components.ListChoice, error) {
+	user := users.FromContext(r.Context())
+	if user == nil {
+		return nil, nil
+	}
+
+	choices, err := userLists.Lists(r.Context(), user.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &components.ListChoice{Kind: kind, Id: id, Lists: choices}, nil
+}
+
+// addToList adds a movie or person to one of the user's lists. htmx gets
+// the picker back saying where the item went, browsers without it the
+// list.
+func addToList(w http.ResponseWriter, r *http.Request, user *users.User) {
+	listId, err := strconv.ParseInt(r.PostFormValue("list"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid list", http.StatusBadRequest)
+		return
+	}
+
+	// asking TMDB is only worth it for lists the user may change
+	_, err = userLists.List(r.Context(), user.Id, listId)
+	if err != nil {
+		listError(w, r, err)
+		return
+	}
+
+	kind := r.PostFormValue("kind")
+
+	id, err := strconv.ParseInt(r.PostFormValue("id"), 10, 64)
+	if err != nil || id < 1 {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return
+	}
+
+	var item lists.Item
+
+	switch kind {
+	case catalog.Movie:
+		details, err := client.MovieDetails(r.Context(), strconv.FormatInt(id, 10))
+		if err != nil {
+			listError(w, r, err)
+			return
+		}
+
+		item = lists.NewMovieItem(details)
+	case catalog.Person:
+		person, err := client.People(r.Context(), strconv.FormatInt(id, 10))
+		if err != nil {
+			listError(w, r, err)
+			return
+		}
+
+		item = lists.NewPersonItem(person)
+	default:
+		http.Error(w, "invalid kind", http.StatusBadRequest)
+		return
+	}
+
+	err = userLists.AddItem(r.Context(), user.Id, listId, item)
+	if err != nil {
+		listError(w, r, err)
+		return
+	}
+
+	if r.Header.Get("HX-Request") != "true" {
+		http.Redirect(w, r, fmt.Sprintf("/me/lists/%d", listId), http.StatusSeeOther)
+		return
+	}
+
+	choice, err := listChoice(r, kind, id)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	choice.Added = listId
+
+	render(w, r, components.ListPicker(*choice))
+}
+
+// listItems answers changes of items with the updated items of the list.
+func listItems(w http.ResponseWriter, r *http.Request, list lists.List) {
+	items, err := userLists.Items(r.Context(), list.Id)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	render(w, r, components.ListItems(list, items))
+}
+
+func removeListItem(w http.ResponseWriter, r *http.Request, user *users.User) {
+	list, ok := pathList(w, r, user)
+	if !ok {
+		return
+	}
+
+	itemId, ok := pathId(w, r, "item")
+	if !ok {
+		return
+	}
+
+	err := userLists.RemoveItem(r.Context(), user.Id, list.Id, itemId)
+	if err != nil {
+		listError(w, r, err)
+		return
+	}
+
+	listItems(w, r, list)
+}
+
+func setListNote(w http.ResponseWriter, r *http.Request, user *users.User) {
+	list, ok := pathList(w, r, user)
+	if !ok {
+		return
+	}
+
+	itemId, ok := pathId(w, r, "item")
+	if !ok {
+		return
+	}
+
+	err := userLists.SetNote(r.Context(), user.Id, list.Id, itemId, strings.TrimSpace(r.PostFormValue("note")))
+	if err != nil {
+		listError(w, r, err)
+		return
+	}
+
+	listItems(w, r, list)
+}
+
+func moveListItem(w http.ResponseWriter, r *http.Request, user *users.User) {
+	list, ok := pathList(w, r, user)
+	if !ok {
+		return
+	}
+
+	itemId, ok := pathId(w, r, "item")
+	if !ok {
+		return
+	}
+
+	direction := r.PostFormValue("direction")
+	if direction != "up" && direction != "down" {
+		http.Error(w, "invalid direction", http.StatusBadRequest)
+		return
+	}
+
+	err := userLists.MoveItem(r.Context(), user.Id, list.Id, itemId, direction == "up")
+	if err != nil {
+		listError(w, r, err)
+		return
+	}
+
+	listItems(w, r, list)
+}
+
+func shareList(w http.ResponseWriter, r *http.Request, user *users.User) {
+	changeSharing(w, r, user, userLists.Share)
+}
+
+func unshareList(w http.ResponseWriter, r *http.Request, user *users.User) {
+	changeSharing(w, r, user, userLists.Unshare)
+}
+
+// changeSharing answers with the updated sharing section of the list.
+func changeSharing(w http.ResponseWriter, r *http.Request, user *users.User, change func(ctx context.Context, userId int64, id int64) error) {
+	id, ok := pathId(w, r, "id")
+	if !ok {
+		return
+	}
+
+	err := change(r.Context(), user.Id, id)
+	if err != nil {
+		listError(w, r, err)
+		return
+	}
+
+	list, err := userLists.List(r.Context(), user.Id, id)
+	if err != nil {
+		listError(w, r, err)
+		return
+	}
+
+	render(w, r, components.ListSharing(list))
+}
+
+func addListMember(w http.ResponseWriter, r *http.Request, user *users.User) {
+	list, ok := pathList(w, r, user)
+	if !ok {
+		return
+	}
+
+	err := userLists.AddMember(r.Context(), user.Id, list.Id, r.PostFormValue("name"))
+	if errors.Is(err, lists.ErrNoUser) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		listMembers(w, r, list, listErrors[err])
+		return
+	}
+	if err != nil {
+		listError(w, r, err)
+		return
+	}
+
+	listMembers(w, r, list, "")
+}
+
+// removeListMember lets owners remove collaborators and collaborators leave
+// the list, which takes them back to their lists.
+func removeListMember(w http.ResponseWriter, r *http.Request, user *users.User) {
+	list, ok := pathList(w, r, user)
+	if !ok {
+		return
+	}
+
+	memberId, ok := pathId(w, r, "user")
+	if !ok {
+		return
+	}
+
+	err := userLists.RemoveMember(r.Context(), user.Id, list.Id, memberId)
+	if errors.Is(err, lists.ErrNoUser) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		listError(w, r, err)
+		return
+	}
+
+	if memberId == user.Id {
+		w.Header().Set("HX-Redirect", "/me/lists")
+		return
+	}
+
+	listMembers(w, r, list, "")
+}
+
+func listMembers(w http.ResponseWriter, r *http.Request, list lists.List, errorKey string) {
+	members, err := userLists.Members(r.Context(), list.Id)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	render(w, r, components.ListMembers(list, members, errorKey))
+}
+
+func exportList(w http.ResponseWriter, r *http.Request, user *users.User) {
+	list, ok := pathList(w, r, user)
+	if !ok {
+		return
+	}
+
+	writeListExport(w, r, list)
+}
+
+func listGraph(w http.ResponseWriter, r *http.Request, user *users.User) {
+	list, ok := pathList(w, r, user)
+	if !ok {
+		return
+	}
+
+	renderListGraph(w, r, list)
+}
+
+// sharedList looks up the list of a link, for anyone who has it.
+func sharedList(w http.ResponseWriter, r *http.Request) (lists.List, bool) {
+	list, err := userLists.Shared(r.Context(), r.PathValue("token"))
+	if err != nil {
+		listError(w, r, err)
+		return list, false
+	}
+
+	return list, true
+}
+
+func sharedListPage(w http.ResponseWriter, r *http.Request) {
+	list, ok := sharedList(w, r)
+	if !ok {
+		return
+	}
+
+	renderList(w, r, list, components.ListForm{})
+}
+
+func exportSharedList(w http.ResponseWriter, r *http.Request) {
+	list, ok := sharedList(w, r)
+	if !ok {
+		return
+	}
+
+	writeListExport(w, r, list)
+}
+
+func sharedListGraph(w http.ResponseWriter, r *http.Request) {
+	list, ok := sharedList(w, r)
+	if !ok {
+		return
+	}
+
+	renderListGraph(w, r, list)
+}
+
+func writeListExport(w http.ResponseWriter, r *http.Request, list lists.List) {
+	format := r.PathValue("format")
+	if !slices.Contains(lists.ExportFormats, format) {
+		http.NotFound(w, r)
+		return
+	}
+
+	items, err := userLists.Items(r.Context(), list.Id)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	var b bytes.Buffer
+
+	err = lists.Export(&b, format, list, items)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	contentType := "text/csv; charset=utf-8"
+	if format == "json" {
+		contentType = "application/json"
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", `attachment; filename="`+lists.FileName(list, format)+`"`)
+	w.Write(b.Bytes())
+}
+
+// renderListGraph starts the graph with every item of the list, each of
+// them expands like the nodes of the other graphs.
+func renderListGraph(w http.ResponseWriter, r *http.Request, list lists.List) {
+	defer metrics.TrackGraph()()
+
+	items, err := userLists.Items(r.Context(), list.Id)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	movies := make([]components.GraphElement, 0)
+	people := make([]components.GraphElement, 0)
+
+	for _, item := range items {
+		if item.Kind == catalog.Person {
+			people = append(people, components.GraphElement{
+				Id:        item.TmdbId,
+				ImageKind: tmdb.ProfileImage,
+				ImagePath: item.ImagePath,
+			})
+			continue
+		}
+
+		movies = append(movies, components.GraphElement{
+			Id:        item.TmdbId,
+			ImageKind: tmdb.PosterImage,
+			ImagePath: item.ImagePath,
+		})
+	}
+
+	render(w, r, components.ListGraph(list, movies, people, uuid.New().String()))
+}
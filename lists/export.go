@@ -0,0 +1,112 @@
+package lists
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/m4tthewde/blunt/tmdb"
+)
+
+// ExportFormats are the file extensions lists can be downloaded as.
+var ExportFormats = []string{"csv", "json"}
+
+type exportedList struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Owner       string         `json:"owner"`
+	Items       []exportedItem `json:"items"`
+}
+
+type exportedItem struct {
+	Position int    `json:"position"`
+	Type     string `json:"type"`
+	TmdbId   int64  `json:"tmdbId"`
+	Name     string `json:"name"`
+	Year     string `json:"year,omitempty"`
+	Note     string `json:"note,omitempty"`
+	Added    string `json:"added"`
+}
+
+// Export writes the list's items in their order as one of the
+// ExportFormats. Positions are renumbered from 1, gaps left by removed
+// items don't matter to anyone reading the file.
+func Export(w io.Writer, format string, list List, items []Item) error {
+	exported := make([]exportedItem, 0, len(items))
+
+	for i, item := range items {
+		exported = append(exported, exportedItem{
+			Position: i + 1,
+			Type:     item.Kind,
+			TmdbId:   item.TmdbId,
+			Name:     item.Title,
+			Year:     tmdb.GetReleaseYear(item.ReleaseDate),
+			Note:     item.Note,
+			Added:    item.Added.UTC().Format(time.DateOnly),
+		})
+	}
+
+	switch format {
+	case "csv":
+		return writeCsv(w, exported)
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+
+		err := encoder.Encode(exportedList{
+			Name:        list.Name,
+			Description: list.Description,
+			Owner:       list.Owner,
+			Items:       exported,
+		})
+		if err != nil {
+			return fmt.Errorf("lists: %w", err)
+		}
+
+		return nil
+	default:
+		return fmt.Errorf("lists: unknown format %q", format)
+	}
+}
+
+func writeCsv(w io.Writer, items []exportedItem) error {
+	records := [][]string{{"Position", "Type", "tmdbID", "Name", "Year", "Notes", "Added"}}
+
+	for _, item := range items {
+		records = append(records, []string{
+			strconv.Itoa(item.Position), item.Type, strconv.FormatInt(item.TmdbId, 10),
+			item.Name, item.Year, item.Note, item.Added,
+		})
+	}
+
+	err := csv.NewWriter(w).WriteAll(records)
+	if err != nil {
+		return fmt.Errorf("lists: %w", err)
+	}
+
+	return nil
+}
+
+// FileName names an export after the list, keeping only ASCII letters and
+// digits, which are safe in file names and headers everywhere.
+func FileName(list List, format string) string {
+	name := strings.Map(func(r rune) rune {
+		if r <= unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return unicode.ToLower(r)
+		}
+
+		return '-'
+	}, list.Name)
+
+	name = strings.Trim(name, "-")
+	if name == "" {
+		name = strconv.FormatInt(list.Id, 10)
+	}
+
+	return "blunt-list-" + name + "." + format
+}
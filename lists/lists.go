@@ -0,0 +1,606 @@
+// Package lists keeps the named lists of movies and people users put
+// together, and who they share them with.
+package lists
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/m4tthewde/blunt/catalog"
+	"github.com/m4tthewde/blunt/tmdb"
+)
+
+var (
+	ErrNotFound    = errors.New("lists: no such list")
+	ErrNoItem      = errors.New("lists: no such item")
+	ErrNoUser      = errors.New("lists: no such user")
+	ErrNotOwner    = errors.New("only the owner can change the list")
+	ErrInvalidName = fmt.Errorf("list names are 1 to %d characters", maxNameLength)
+	ErrInvalidNote = fmt.Errorf("notes are at most %d characters", maxNoteLength)
+)
+
+const (
+	maxNameLength        = 100
+	maxDescriptionLength = 1000
+	maxNoteLength        = 1000
+)
+
+// Role is what the user looking at a list may do with it.
+type Role int
+
+const (
+	// Viewer opened a list shared by link.
+	Viewer Role = iota
+	// Collaborator may add, remove, reorder and annotate items.
+	Collaborator
+	// Owner may also rename, share and delete the list.
+	Owner
+)
+
+func (r Role) CanEdit() bool {
+	return r >= Collaborator
+}
+
+type List struct {
+	Id          int64
+	Owner       string
+	Name        string
+	Description string
+	// ShareToken is empty while the list isn't shared by link.
+	ShareToken string
+	Role       Role
+	Items      int
+	Created    time.Time
+	Updated    time.Time
+}
+
+type Item struct {
+	Id int64
+	// Kind is catalog.Movie or catalog.Person.
+	Kind      string
+	TmdbId    int64
+	Title     string
+	ImagePath string
+	// ReleaseDate of movies, empty for people.
+	ReleaseDate string
+	Note        string
+	Position    int
+	// AddedBy is empty once the account is deleted.
+	AddedBy string
+	Added   time.Time
+}
+
+func NewMovieItem(details *tmdb.MovieDetailsResponse) Item {
+	return Item{
+		Kind:        catalog.Movie,
+		TmdbId:      details.Id,
+		Title:       details.Title,
+		ImagePath:   details.PosterPath,
+		ReleaseDate: details.ReleaseDate,
+	}
+}
+
+func NewPersonItem(person *tmdb.PeopleResponse) Item {
+	return Item{
+		Kind:      catalog.Person,
+		TmdbId:    person.Id,
+		Title:     person.Name,
+		ImagePath: person.ProfilePath,
+	}
+}
+
+type Member struct {
+	UserId int64
+	Name   string
+	Added  time.Time
+}
+
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+func validName(name string, description string) error {
+	length := utf8.RuneCountInString(name)
+	if length < 1 || length > maxNameLength || utf8.RuneCountInString(description) > maxDescriptionLength {
+		return ErrInvalidName
+	}
+
+	return nil
+}
+
+func (s *Store) Create(ctx context.Context, userId int64, name string, description string) (int64, error) {
+	err := validName(name, description)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now().Unix()
+
+	var id int64
+
+	err = s.db.QueryRowContext(ctx, `
+		INSERT INTO lists (user_id, name, description, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?) RETURNING id`,
+		userId, name, description, now, now,
+	).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("lists: %w", err)
+	}
+
+	return id, nil
+}
+
+// listColumns are read by scanList, role is computed by each query.
+const listColumns = `
+	lists.id, users.name, lists.name, lists.description, coalesce(lists.share_token, ''),
+	(SELECT count(*) FROM list_items WHERE list_id = lists.id), lists.created_at, lists.updated_at`
+
+// Lists returns the lists the user owns or collaborates on, recently
+// changed ones first.
+func (s *Store) Lists(ctx context.Context, userId int64) ([]List, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+listColumns+`, CASE WHEN lists.user_id = ? THEN 2 ELSE 1 END
+		FROM lists JOIN users ON users.id = lists.user_id
+		WHERE lists.user_id = ?
+			OR EXISTS (SELECT 1 FROM list_members WHERE list_id = lists.id AND user_id = ?)
+		ORDER BY lists.updated_at DESC, lists.id DESC`,
+		userId, userId, userId,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("lists: %w", err)
+	}
+	defer rows.Close()
+
+	result := make([]List, 0)
+
+	for rows.Next() {
+		list, err := scanList(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, list)
+	}
+
+	return result, rows.Err()
+}
+
+// List returns a list the user owns or collaborates on, other lists are
+// ErrNotFound.
+func (s *Store) List(ctx context.Context, userId int64, id int64) (List, error) {
+	row := s.db.QueryRowContext(ctx, `
+		SELECT `+listColumns+`, CASE WHEN lists.user_id = ? THEN 2 ELSE 1 END
+		FROM lists JOIN users ON users.id = lists.user_id
+		WHERE lists.id = ? AND (
+			lists.user_id = ?
+			OR EXISTS (SELECT 1 FROM list_members WHERE list_id = lists.id AND user_id = ?)
+		)`,
+		userId, id, userId, userId,
+	)
+
+	list, err := scanList(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return list, ErrNotFound
+	}
+
+	return list, err
+}
+
+// Shared returns the list shared by token, for viewing only.
+func (s *Store) Shared(ctx context.Context, token string) (List, error) {
+	row := s.db.QueryRowContext(ctx, `
+		SELECT `+listColumns+`, 0
+		FROM lists JOIN users ON users.id = lists.user_id
+		WHERE lists.share_token = ?`,
+		token,
+	)
+
+	list, err := scanList(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return list, ErrNotFound
+	}
+
+	return list, err
+}
+
+func scanList(row interface{ Scan(dest ...any) error }) (List, error) {
+	var list List
+	var created, updated int64
+
+	err := row.Scan(&list.Id, &list.Owner, &list.Name, &list.Description, &list.ShareToken,
+		&list.Items, &created, &updated, &list.Role)
+	if errors.Is(err, sql.ErrNoRows) {
+		return list, err
+	}
+	if err != nil {
+		return list, fmt.Errorf("lists: %w", err)
+	}
+
+	list.Created = time.Unix(created, 0)
+	list.Updated = time.Unix(updated, 0)
+
+	return list, nil
+}
+
+// role returns what the user may do with the list, ErrNotFound when
+// nothing.
+func (s *Store) role(ctx context.Context, userId int64, listId int64) (Role, error) {
+	var role sql.NullInt64
+
+	err := s.db.QueryRowContext(ctx, `
+		SELECT CASE
+			WHEN EXISTS (SELECT 1 FROM lists WHERE id = ? AND user_id = ?) THEN 2
+			WHEN EXISTS (SELECT 1 FROM list_members WHERE list_id = ? AND user_id = ?) THEN 1
+		END`,
+		listId, userId, listId, userId,
+	).Scan(&role)
+	if err != nil {
+		return Viewer, fmt.Errorf("lists: %w", err)
+	}
+
+	if !role.Valid {
+		return Viewer, ErrNotFound
+	}
+
+	return Role(role.Int64), nil
+}
+
+func (s *Store) requireRole(ctx context.Context, userId int64, listId int64, required Role) error {
+	role, err := s.role(ctx, userId, listId)
+	if err != nil {
+		return err
+	}
+
+	if role < required {
+		return ErrNotOwner
+	}
+
+	return nil
+}
+
+// touch marks the list as changed, which moves it up on the overview.
+func (s *Store) touch(ctx context.Context, listId int64) error {
+	_, err := s.db.ExecContext(ctx, "UPDATE lists SET updated_at = ? WHERE id = ?", time.Now().Unix(), listId)
+	if err != nil {
+		return fmt.Errorf("lists: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Store) Update(ctx context.Context, userId int64, id int64, name string, description string) error {
+	err := validName(name, description)
+	if err != nil {
+		return err
+	}
+
+	err = s.requireRole(ctx, userId, id, Owner)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(ctx,
+		"UPDATE lists SET name = ?, description = ?, updated_at = ? WHERE id = ?",
+		name, description, time.Now().Unix(), id,
+	)
+	if err != nil {
+		return fmt.Errorf("lists: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Store) Delete(ctx context.Context, userId int64, id int64) error {
+	err := s.requireRole(ctx, userId, id, Owner)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(ctx, "DELETE FROM lists WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("lists: %w", err)
+	}
+
+	return nil
+}
+
+// Share makes the list readable by everyone with its link. Sharing a shared
+// list again keeps the link.
+func (s *Store) Share(ctx context.Context, userId int64, id int64) error {
+	err := s.requireRole(ctx, userId, id, Owner)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(ctx,
+		"UPDATE lists SET share_token = coalesce(share_token, ?) WHERE id = ?", rand.Text(), id,
+	)
+	if err != nil {
+		return fmt.Errorf("lists: %w", err)
+	}
+
+	return nil
+}
+
+// Unshare revokes the link, sharing the list again creates a new one.
+func (s *Store) Unshare(ctx context.Context, userId int64, id int64) error {
+	err := s.requireRole(ctx, userId, id, Owner)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(ctx, "UPDATE lists SET share_token = NULL WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("lists: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Store) Items(ctx context.Context, listId int64) ([]Item, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT list_items.id, kind, tmdb_id, title, image_path, release_date, note, position,
+			coalesce(users.name, ''), added_at
+		FROM list_items LEFT JOIN users ON users.id = list_items.added_by
+		WHERE list_id = ? ORDER BY position, list_items.id`,
+		listId,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("lists: %w", err)
+	}
+	defer rows.Close()
+
+	items := make([]Item, 0)
+
+	for rows.Next() {
+		var item Item
+		var added int64
+
+		err := rows.Scan(&item.Id, &item.Kind, &item.TmdbId, &item.Title, &item.ImagePath,
+			&item.ReleaseDate, &item.Note, &item.Position, &item.AddedBy, &added)
+		if err != nil {
+			return nil, fmt.Errorf("lists: %w", err)
+		}
+
+		item.Added = time.Unix(added, 0)
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+// AddItem appends item to the list. Items already on it stay where they
+// are.
+func (s *Store) AddItem(ctx context.Context, userId int64, listId int64, item Item) error {
+	err := s.requireRole(ctx, userId, listId, Collaborator)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO list_items (list_id, kind, tmdb_id, title, image_path, release_date, note, position, added_by, added_at)
+		SELECT ?, ?, ?, ?, ?, ?, '', coalesce(max(position), 0) + 1, ?, ?
+		FROM list_items WHERE list_id = ?
+		ON CONFLICT (list_id, kind, tmdb_id) DO NOTHING`,
+		listId, item.Kind, item.TmdbId, item.Title, item.ImagePath, item.ReleaseDate, userId, time.Now().Unix(), listId,
+	)
+	if err != nil {
+		return fmt.Errorf("lists: %w", err)
+	}
+
+	return s.touch(ctx, listId)
+}
+
+func (s *Store) RemoveItem(ctx context.Context, userId int64, listId int64, itemId int64) error {
+	err := s.requireRole(ctx, userId, listId, Collaborator)
+	if err != nil {
+		return err
+	}
+
+	result, err := s.db.ExecContext(ctx, "DELETE FROM list_items WHERE id = ? AND list_id = ?", itemId, listId)
+	if err != nil {
+		return fmt.Errorf("lists: %w", err)
+	}
+
+	err = affected(result)
+	if err != nil {
+		return err
+	}
+
+	return s.touch(ctx, listId)
+}
+
+func (s *Store) SetNote(ctx context.Context, userId int64, listId int64, itemId int64, note string) error {
+	if utf8.RuneCountInString(note) > maxNoteLength {
+		return ErrInvalidNote
+	}
+
+	err := s.requireRole(ctx, userId, listId, Collaborator)
+	if err != nil {
+		return err
+	}
+
+	result, err := s.db.ExecContext(ctx,
+		"UPDATE list_items SET note = ? WHERE id = ? AND list_id = ?", note, itemId, listId,
+	)
+	if err != nil {
+		return fmt.Errorf("lists: %w", err)
+	}
+
+	err = affected(result)
+	if err != nil {
+		return err
+	}
+
+	return s.touch(ctx, listId)
+}
+
+// MoveItem swaps an item with its neighbour, the previous one when up is
+// true. Items at either end stay where they are.
+func (s *Store) MoveItem(ctx context.Context, userId int64, listId int64, itemId int64, up bool) error {
+	err := s.requireRole(ctx, userId, listId, Collaborator)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("lists: %w", err)
+	}
+	defer tx.Rollback()
+
+	var position int
+
+	err = tx.QueryRowContext(ctx,
+		"SELECT position FROM list_items WHERE id = ? AND list_id = ?", itemId, listId,
+	).Scan(&position)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNoItem
+	}
+	if err != nil {
+		return fmt.Errorf("lists: %w", err)
+	}
+
+	neighbour := "SELECT id, position FROM list_items WHERE list_id = ? AND position > ? ORDER BY position LIMIT 1"
+	if up {
+		neighbour = "SELECT id, position FROM list_items WHERE list_id = ? AND position < ? ORDER BY position DESC LIMIT 1"
+	}
+
+	var otherId int64
+	var otherPosition int
+
+	err = tx.QueryRowContext(ctx, neighbour, listId, position).Scan(&otherId, &otherPosition)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("lists: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE list_items SET position = ? WHERE id = ?", otherPosition, itemId)
+	if err != nil {
+		return fmt.Errorf("lists: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE list_items SET position = ? WHERE id = ?", position, otherId)
+	if err != nil {
+		return fmt.Errorf("lists: %w", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("lists: %w", err)
+	}
+
+	return s.touch(ctx, listId)
+}
+
+func (s *Store) Members(ctx context.Context, listId int64) ([]Member, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT users.id, users.name, list_members.added_at
+		FROM list_members JOIN users ON users.id = list_members.user_id
+		WHERE list_id = ? ORDER BY users.name COLLATE NOCASE`,
+		listId,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("lists: %w", err)
+	}
+	defer rows.Close()
+
+	members := make([]Member, 0)
+
+	for rows.Next() {
+		var member Member
+		var added int64
+
+		err := rows.Scan(&member.UserId, &member.Name, &added)
+		if err != nil {
+			return nil, fmt.Errorf("lists: %w", err)
+		}
+
+		member.Added = time.Unix(added, 0)
+		members = append(members, member)
+	}
+
+	return members, rows.Err()
+}
+
+// AddMember lets the account called name collaborate on the list.
+func (s *Store) AddMember(ctx context.Context, userId int64, listId int64, name string) error {
+	err := s.requireRole(ctx, userId, listId, Owner)
+	if err != nil {
+		return err
+	}
+
+	var memberId int64
+
+	err = s.db.QueryRowContext(ctx, "SELECT id FROM users WHERE name = ?", strings.TrimSpace(name)).Scan(&memberId)
+	if errors.Is(err, sql.ErrNoRows) || memberId == userId {
+		return ErrNoUser
+	}
+	if err != nil {
+		return fmt.Errorf("lists: %w", err)
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO list_members (list_id, user_id, added_at) VALUES (?, ?, ?)
+		ON CONFLICT (list_id, user_id) DO NOTHING`,
+		listId, memberId, time.Now().Unix(),
+	)
+	if err != nil {
+		return fmt.Errorf("lists: %w", err)
+	}
+
+	return nil
+}
+
+// RemoveMember takes a collaborator off the list. Owners remove anyone,
+// collaborators only themselves.
+func (s *Store) RemoveMember(ctx context.Context, userId int64, listId int64, memberId int64) error {
+	required := Owner
+	if memberId == userId {
+		required = Collaborator
+	}
+
+	err := s.requireRole(ctx, userId, listId, required)
+	if err != nil {
+		return err
+	}
+
+	result, err := s.db.ExecContext(ctx,
+		"DELETE FROM list_members WHERE list_id = ? AND user_id = ?", listId, memberId,
+	)
+	if err != nil {
+		return fmt.Errorf("lists: %w", err)
+	}
+
+	err = affected(result)
+	if errors.Is(err, ErrNoItem) {
+		return ErrNoUser
+	}
+
+	return err
+}
+
+func affected(result sql.Result) error {
+	count, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("lists: %w", err)
+	}
+
+	if count == 0 {
+		return ErrNoItem
+	}
+
+	return nil
+}
@@ -0,0 +1,291 @@
+package lists
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/m4tthewde/blunt/catalog"
+	"github.com/m4tthewde/blunt/database"
+	"github.com/m4tthewde/blunt/users"
+)
+
+// fixture is a list of alice with bob collaborating on it, while carol is
+// a stranger.
+type fixture struct {
+	store             *Store
+	alice, bob, carol int64
+	list              int64
+	item              int64
+}
+
+func newFixture(t *testing.T) fixture {
+	t.Helper()
+
+	ctx := context.Background()
+
+	db, err := database.Open(ctx, filepath.Join(t.TempDir(), "blunt.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	accounts := users.NewStore(db)
+	f := fixture{store: NewStore(db)}
+
+	for _, account := range []struct {
+		name string
+		id   *int64
+	}{{"alice", &f.alice}, {"bob", &f.bob}, {"carol", &f.carol}} {
+		user, err := accounts.Ensure(ctx, account.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		*account.id = user.Id
+	}
+
+	f.list, err = f.store.Create(ctx, f.alice, "Heists", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = f.store.AddMember(ctx, f.alice, f.list, "bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = f.store.AddItem(ctx, f.alice, f.list, Item{Kind: catalog.Movie, TmdbId: 949, Title: "Heat"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	items, err := f.store.Items(ctx, f.list)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.item = items[0].Id
+
+	return f
+}
+
+func TestAuthorization(t *testing.T) {
+	ctx := context.Background()
+
+	item := Item{Kind: catalog.Movie, TmdbId: 2001, Title: "Ronin"}
+
+	tests := []struct {
+		name   string
+		change func(f fixture, user int64) error
+		// errors of alice, bob and carol
+		want [3]error
+	}{
+		{
+			name:   "rename",
+			change: func(f fixture, user int64) error { return f.store.Update(ctx, user, f.list, "Capers", "") },
+			want:   [3]error{nil, ErrNotOwner, ErrNotFound},
+		},
+		{
+			name:   "share",
+			change: func(f fixture, user int64) error { return f.store.Share(ctx, user, f.list) },
+			want:   [3]error{nil, ErrNotOwner, ErrNotFound},
+		},
+		{
+			name:   "unshare",
+			change: func(f fixture, user int64) error { return f.store.Unshare(ctx, user, f.list) },
+			want:   [3]error{nil, ErrNotOwner, ErrNotFound},
+		},
+		{
+			name:   "add member",
+			change: func(f fixture, user int64) error { return f.store.AddMember(ctx, user, f.list, "carol") },
+			want:   [3]error{nil, ErrNotOwner, ErrNotFound},
+		},
+		{
+			name:   "delete",
+			change: func(f fixture, user int64) error { return f.store.Delete(ctx, user, f.list) },
+			want:   [3]error{nil, ErrNotOwner, ErrNotFound},
+		},
+		{
+			name:   "add item",
+			change: func(f fixture, user int64) error { return f.store.AddItem(ctx, user, f.list, item) },
+			want:   [3]error{nil, nil, ErrNotFound},
+		},
+		{
+			name:   "remove item",
+			change: func(f fixture, user int64) error { return f.store.RemoveItem(ctx, user, f.list, f.item) },
+			want:   [3]error{nil, nil, ErrNotFound},
+		},
+		{
+			name:   "set note",
+			change: func(f fixture, user int64) error { return f.store.SetNote(ctx, user, f.list, f.item, "Diner scene") },
+			want:   [3]error{nil, nil, ErrNotFound},
+		},
+		{
+			name:   "move item",
+			change: func(f fixture, user int64) error { return f.store.MoveItem(ctx, user, f.list, f.item, false) },
+			want:   [3]error{nil, nil, ErrNotFound},
+		},
+		{
+			name:   "remove bob",
+			change: func(f fixture, user int64) error { return f.store.RemoveMember(ctx, user, f.list, f.bob) },
+			want:   [3]error{nil, nil, ErrNotFound},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for i, name := range []string{"alice", "bob", "carol"} {
+				// every user gets a fresh list, so earlier changes don't
+				// get in the way
+				f := newFixture(t)
+				user := [3]int64{f.alice, f.bob, f.carol}[i]
+
+				err := test.change(f, user)
+				if !errors.Is(err, test.want[i]) {
+					t.Errorf("%s: error %v, want %v", name, err, test.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestList(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture(t)
+
+	for _, test := range []struct {
+		name string
+		user int64
+		role Role
+		err  error
+	}{
+		{"alice", f.alice, Owner, nil},
+		{"bob", f.bob, Collaborator, nil},
+		{"carol", f.carol, Viewer, ErrNotFound},
+	} {
+		list, err := f.store.List(ctx, test.user, f.list)
+		if !errors.Is(err, test.err) {
+			t.Errorf("%s: error %v, want %v", test.name, err, test.err)
+			continue
+		}
+
+		if err == nil && list.Role != test.role {
+			t.Errorf("%s: role %d, want %d", test.name, list.Role, test.role)
+		}
+	}
+
+	lists, err := f.store.Lists(ctx, f.carol)
+	if err != nil || len(lists) != 0 {
+		t.Errorf("carol sees %v, %v", lists, err)
+	}
+}
+
+func TestItemsOfOtherLists(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture(t)
+
+	// bob's own list doesn't let him touch the items of alice's
+	own, err := f.store.Create(ctx, f.bob, "Mine", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = f.store.RemoveItem(ctx, f.bob, own, f.item)
+	if !errors.Is(err, ErrNoItem) {
+		t.Errorf("remove: error %v, want no item", err)
+	}
+
+	err = f.store.SetNote(ctx, f.bob, own, f.item, "Mine now")
+	if !errors.Is(err, ErrNoItem) {
+		t.Errorf("set note: error %v, want no item", err)
+	}
+
+	err = f.store.MoveItem(ctx, f.bob, own, f.item, true)
+	if !errors.Is(err, ErrNoItem) {
+		t.Errorf("move: error %v, want no item", err)
+	}
+}
+
+func TestShareLinks(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture(t)
+
+	err := f.store.Share(ctx, f.alice, f.list)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := f.store.List(ctx, f.alice, f.list)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token := list.ShareToken
+
+	shared, err := f.store.Shared(ctx, token)
+	if err != nil || shared.Id != f.list || shared.Role != Viewer || shared.Role.CanEdit() {
+		t.Fatalf("shared list %+v, %v", shared, err)
+	}
+
+	// sharing again keeps the link
+	err = f.store.Share(ctx, f.alice, f.list)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = f.store.Shared(ctx, token)
+	if err != nil {
+		t.Errorf("link after sharing again: %v", err)
+	}
+
+	// the link doesn't make carol a collaborator
+	err = f.store.AddItem(ctx, f.carol, f.list, Item{Kind: catalog.Movie, TmdbId: 2001, Title: "Ronin"})
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("carol added an item: %v", err)
+	}
+
+	err = f.store.Unshare(ctx, f.alice, f.list)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = f.store.Shared(ctx, token)
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("revoked link: error %v", err)
+	}
+}
+
+func TestMembers(t *testing.T) {
+	ctx := context.Background()
+	f := newFixture(t)
+
+	err := f.store.AddMember(ctx, f.alice, f.list, "alice")
+	if !errors.Is(err, ErrNoUser) {
+		t.Errorf("adding the owner: error %v", err)
+	}
+
+	err = f.store.AddMember(ctx, f.alice, f.list, "mallory")
+	if !errors.Is(err, ErrNoUser) {
+		t.Errorf("adding an unknown user: error %v", err)
+	}
+
+	err = f.store.AddMember(ctx, f.alice, f.list, "carol")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// collaborators can leave, but not remove each other
+	err = f.store.RemoveMember(ctx, f.bob, f.list, f.carol)
+	if !errors.Is(err, ErrNotOwner) {
+		t.Errorf("bob removed carol: %v", err)
+	}
+
+	err = f.store.RemoveMember(ctx, f.carol, f.list, f.carol)
+	if err != nil {
+		t.Errorf("carol couldn't leave: %v", err)
+	}
+
+	_, err = f.store.List(ctx, f.carol, f.list)
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("carol still sees the list: %v", err)
+	}
+}
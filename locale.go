@@ -0,0 +1,74 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/m4tthewde/blunt/i18n"
+	"github.com/m4tthewde/blunt/tmdb"
+	"golang.org/x/text/language"
+)
+
+const languageCookie = "lang"
+
+// localize picks the content and UI language of a request. A `lang` query
+// parameter is remembered in a cookie and wins over Accept-Language, which
+// wins over the configured default.
+func localize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		preferred := make([]language.Tag, 0)
+
+		lang := r.URL.Query().Get("lang")
+		if lang != "" {
+			tag, err := language.Parse(lang)
+			if err == nil {
+				preferred = append(preferred, tag)
+
+				http.SetCookie(w, &http.Cookie{
+					Name:     languageCookie,
+					Value:    tag.String(),
+					Path:     "/",
+					MaxAge:   int((365 * 24 * time.Hour).Seconds()),
+					HttpOnly: true,
+					SameSite: http.SameSiteLaxMode,
+				})
+			}
+		}
+
+		cookie, err := r.Cookie(languageCookie)
+		if len(preferred) == 0 && err == nil {
+			tag, err := language.Parse(cookie.Value)
+			if err == nil {
+				preferred = append(preferred, tag)
+			}
+		}
+
+		if len(preferred) == 0 {
+			tags, _, err := language.ParseAcceptLanguage(r.Header.Get("Accept-Language"))
+			if err == nil {
+				preferred = append(preferred, tags...)
+			}
+		}
+
+		locale := tmdb.Locale{Language: config.Tmdb.Language, Region: config.Tmdb.Region}
+
+		if len(preferred) != 0 {
+			locale.Language = preferred[0].String()
+
+			region, confidence := preferred[0].Region()
+			if confidence == language.Exact {
+				locale.Region = region.String()
+			}
+		} else {
+			tag, err := language.Parse(config.Tmdb.Language)
+			if err == nil {
+				preferred = append(preferred, tag)
+			}
+		}
+
+		ctx := i18n.WithLanguage(r.Context(), i18n.Match(preferred...))
+		ctx = tmdb.WithLocale(ctx, locale)
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,69 @@
+// Package logging sets up structured logging and ties log records to the
+// request they belong to.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+)
+
+const (
+	FormatText = "text"
+	FormatJson = "json"
+)
+
+type requestIdKey struct{}
+
+// WithRequestId returns a context whose log records carry id.
+func WithRequestId(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIdKey{}, id)
+}
+
+// RequestId returns the id of the request ctx belongs to, if any.
+func RequestId(ctx context.Context) string {
+	id, _ := ctx.Value(requestIdKey{}).(string)
+
+	return id
+}
+
+// New creates a logger writing text or JSON records to w. Records logged with
+// a request context get a request_id attribute.
+func New(w io.Writer, format string, level slog.Level) (*slog.Logger, error) {
+	options := slog.HandlerOptions{Level: level}
+
+	var handler slog.Handler
+
+	switch format {
+	case FormatText:
+		handler = slog.NewTextHandler(w, &options)
+	case FormatJson:
+		handler = slog.NewJSONHandler(w, &options)
+	default:
+		return nil, fmt.Errorf("logging: %q is not one of text or json", format)
+	}
+
+	return slog.New(contextHandler{handler}), nil
+}
+
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	id := RequestId(ctx)
+	if id != "" {
+		record.AddAttrs(slog.String("request_id", id))
+	}
+
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
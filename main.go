@@ -2,176 +2,507 @@ package main
 
 import (
 	"cmp"
+	"context"
+	"errors"
+	"flag"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
 	"slices"
-	"time"
+	"syscall"
 
 	"github.com/a-h/templ"
 	"github.com/google/uuid"
+	"github.com/m4tthewde/blunt/api"
+	"github.com/m4tthewde/blunt/catalog"
 	"github.com/m4tthewde/blunt/components"
+	"github.com/m4tthewde/blunt/csrf"
+	"github.com/m4tthewde/blunt/database"
+	"github.com/m4tthewde/blunt/follow"
+	"github.com/m4tthewde/blunt/gql"
+	"github.com/m4tthewde/blunt/imageproxy"
+	"github.com/m4tthewde/blunt/library"
+	"github.com/m4tthewde/blunt/lists"
+	"github.com/m4tthewde/blunt/logging"
+	"github.com/m4tthewde/blunt/metrics"
+	"github.com/m4tthewde/blunt/ratelimit"
+	"github.com/m4tthewde/blunt/recommend"
+	"github.com/m4tthewde/blunt/static"
 	"github.com/m4tthewde/blunt/tmdb"
-	"gopkg.in/yaml.v3"
+	"github.com/m4tthewde/blunt/tracing"
+	"github.com/m4tthewde/blunt/transfer"
+	"github.com/m4tthewde/blunt/users"
+	"github.com/m4tthewde/blunt/watch"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
 )
 
-type Config struct {
-	Token string `yaml:"token"`
-}
+var client *tmdb.Client
+
+// limiter is nil when clients aren't rate limited.
+var limiter *ratelimit.Limiter
 
-var config Config
+var (
+	accounts     *users.Store
+	auth         *users.Auth
+	movieLibrary *library.Store
+)
+
+var tracer = otel.Tracer("github.com/m4tthewde/blunt")
 
 func main() {
-	data, err := os.ReadFile("config.yaml")
+	var args []string
+	var err error
+
+	config, args, err = loadConfig(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		fmt.Print(usage)
+		return
+	}
 	if err != nil {
-		log.Fatalln(err)
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
 	}
 
-	err = yaml.Unmarshal(data, &config)
+	// SIGINT and SIGTERM shut the server down gracefully, bounded by
+	// server.shutdown_timeout
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	err = run(ctx, args)
 	if err != nil {
-		log.Fatalln(err)
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func serve(ctx context.Context) error {
+	err := static.Check()
+	if err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	ready := readiness{}
+
+	rateLimits, err := config.RateLimitOptions()
+	if err != nil {
+		return err
+	}
+
+	if rateLimits != nil {
+		limiter = ratelimit.New(*rateLimits)
+	}
+
+	db, err := database.Open(ctx, config.Database)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	accounts = users.NewStore(db)
+	auth = users.NewAuth(accounts, config.AuthOptions())
+	movieLibrary = library.NewStore(db)
+	userLists = lists.NewStore(db)
+	recommender = recommend.New(client)
+	importIssues = transfer.NewStore(db)
+	importer = transfer.NewImporter(ctx, client, movieLibrary, importIssues)
+	// imports stop with ctx, they record how far they got before the
+	// database closes
+	defer importer.Wait()
+	follows = follow.NewStore(db)
+	watchServices = watch.NewStore(db)
+
+	if config.Follow.Webhooks {
+		webhookSender = follow.NewSender()
+	}
+
+	go follow.NewWatcher(follows, client, webhookSender, config.Follow.Interval).Run(ctx)
+
+	mux.Handle("GET /{$}", templ.Handler(components.Index()))
+	mux.Handle("POST /search", limit(ratelimit.Search, search))
+	mux.Handle("GET /movie/{id}", limit(ratelimit.Detail, movie))
+	mux.Handle("GET /castMember/{id}", limit(ratelimit.Detail, castMember))
+	mux.Handle("GET /castMember/{id}/graph", limit(ratelimit.Graph, castMemberGraph))
+	mux.Handle("GET /castMember/{id}/releases.ics", limit(ratelimit.Graph, castMemberReleases))
+	mux.Handle("GET /releases.ics", limit(ratelimit.Graph, movieReleases))
+	mux.Handle("GET /movie/{id}/graph", limit(ratelimit.Graph, movieGraph))
+	mux.Handle("POST /subGraph/movie/{id}", limit(ratelimit.Graph, subGraphMovie))
+	mux.Handle("POST /subGraph/person/{id}", limit(ratelimit.Graph, subGraphPerson))
+	mux.HandleFunc("GET /img/placeholder.svg", imageproxy.Placeholder)
+	mux.Handle("GET /me/watchlist", requireUser(watchlist))
+	mux.Handle("POST /me/watchlist/{id}", limit(ratelimit.Detail, requireUser(addToWatchlist)))
+	mux.Handle("DELETE /me/watchlist/{id}", requireUser(removeFromWatchlist))
+	mux.Handle("GET /me/history", requireUser(history))
+	mux.Handle("GET /me/recommendations", limit(ratelimit.Graph, requireUser(recommendations)))
+	mux.Handle("POST /me/history/{id}", limit(ratelimit.Detail, requireUser(addViewing)))
+	mux.Handle("DELETE /me/history/viewing/{id}", requireUser(deleteViewing))
+	mux.Handle("GET /me/import", requireUser(importPage))
+	mux.Handle("POST /me/import", limit(ratelimit.Graph, requireUser(startImport)))
+	mux.Handle("GET /me/import/status", requireUser(importStatus))
+	mux.Handle("GET /me/import/issues", requireUser(importIssueList))
+	mux.Handle("POST /me/import/issues/{id}", limit(ratelimit.Detail, requireUser(resolveIssue)))
+	mux.Handle("DELETE /me/import/issues/{id}", requireUser(dismissIssue))
+	mux.Handle("GET /me/export/{file}", limit(ratelimit.Detail, requireUser(export)))
+	mux.Handle("GET /me/settings", limit(ratelimit.Detail, requireUser(settings)))
+	mux.Handle("POST /me/settings", limit(ratelimit.Detail, requireUser(saveSettings)))
+	mux.Handle("GET /me/lists", requireUser(listsPage))
+	mux.Handle("POST /me/lists", requireUser(createList))
+	mux.Handle("POST /me/lists/add", limit(ratelimit.Detail, requireUser(addToList)))
+	mux.Handle("GET /me/lists/{id}", requireUser(listPage))
+	mux.Handle("POST /me/lists/{id}", requireUser(updateList))
+	mux.Handle("DELETE /me/lists/{id}", requireUser(deleteList))
+	mux.Handle("DELETE /me/lists/{id}/items/{item}", requireUser(removeListItem))
+	mux.Handle("POST /me/lists/{id}/items/{item}/note", requireUser(setListNote))
+	mux.Handle("POST /me/lists/{id}/items/{item}/move", requireUser(moveListItem))
+	mux.Handle("POST /me/lists/{id}/share", requireUser(shareList))
+	mux.Handle("DELETE /me/lists/{id}/share", requireUser(unshareList))
+	mux.Handle("POST /me/lists/{id}/members", requireUser(addListMember))
+	mux.Handle("DELETE /me/lists/{id}/members/{user}", requireUser(removeListMember))
+	mux.Handle("GET /me/lists/{id}/export/{format}", requireUser(exportList))
+	mux.Handle("GET /me/lists/{id}/graph", limit(ratelimit.Graph, requireUser(listGraph)))
+	mux.Handle("GET /lists/{token}", limit(ratelimit.Detail, sharedListPage))
+	mux.Handle("GET /lists/{token}/export/{format}", limit(ratelimit.Detail, exportSharedList))
+	mux.Handle("GET /lists/{token}/graph", limit(ratelimit.Graph, sharedListGraph))
+	mux.Handle("POST /me/follows/{id}", limit(ratelimit.Detail, requireUser(followPerson)))
+	mux.Handle("DELETE /me/follows/{id}", requireUser(unfollowPerson))
+	mux.Handle("GET /me/notifications", requireUser(notifications))
+	mux.Handle("POST /me/notifications/read", requireUser(markNotificationsRead))
+	mux.Handle("POST /me/notifications/feed", requireUser(resetFeed))
+	mux.Handle("GET /feeds/{file}", limit(ratelimit.Detail, feed))
+
+	if webhookSender != nil {
+		mux.Handle("POST /me/webhooks", requireUser(addWebhook))
+		mux.Handle("DELETE /me/webhooks/{id}", requireUser(deleteWebhook))
+		mux.Handle("POST /me/webhooks/{id}/test", limit(ratelimit.Detail, requireUser(testWebhook)))
+	}
+
+	if config.Auth.Mode == users.ModeLocal {
+		mux.HandleFunc("GET /login", loginPage)
+		mux.Handle("POST /login", limit(ratelimit.Login, login))
+		mux.HandleFunc("POST /logout", logout)
+
+		if config.Auth.Registration {
+			mux.HandleFunc("GET /register", registerPage)
+			mux.Handle("POST /register", limit(ratelimit.Login, register))
+		}
+	}
+
+	mux.HandleFunc("GET /static/", static.Handler)
+	mux.Handle("/graphql", gql.New(client, gql.Options{
+		MaxDepth:      config.GraphQL.MaxDepth,
+		MaxComplexity: config.GraphQL.MaxComplexity,
+		Limiter:       limiter,
+	}))
+	mux.Handle(api.Prefix+"/", api.New(client, api.Limits{MaxDepth: config.Graph.MaxDepth, MaxFanOut: config.Graph.FanOut, MaxLookups: config.Graph.MaxLookups}, limiter))
+	mux.HandleFunc("GET /healthz", ready.live)
+	mux.HandleFunc("GET /readyz", ready.ready)
+
+	if config.Metrics.Enabled {
+		mux.Handle("GET /metrics", metrics.Handler())
+	}
+
+	if config.Images.Proxy {
+		proxy, err := imageproxy.New(imageproxy.Options{
+			CacheDir:      config.Images.CacheDir,
+			MaxCacheBytes: config.Images.MaxCacheMB << 20,
+			Resize:        config.Images.Resize,
+			WebP:          config.Images.WebP,
+		})
+		if err != nil {
+			return err
+		}
+
+		mux.Handle("GET /img/{size}/{path}", limit(ratelimit.Images, proxy.ServeHTTP))
+		tmdb.UseImageProxy("/img/", config.Images.Resize)
 	}
 
-	http.Handle("/", templ.Handler(components.Index()))
-	http.HandleFunc("/search", search)
-	http.HandleFunc("GET /movie/{id}", movie)
-	http.HandleFunc("GET /castMember/{id}", castMember)
-	http.HandleFunc("GET /castMember/{id}/graph", castMemberGraph)
-	http.HandleFunc("GET /movie/{id}/graph", movieGraph)
-	http.HandleFunc("POST /subGraph/movie/{id}", subGraphMovie)
-	http.HandleFunc("POST /subGraph/person/{id}", subGraphPerson)
+	// scripts use the API and GraphQL without cookies, so without a token
+	protection := csrf.Options{
+		TokenExempt:   []string{"/graphql", api.Prefix + "/"},
+		SecureCookies: config.Server.SecureCookies,
+	}
 
-	log.Println("Starting server on port 8080")
-	http.ListenAndServe(":8080", nil)
+	handler := logging.Middleware(recoverPanics(secureHeaders(localize(csrf.Middleware(protection, auth.Middleware(tracing.Middleware(metrics.Middleware(mux))))))))
+
+	return listen(ctx, handler, &ready)
+}
+
+// serverError logs err and answers with a 500. Requests cancelled by the
+// client aren't worth a log record. Movies and people TMDB doesn't know, or
+// family mode hides, are not found rather than a failure.
+func serverError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+
+	if errors.Is(err, tmdb.ErrNotFound) {
+		slog.DebugContext(r.Context(), "not found", "method", r.Method, "path", r.URL.Path, "error", err)
+		http.NotFound(w, r)
+		return
+	}
+
+	slog.ErrorContext(r.Context(), "request failed", "method", r.Method, "path", r.URL.Path, "error", err)
+	w.WriteHeader(500)
+}
+
+func render(w http.ResponseWriter, r *http.Request, component templ.Component) {
+	ctx, span := tracer.Start(r.Context(), "render "+r.Pattern)
+	defer span.End()
+
+	err := component.Render(ctx, w)
+	if err != nil && !errors.Is(err, context.Canceled) {
+		span.SetStatus(codes.Error, err.Error())
+		slog.ErrorContext(ctx, "rendering failed", "path", r.URL.Path, "error", err)
+	}
 }
 
 func search(w http.ResponseWriter, r *http.Request) {
 	err := r.ParseForm()
 	if err != nil {
-		w.WriteHeader(500)
+		serverError(w, r, err)
 		return
 	}
 
 	search := r.FormValue("search")
 
-	movieResponse, err := tmdb.SearchMovies(r.Context(), config.Token, search)
+	// pasted IMDb and Wikidata ids lead straight to their page
+	externalId, ok := catalog.ParseExternalId(search)
+	if ok {
+		result, found, err := catalog.FindExternal(r.Context(), client, externalId)
+		if err != nil {
+			serverError(w, r, err)
+			return
+		}
+
+		if found {
+			href := resultHref(result)
+
+			if r.Header.Get("HX-Request") == "true" {
+				w.Header().Set("HX-Redirect", href)
+				return
+			}
+
+			http.Redirect(w, r, href, http.StatusSeeOther)
+			return
+		}
+	}
+
+	searchResults, err := searchAll(r.Context(), search)
 	if err != nil {
-		w.WriteHeader(500)
+		serverError(w, r, err)
 		return
 	}
 
-	peopleResponse, err := tmdb.SearchPeople(r.Context(), config.Token, search)
+	filter, services, err := availability(r, r.FormValue("available") != "")
 	if err != nil {
-		w.WriteHeader(500)
+		serverError(w, r, err)
 		return
 	}
 
-	searchResults := make([]components.SearchResult, 0)
+	if filtering(filter) {
+		var checked int
 
-	for _, movieResult := range movieResponse.Results {
-		searchResults = append(searchResults, components.SearchResult{
-			Href:       fmt.Sprintf("/movie/%d", movieResult.Id),
-			ImagePath:  tmdb.BuildPosterPath(movieResult.PosterPath),
-			Name:       movieResult.OriginalTitle,
-			Year:       tmdb.GetReleaseYear(movieResult.ReleaseDate),
-			Popularity: movieResult.Popularity,
-		})
+		searchResults, checked, filter.Unchecked, err = watch.Filter(r.Context(), client, searchResults, searchResultMovie, client.Region(r.Context()), services)
+		limiter.Charge(r, ratelimit.Search, checked)
+		if err != nil {
+			serverError(w, r, err)
+			return
+		}
+	}
+
+	var choices []lists.List
+
+	user := users.FromContext(r.Context())
+	if user != nil {
+		choices, err = userLists.Lists(r.Context(), user.Id)
+		if err != nil {
+			serverError(w, r, err)
+			return
+		}
 	}
 
-	for _, peopleResult := range peopleResponse.Results {
+	render(w, r, components.Search(searchResults, choices, filter))
+}
+
+func searchAll(ctx context.Context, search string) ([]components.SearchResult, error) {
+	results, err := catalog.Search(ctx, client, search)
+	if err != nil {
+		return nil, err
+	}
+
+	searchResults := make([]components.SearchResult, 0)
+
+	for _, result := range results {
 		searchResults = append(searchResults, components.SearchResult{
-			Href:       fmt.Sprintf("/castMember/%d", peopleResult.Id),
-			ImagePath:  tmdb.BuildPosterPath(peopleResult.ProfilePath),
-			Name:       peopleResult.Name,
-			Year:       "",
-			Popularity: peopleResult.Popularity,
+			Kind:         result.Type,
+			Id:           result.Id,
+			Href:         resultHref(result),
+			ImageKind:    result.ImageKind,
+			ImagePath:    result.ImagePath,
+			Name:         result.Name,
+			OriginalName: result.OriginalName,
+			Year:         result.Year,
+			Popularity:   result.Popularity,
 		})
 	}
 
-	slices.SortFunc(searchResults,
-		func(a, b components.SearchResult) int {
-			return cmp.Compare(b.Popularity, a.Popularity)
-		},
-	)
+	return searchResults, nil
+}
+
+// searchResultMovie returns the movie id of a search result, people aren't
+// filtered by availability.
+func searchResultMovie(result components.SearchResult) (int64, bool) {
+	return result.Id, result.Kind == catalog.Movie
+}
+
+func resultHref(result catalog.SearchResult) string {
+	if result.Type == catalog.Person {
+		return fmt.Sprintf("/castMember/%d", result.Id)
+	}
 
-	components.Search(searchResults).Render(r.Context(), w)
+	return fmt.Sprintf("/movie/%d", result.Id)
 }
 
 func movie(w http.ResponseWriter, r *http.Request) {
 	idString := r.PathValue("id")
 
-	movieDetails, err := tmdb.MovieDetails(r.Context(), config.Token, idString)
+	movieDetails, err := client.MovieDetails(r.Context(), idString)
 	if err != nil {
-		w.WriteHeader(500)
+		serverError(w, r, err)
 		return
 	}
 
-	credits, err := tmdb.Credits(r.Context(), config.Token, idString)
+	credits, err := client.Credits(r.Context(), idString)
 	if err != nil {
-		w.WriteHeader(500)
+		serverError(w, r, err)
 		return
 	}
 
-	components.Movie(*movieDetails, credits.Cast).Render(r.Context(), w)
+	externalIds, err := client.MovieExternalIds(r.Context(), idString)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	var status *library.Status
+
+	user := users.FromContext(r.Context())
+	if user != nil {
+		userStatus, err := movieLibrary.Status(r.Context(), user.Id, movieDetails.Id)
+		if err != nil {
+			serverError(w, r, err)
+			return
+		}
+
+		status = &userStatus
+	}
+
+	choice, err := listChoice(r, catalog.Movie, movieDetails.Id)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	releaseDates, err := client.ReleaseDates(r.Context(), idString)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	providers, err := client.WatchProviders(r.Context(), idString)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	region := client.Region(r.Context())
+	releases := releaseDates.Country(region)
+	slices.SortFunc(releases, func(a, b tmdb.ReleaseDate) int {
+		return cmp.Compare(a.ReleaseDate, b.ReleaseDate)
+	})
+
+	links := catalog.ExternalLinks(catalog.Movie, movieDetails.Id, externalIds)
+
+	render(w, r, components.Movie(*movieDetails, credits.Cast, links, status, choice, region, releases, providers.Results[region]))
 }
 
 func castMember(w http.ResponseWriter, r *http.Request) {
 	idString := r.PathValue("id")
 
-	people, err := tmdb.People(r.Context(), config.Token, idString)
+	people, err := client.People(r.Context(), idString)
 	if err != nil {
-		w.WriteHeader(500)
+		serverError(w, r, err)
 		return
 	}
 
-	peopleCredits, err := tmdb.PeopleCredits(r.Context(), config.Token, idString)
+	peopleCredits, err := client.PeopleCredits(r.Context(), idString)
 	if err != nil {
-		w.WriteHeader(500)
+		serverError(w, r, err)
 		return
 	}
 
-	cast := make([]tmdb.PeopleCredit, 0)
-	for _, c := range peopleCredits.Cast {
-		if c.ReleaseDate != "" {
-			cast = append(cast, c)
-		}
+	externalIds, err := client.PersonExternalIds(r.Context(), idString)
+	if err != nil {
+		serverError(w, r, err)
+		return
 	}
 
-	slices.SortFunc(cast,
-		func(a, b tmdb.PeopleCredit) int {
-			timeA, err := time.Parse(time.DateOnly, a.ReleaseDate)
-			if err != nil {
-				return 0
-			}
-			timeB, err := time.Parse(time.DateOnly, b.ReleaseDate)
-			if err != nil {
-				return 0
-			}
+	choice, err := listChoice(r, catalog.Person, people.Id)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
 
-			if timeA.Before(timeB) {
-				return 1
-			}
+	following, err := followState(r, people.Id)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
 
-			return -1
-		},
-	)
+	filter, services, err := availability(r, r.URL.Query().Get("available") != "")
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	cast := catalog.ReleasedCredits(peopleCredits.Cast)
+
+	if filtering(filter) {
+		var checked int
 
-	components.CastMember(*people, cast).Render(r.Context(), w)
+		cast, checked, filter.Unchecked, err = watch.Filter(r.Context(), client, cast,
+			func(credit tmdb.PeopleCredit) (int64, bool) { return credit.Id, true },
+			client.Region(r.Context()), services,
+		)
+		limiter.Charge(r, ratelimit.Detail, checked)
+		if err != nil {
+			serverError(w, r, err)
+			return
+		}
+	}
+
+	links := catalog.ExternalLinks(catalog.Person, people.Id, externalIds)
+
+	render(w, r, components.CastMember(*people, cast, peopleCredits.Pending, links, choice, following, filter))
 }
 
 func castMemberGraph(w http.ResponseWriter, r *http.Request) {
+	defer metrics.TrackGraph()()
+
 	idString := r.PathValue("id")
 
-	person, err := tmdb.People(r.Context(), config.Token, idString)
+	person, err := client.People(r.Context(), idString)
 	if err != nil {
-		w.WriteHeader(500)
+		serverError(w, r, err)
 		return
 	}
 
-	credits, err := tmdb.PeopleCredits(r.Context(), config.Token, idString)
+	credits, err := client.PeopleCredits(r.Context(), idString)
 	if err != nil {
-		w.WriteHeader(500)
+		serverError(w, r, err)
 		return
 	}
 
@@ -183,10 +514,11 @@ func castMemberGraph(w http.ResponseWriter, r *http.Request) {
 
 	children := make([]components.GraphElement, 0)
 
-	for _, credit := range credits.Cast[0:5] {
+	for _, credit := range credits.Cast[:min(config.Graph.FanOut, len(credits.Cast))] {
 		graphElement := components.GraphElement{
 			Id:        credit.Id,
-			ImagePath: tmdb.BuildPosterPath(credit.PosterPath),
+			ImageKind: tmdb.PosterImage,
+			ImagePath: credit.PosterPath,
 		}
 
 		children = append(children, graphElement)
@@ -194,33 +526,37 @@ func castMemberGraph(w http.ResponseWriter, r *http.Request) {
 
 	parent := components.GraphElement{
 		Id:        person.Id,
-		ImagePath: tmdb.BuildPosterPath(person.ProfilePath),
+		ImageKind: tmdb.ProfileImage,
+		ImagePath: person.ProfilePath,
 	}
 
-	components.Graph(parent, children, "person", uuid.New().String()).Render(r.Context(), w)
+	render(w, r, components.Graph(parent, children, "person", uuid.New().String()))
 }
 
 func movieGraph(w http.ResponseWriter, r *http.Request) {
+	defer metrics.TrackGraph()()
+
 	idString := r.PathValue("id")
 
-	movie, err := tmdb.MovieDetails(r.Context(), config.Token, idString)
+	movie, err := client.MovieDetails(r.Context(), idString)
 	if err != nil {
-		w.WriteHeader(500)
+		serverError(w, r, err)
 		return
 	}
 
-	credits, err := tmdb.Credits(r.Context(), config.Token, idString)
+	credits, err := client.Credits(r.Context(), idString)
 	if err != nil {
-		w.WriteHeader(500)
+		serverError(w, r, err)
 		return
 	}
 
 	children := make([]components.GraphElement, 0)
 
-	for _, credit := range credits.Cast[0:5] {
+	for _, credit := range credits.Cast[:min(config.Graph.FanOut, len(credits.Cast))] {
 		graphElement := components.GraphElement{
 			Id:        credit.Id,
-			ImagePath: tmdb.BuildPosterPath(credit.ProfilePath),
+			ImageKind: tmdb.ProfileImage,
+			ImagePath: credit.ProfilePath,
 		}
 
 		children = append(children, graphElement)
@@ -228,41 +564,47 @@ func movieGraph(w http.ResponseWriter, r *http.Request) {
 
 	parent := components.GraphElement{
 		Id:        movie.Id,
-		ImagePath: tmdb.BuildPosterPath(movie.PosterPath),
+		ImageKind: tmdb.PosterImage,
+		ImagePath: movie.PosterPath,
 	}
 
-	components.Graph(parent, children, "movie", uuid.New().String()).Render(r.Context(), w)
+	render(w, r, components.Graph(parent, children, "movie", uuid.New().String()))
 }
 
 func subGraphMovie(w http.ResponseWriter, r *http.Request) {
+	defer metrics.TrackGraph()()
+
 	id := r.PathValue("id")
 
-	credits, err := tmdb.Credits(r.Context(), config.Token, id)
+	credits, err := client.Credits(r.Context(), id)
 	if err != nil {
-		w.WriteHeader(500)
+		serverError(w, r, err)
 		return
 	}
 
 	children := make([]components.GraphElement, 0)
 
-	for _, credit := range credits.Cast[:5] {
+	for _, credit := range credits.Cast[:min(config.Graph.FanOut, len(credits.Cast))] {
 		graphElement := components.GraphElement{
 			Id:        credit.Id,
-			ImagePath: tmdb.BuildPosterPath(credit.ProfilePath),
+			ImageKind: tmdb.ProfileImage,
+			ImagePath: credit.ProfilePath,
 		}
 
 		children = append(children, graphElement)
 	}
 
-	components.SubGraph(children, "person", credits.Id, uuid.New().String()).Render(r.Context(), w)
+	render(w, r, components.SubGraph(children, "person", credits.Id, uuid.New().String()))
 }
 
 func subGraphPerson(w http.ResponseWriter, r *http.Request) {
+	defer metrics.TrackGraph()()
+
 	id := r.PathValue("id")
 
-	credits, err := tmdb.PeopleCredits(r.Context(), config.Token, id)
+	credits, err := client.PeopleCredits(r.Context(), id)
 	if err != nil {
-		w.WriteHeader(500)
+		serverError(w, r, err)
 		return
 	}
 
@@ -274,14 +616,15 @@ func subGraphPerson(w http.ResponseWriter, r *http.Request) {
 
 	children := make([]components.GraphElement, 0)
 
-	for _, credit := range credits.Cast[:5] {
+	for _, credit := range credits.Cast[:min(config.Graph.FanOut, len(credits.Cast))] {
 		graphElement := components.GraphElement{
 			Id:        credit.Id,
-			ImagePath: tmdb.BuildPosterPath(credit.PosterPath),
+			ImageKind: tmdb.PosterImage,
+			ImagePath: credit.PosterPath,
 		}
 
 		children = append(children, graphElement)
 	}
 
-	components.SubGraph(children, "movie", credits.Id, uuid.New().String()).Render(r.Context(), w)
+	render(w, r, components.SubGraph(children, "movie", credits.Id, uuid.New().String()))
 }
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/m4tthewde/blunt/components"
+	"github.com/m4tthewde/blunt/library"
+	"github.com/m4tthewde/blunt/recommend"
+	"github.com/m4tthewde/blunt/users"
+)
+
+var recommender *recommend.Recommender
+
+const maxRecommendations = 20
+
+// requireUser sends anonymous visitors to the login page, or turns them away
+// when the reverse proxy handles logins.
+func requireUser(next func(w http.ResponseWriter, r *http.Request, user *users.User)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := users.FromContext(r.Context())
+		if user != nil {
+			next(w, r, user)
+			return
+		}
+
+		if config.Auth.Mode != users.ModeLocal {
+			http.Error(w, "not signed in", http.StatusUnauthorized)
+			return
+		}
+
+		loginUrl := "/login?next=" + url.QueryEscape(r.URL.RequestURI())
+
+		// htmx would swap the login page into the target otherwise
+		if r.Header.Get("HX-Request") == "true" {
+			loginUrl = "/login"
+
+			current, err := url.Parse(r.Header.Get("HX-Current-URL"))
+			if err == nil {
+				loginUrl += "?next=" + url.QueryEscape(current.RequestURI())
+			}
+
+			w.Header().Set("HX-Redirect", loginUrl)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		http.Redirect(w, r, loginUrl, http.StatusSeeOther)
+	}
+}
+
+func pathMovieId(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || id < 1 {
+		http.Error(w, "invalid id", http.StatusBadRequest)
+		return 0, false
+	}
+
+	return id, true
+}
+
+// libraryPanel answers htmx requests of a movie page with its updated panel.
+func libraryPanel(w http.ResponseWriter, r *http.Request, user *users.User, movieId int64) {
+	status, err := movieLibrary.Status(r.Context(), user.Id, movieId)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	render(w, r, components.LibraryPanel(movieId, &status))
+}
+
+func addToWatchlist(w http.ResponseWriter, r *http.Request, user *users.User) {
+	id, ok := pathMovieId(w, r)
+	if !ok {
+		return
+	}
+
+	details, err := client.MovieDetails(r.Context(), strconv.FormatInt(id, 10))
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	err = movieLibrary.AddToWatchlist(r.Context(), user.Id, library.NewMovie(details))
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	libraryPanel(w, r, user, id)
+}
+
+func removeFromWatchlist(w http.ResponseWriter, r *http.Request, user *users.User) {
+	id, ok := pathMovieId(w, r)
+	if !ok {
+		return
+	}
+
+	err := movieLibrary.RemoveFromWatchlist(r.Context(), user.Id, id)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	libraryPanel(w, r, user, id)
+}
+
+func addViewing(w http.ResponseWriter, r *http.Request, user *users.User) {
+	id, ok := pathMovieId(w, r)
+	if !ok {
+		return
+	}
+
+	date, err := time.Parse(library.DateLayout, r.PostFormValue("date"))
+	if err != nil {
+		http.Error(w, "invalid date", http.StatusBadRequest)
+		return
+	}
+
+	rating, err := strconv.Atoi(r.PostFormValue("rating"))
+	if err != nil {
+		http.Error(w, "invalid rating", http.StatusBadRequest)
+		return
+	}
+
+	details, err := client.MovieDetails(r.Context(), strconv.FormatInt(id, 10))
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	err = movieLibrary.AddViewing(r.Context(), user.Id, library.NewMovie(details), date, rating)
+	if errors.Is(err, library.ErrInvalidRating) || errors.Is(err, library.ErrInvalidDate) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	libraryPanel(w, r, user, id)
+}
+
+// deleteViewing answers the movie page with its updated panel, the history
+// page just drops the entry.
+func deleteViewing(w http.ResponseWriter, r *http.Request, user *users.User) {
+	id, ok := pathMovieId(w, r)
+	if !ok {
+		return
+	}
+
+	movieId, err := movieLibrary.DeleteViewing(r.Context(), user.Id, id)
+	if errors.Is(err, library.ErrNotFound) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	if r.Header.Get("HX-Target") == "library" {
+		libraryPanel(w, r, user, movieId)
+	}
+}
+
+func watchlist(w http.ResponseWriter, r *http.Request, user *users.User) {
+	query := libraryQuery(r)
+
+	entries, err := movieLibrary.Watchlist(r.Context(), user.Id, query)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	render(w, r, components.Watchlist(entries, query))
+}
+
+func history(w http.ResponseWriter, r *http.Request, user *users.User) {
+	query := libraryQuery(r)
+
+	viewings, err := movieLibrary.History(r.Context(), user.Id, query)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	render(w, r, components.History(viewings, library.NewStats(viewings), query))
+}
+
+// libraryQuery reads the filter form of the watchlist and history pages.
+// Invalid values are ignored, the form shows what was actually applied.
+func libraryQuery(r *http.Request) library.Query {
+	values := r.URL.Query()
+
+	query := library.Query{
+		Sort:   values.Get("sort"),
+		Search: strings.TrimSpace(values.Get("q")),
+	}
+
+	// newest first, but titles alphabetically
+	query.Descending = query.Sort != "title"
+
+	switch values.Get("order") {
+	case "asc":
+		query.Descending = false
+	case "desc":
+		query.Descending = true
+	}
+
+	year, err := strconv.Atoi(values.Get("year"))
+	if err == nil && year > 0 && year < 10000 {
+		query.Year = year
+	}
+
+	rating, err := strconv.Atoi(values.Get("rating"))
+	if err == nil && rating >= 1 && rating <= 10 {
+		query.MinRating = rating
+	}
+
+	return query
+}
+
+func recommendations(w http.ResponseWriter, r *http.Request, user *users.User) {
+	viewings, err := movieLibrary.History(r.Context(), user.Id, library.Query{Sort: "watched"})
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	suggestions, err := recommender.Recommend(r.Context(), viewings, maxRecommendations)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	render(w, r, components.Recommendations(suggestions, len(viewings) != 0))
+}
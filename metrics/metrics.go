@@ -0,0 +1,90 @@
+// Package metrics exposes Prometheus metrics of the web server at /metrics.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	requests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "blunt_http_requests_total",
+		Help: "HTTP requests by route pattern, method and status code.",
+	}, []string{"route", "method", "status"})
+
+	requestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "blunt_http_request_duration_seconds",
+		Help:    "Time to answer HTTP requests by route pattern and method.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"route", "method"})
+
+	graphSessions = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "blunt_graph_sessions_in_flight",
+		Help: "Graph pages, graph expansions and path searches currently being computed.",
+	})
+)
+
+// Handler serves the metrics in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// Middleware records the count and latency of requests. It has to wrap the
+// ServeMux directly, so the matched route pattern can be read from the
+// request afterwards.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		next.ServeHTTP(&recorder, r)
+
+		// unmatched paths would give every scanner its own time series
+		route := r.Pattern
+		if route == "" {
+			route = "unmatched"
+		}
+
+		requests.WithLabelValues(route, r.Method, strconv.Itoa(recorder.status)).Inc()
+		requestDuration.WithLabelValues(route, r.Method).Observe(time.Since(start).Seconds())
+	})
+}
+
+// TrackGraph counts a graph computation as in flight until the returned
+// function is called.
+func TrackGraph() func() {
+	graphSessions.Inc()
+
+	return graphSessions.Dec
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(data []byte) (int, error) {
+	r.wroteHeader = true
+
+	return r.ResponseWriter.Write(data)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
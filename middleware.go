@@ -0,0 +1,71 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/m4tthewde/blunt/components"
+	"github.com/m4tthewde/blunt/ratelimit"
+)
+
+// contentSecurityPolicy only allows scripts, styles and images served by the
+// explorer itself, plus TMDB's image host when images aren't proxied.
+func contentSecurityPolicy() string {
+	images := "'self'"
+	if !config.Images.Proxy {
+		images += " https://image.tmdb.org"
+	}
+
+	return strings.Join([]string{
+		"default-src 'self'",
+		"script-src 'self'",
+		"style-src 'self'",
+		"img-src " + images,
+		"connect-src 'self'",
+		"object-src 'none'",
+		"base-uri 'none'",
+		"form-action 'self'",
+		"frame-ancestors 'none'",
+	}, "; ")
+}
+
+func secureHeaders(next http.Handler) http.Handler {
+	policy := contentSecurityPolicy()
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := w.Header()
+		header.Set("Content-Security-Policy", policy)
+		header.Set("X-Content-Type-Options", "nosniff")
+		header.Set("X-Frame-Options", "DENY")
+		header.Set("Referrer-Policy", "same-origin")
+		header.Set("Cross-Origin-Opener-Policy", "same-origin")
+		header.Set("Permissions-Policy", "camera=(), microphone=(), geolocation=()")
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// limit answers with 429 once the client exhausted its budget of class. htmx
+// requests get a message to swap in instead of a whole page.
+func limit(class ratelimit.Class, next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		delay, ok := limiter.Allow(r, class, 1)
+		if ok {
+			next(w, r)
+			return
+		}
+
+		retryAfter := ratelimit.RetryAfter(delay)
+
+		w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+		w.WriteHeader(http.StatusTooManyRequests)
+
+		if r.Header.Get("HX-Request") == "true" {
+			render(w, r, components.TooManyRequestsMessage(retryAfter))
+			return
+		}
+
+		render(w, r, components.TooManyRequests(retryAfter))
+	})
+}
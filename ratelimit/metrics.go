@@ -0,0 +1,18 @@
+package ratelimit
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	rejected = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "blunt_rate_limited_requests_total",
+		Help: "Requests rejected because the client exhausted its budget, by route class.",
+	}, []string{"class"})
+
+	clients = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "blunt_rate_limit_clients",
+		Help: "Clients with rate limit state, as of the last cleanup.",
+	})
+)
@@ -0,0 +1,253 @@
+// Package ratelimit limits how fast a single client may use the explorer, so
+// nobody can burn through the shared TMDB quota by scripting requests.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/m4tthewde/blunt/users"
+	"golang.org/x/time/rate"
+)
+
+// Class groups routes sharing a budget, searching shouldn't keep somebody
+// from opening the movie they found.
+type Class string
+
+const (
+	Search Class = "search"
+	Detail Class = "detail"
+	Graph  Class = "graph"
+	// Login covers logins and registrations, keeping passwords from being
+	// guessed quickly.
+	Login Class = "login"
+	// Images covers the image proxy, pages load dozens of images at once
+	// but resizing them costs CPU and disk.
+	Images Class = "images"
+)
+
+// Budget allows PerMinute cost units per client, plus a burst of Burst units.
+type Budget struct {
+	PerMinute float64
+	Burst     int
+}
+
+type Options struct {
+	Budgets map[Class]Budget
+	// Allow lists internal clients that are never limited.
+	Allow []netip.Prefix
+	// TrustedProxies may set X-Forwarded-For, the client is the last address
+	// not added by one of them.
+	TrustedProxies []netip.Prefix
+}
+
+// idleTimeout is how long a client's buckets are kept after its last request.
+// Clients coming back later start with a full burst again.
+const idleTimeout = 10 * time.Minute
+
+type client struct {
+	limiters map[Class]*rate.Limiter
+	lastSeen time.Time
+}
+
+// Limiter keeps a token bucket per client and class, clients are signed in
+// users or addresses. A nil Limiter allows everything.
+type Limiter struct {
+	options Options
+
+	mu      sync.Mutex
+	clients map[string]*client
+	swept   time.Time
+}
+
+func New(options Options) *Limiter {
+	return &Limiter{
+		options: options,
+		clients: make(map[string]*client),
+		swept:   time.Now(),
+	}
+}
+
+// Allow takes cost units from the client's budget of class. When the budget
+// is exhausted nothing is taken and it returns how long the client should
+// wait before trying again.
+func (l *Limiter) Allow(r *http.Request, class Class, cost int) (time.Duration, bool) {
+	limiter := l.limiter(r, class)
+	if limiter == nil {
+		return 0, true
+	}
+
+	now := time.Now()
+
+	// a request costing more than the burst could never pass, it takes the
+	// whole budget instead
+	reservation := limiter.ReserveN(now, min(cost, limiter.Burst()))
+
+	delay := reservation.DelayFrom(now)
+	if delay == 0 {
+		return 0, true
+	}
+
+	reservation.CancelAt(now)
+	rejected.WithLabelValues(string(class)).Inc()
+
+	return delay, false
+}
+
+// Charge takes cost units from the client's budget of class even if that
+// exhausts it, for work whose cost is only known once it is done.
+func (l *Limiter) Charge(r *http.Request, class Class, cost int) {
+	limiter := l.limiter(r, class)
+	if limiter == nil || cost < 1 {
+		return
+	}
+
+	limiter.ReserveN(time.Now(), min(cost, limiter.Burst()))
+}
+
+// Wait takes cost units from the client's budget of class, waiting until
+// they are available. Background work started by a request uses it to stay
+// within the budget of the client that started it.
+func (l *Limiter) Wait(ctx context.Context, r *http.Request, class Class, cost int) error {
+	limiter := l.limiter(r, class)
+	if limiter == nil || cost < 1 {
+		return nil
+	}
+
+	return limiter.WaitN(ctx, min(cost, limiter.Burst()))
+}
+
+// limiter returns the bucket of the request's client for class, or nil when
+// the client isn't limited.
+func (l *Limiter) limiter(r *http.Request, class Class) *rate.Limiter {
+	if l == nil {
+		return nil
+	}
+
+	budget, ok := l.options.Budgets[class]
+	if !ok {
+		return nil
+	}
+
+	ip := l.ClientIP(r)
+	if contains(l.options.Allow, ip) {
+		return nil
+	}
+
+	key := clientKey(ip)
+	now := time.Now()
+
+	// signed in users have a budget of their own, people sharing an address
+	// like an office or a carrier NAT don't share theirs. Logins are always
+	// limited by address, or every account would add password guesses.
+	user := users.FromContext(r.Context())
+	if user != nil && class != Login {
+		key = "user:" + strconv.FormatInt(user.Id, 10)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.swept) > time.Minute {
+		l.sweep(now)
+	}
+
+	c, ok := l.clients[key]
+	if !ok {
+		c = &client{limiters: make(map[Class]*rate.Limiter)}
+		l.clients[key] = c
+	}
+
+	c.lastSeen = now
+
+	limiter, ok := c.limiters[class]
+	if !ok {
+		limiter = rate.NewLimiter(rate.Limit(budget.PerMinute/60), budget.Burst)
+		c.limiters[class] = limiter
+	}
+
+	return limiter
+}
+
+func (l *Limiter) sweep(now time.Time) {
+	for key, c := range l.clients {
+		if now.Sub(c.lastSeen) > idleTimeout {
+			delete(l.clients, key)
+		}
+	}
+
+	l.swept = now
+	clients.Set(float64(len(l.clients)))
+}
+
+// ClientIP returns the address of the client, looking through trusted
+// reverse proxies.
+func (l *Limiter) ClientIP(r *http.Request) netip.Addr {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}
+	}
+
+	ip = ip.Unmap()
+
+	if l == nil || !contains(l.options.TrustedProxies, ip) {
+		return ip
+	}
+
+	forwarded := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(forwarded[i]))
+		if err != nil {
+			break
+		}
+
+		ip = hop.Unmap()
+
+		if !contains(l.options.TrustedProxies, ip) {
+			break
+		}
+	}
+
+	return ip
+}
+
+// clientKey groups IPv6 clients by their /64, every host usually gets a
+// whole one to pick addresses from.
+func clientKey(ip netip.Addr) string {
+	if ip.Is6() {
+		prefix, err := ip.Prefix(64)
+		if err == nil {
+			return prefix.String()
+		}
+	}
+
+	return ip.String()
+}
+
+func contains(prefixes []netip.Prefix, ip netip.Addr) bool {
+	for _, prefix := range prefixes {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RetryAfter formats a delay for the Retry-After header, in whole seconds.
+func RetryAfter(delay time.Duration) int {
+	return max(1, int(math.Ceil(delay.Seconds())))
+}
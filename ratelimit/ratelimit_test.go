@@ -0,0 +1,324 @@
+package ratelimit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/m4tthewde/blunt/users"
+)
+
+func request(remote string, forwarded ...string) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = remote
+	for _, header := range forwarded {
+		r.Header.Add("X-Forwarded-For", header)
+	}
+
+	return r
+}
+
+func prefixes(values ...string) []netip.Prefix {
+	result := make([]netip.Prefix, 0, len(values))
+	for _, value := range values {
+		result = append(result, netip.MustParsePrefix(value))
+	}
+
+	return result
+}
+
+// slow refills a unit every minute, so the burst is all a test gets.
+func slow(burst int) *Limiter {
+	return New(Options{Budgets: map[Class]Budget{
+		Search: {PerMinute: 1, Burst: burst},
+		Graph:  {PerMinute: 1, Burst: burst},
+		Login:  {PerMinute: 1, Burst: burst},
+	}})
+}
+
+func TestAllow(t *testing.T) {
+	l := slow(3)
+	r := request("192.0.2.1:1234")
+
+	for i := range 3 {
+		_, ok := l.Allow(r, Search, 1)
+		if !ok {
+			t.Fatalf("request %d was refused", i+1)
+		}
+	}
+
+	delay, ok := l.Allow(r, Search, 1)
+	if ok || delay <= 0 || delay > time.Minute {
+		t.Errorf("fourth request: allowed %v, retry after %v", ok, delay)
+	}
+
+	// other classes and clients have budgets of their own
+	_, ok = l.Allow(r, Graph, 1)
+	if !ok {
+		t.Error("graph request was refused")
+	}
+
+	_, ok = l.Allow(request("192.0.2.2:1234"), Search, 1)
+	if !ok {
+		t.Error("another client was refused")
+	}
+
+	// classes without a budget aren't limited
+	_, ok = l.Allow(r, Detail, 100)
+	if !ok {
+		t.Error("detail request was refused")
+	}
+}
+
+func TestAllowCostAboveBurst(t *testing.T) {
+	l := slow(5)
+	r := request("192.0.2.1:1234")
+
+	_, ok := l.Allow(r, Graph, 50)
+	if !ok {
+		t.Fatal("a request costing more than the burst never passes")
+	}
+
+	_, ok = l.Allow(r, Graph, 1)
+	if ok {
+		t.Error("the expensive request didn't take the whole budget")
+	}
+}
+
+func TestCharge(t *testing.T) {
+	l := slow(5)
+	r := request("192.0.2.1:1234")
+
+	_, ok := l.Allow(r, Graph, 1)
+	if !ok {
+		t.Fatal("first request was refused")
+	}
+
+	// the work turned out to cost more than what's left
+	l.Charge(r, Graph, 20)
+
+	delay, ok := l.Allow(r, Graph, 1)
+	if ok {
+		t.Error("charging didn't exhaust the budget")
+	}
+
+	// the debt is bounded by the burst, a minute refills one unit
+	if delay > 6*time.Minute {
+		t.Errorf("retry after %v", delay)
+	}
+}
+
+func TestWait(t *testing.T) {
+	l := slow(2)
+	r := request("192.0.2.1:1234")
+
+	err := l.Wait(context.Background(), r, Search, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err = l.Wait(ctx, r, Search, 1)
+	if err == nil {
+		t.Error("waited for a unit a minute away")
+	}
+}
+
+func TestNilLimiter(t *testing.T) {
+	var l *Limiter
+	r := request("192.0.2.1:1234")
+
+	_, ok := l.Allow(r, Search, 1000)
+	if !ok {
+		t.Error("nil limiter refused")
+	}
+
+	l.Charge(r, Search, 1000)
+
+	err := l.Wait(context.Background(), r, Search, 1000)
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestClientKeys(t *testing.T) {
+	tests := []struct {
+		name   string
+		first  *http.Request
+		second *http.Request
+		class  Class
+		shared bool
+	}{
+		{
+			name:   "IPv6 addresses of one /64",
+			first:  request("[2001:db8:1:2::1]:1234"),
+			second: request("[2001:db8:1:2:ffff::7]:1234"),
+			class:  Search,
+			shared: true,
+		},
+		{
+			name:   "IPv6 addresses of different /64s",
+			first:  request("[2001:db8:1:2::1]:1234"),
+			second: request("[2001:db8:1:3::1]:1234"),
+			class:  Search,
+		},
+		{
+			name:   "IPv4 mapped into IPv6",
+			first:  request("192.0.2.1:1234"),
+			second: request("[::ffff:192.0.2.1]:1234"),
+			class:  Search,
+			shared: true,
+		},
+		{
+			name:   "users behind one address",
+			first:  signedIn(request("192.0.2.1:1234"), 1),
+			second: signedIn(request("192.0.2.1:1234"), 2),
+			class:  Search,
+		},
+		{
+			name:   "one user at two addresses",
+			first:  signedIn(request("192.0.2.1:1234"), 1),
+			second: signedIn(request("198.51.100.1:1234"), 1),
+			class:  Search,
+			shared: true,
+		},
+		{
+			name:   "logins of users behind one address",
+			first:  signedIn(request("192.0.2.1:1234"), 1),
+			second: signedIn(request("192.0.2.1:1234"), 2),
+			class:  Login,
+			shared: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			l := slow(1)
+
+			_, ok := l.Allow(test.first, test.class, 1)
+			if !ok {
+				t.Fatal("first request was refused")
+			}
+
+			_, ok = l.Allow(test.second, test.class, 1)
+			if ok == test.shared {
+				t.Errorf("second request allowed %v, want budgets shared %v", ok, test.shared)
+			}
+		})
+	}
+}
+
+func signedIn(r *http.Request, id int64) *http.Request {
+	return r.WithContext(users.WithUser(r.Context(), &users.User{Id: id}))
+}
+
+func TestAllowList(t *testing.T) {
+	l := New(Options{
+		Budgets: map[Class]Budget{Search: {PerMinute: 1, Burst: 1}},
+		Allow:   prefixes("10.0.0.0/8"),
+	})
+
+	for range 5 {
+		_, ok := l.Allow(request("10.1.2.3:1234"), Search, 1)
+		if !ok {
+			t.Fatal("internal client was limited")
+		}
+	}
+}
+
+func TestSweep(t *testing.T) {
+	l := slow(1)
+
+	_, ok := l.Allow(request("192.0.2.1:1234"), Search, 1)
+	if !ok {
+		t.Fatal("first request was refused")
+	}
+
+	l.clients["192.0.2.1"].lastSeen = time.Now().Add(-idleTimeout - time.Second)
+
+	_, ok = l.Allow(request("192.0.2.2:1234"), Search, 1)
+	if !ok {
+		t.Fatal("second client was refused")
+	}
+
+	l.sweep(time.Now())
+
+	if _, ok := l.clients["192.0.2.1"]; ok {
+		t.Error("idle client was kept")
+	}
+	if _, ok := l.clients["192.0.2.2"]; !ok {
+		t.Error("active client was dropped")
+	}
+
+	// coming back starts with a full burst
+	_, ok = l.Allow(request("192.0.2.1:1234"), Search, 1)
+	if !ok {
+		t.Error("returning client was refused")
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	l := New(Options{TrustedProxies: prefixes("10.0.0.0/8", "2001:db8:ffff::/48")})
+
+	tests := []struct {
+		name    string
+		request *http.Request
+		want    string
+	}{
+		{"direct", request("192.0.2.1:1234"), "192.0.2.1"},
+		{"direct IPv4 mapped", request("[::ffff:192.0.2.1]:1234"), "192.0.2.1"},
+		{"header of an untrusted client", request("192.0.2.1:1234", "198.51.100.1"), "192.0.2.1"},
+		{"through a trusted proxy", request("10.0.0.1:1234", "198.51.100.1"), "198.51.100.1"},
+		{"through a trusted IPv6 proxy", request("[2001:db8:ffff::1]:1234", "198.51.100.1"), "198.51.100.1"},
+		{"through two trusted proxies", request("10.0.0.1:1234", "198.51.100.1, 10.0.0.2"), "198.51.100.1"},
+		{"header lines joined", request("10.0.0.1:1234", "198.51.100.1", "10.0.0.2"), "198.51.100.1"},
+		{
+			// the client may send any X-Forwarded-For, only what the
+			// proxies appended counts
+			name:    "spoofed hops before the client",
+			request: request("10.0.0.1:1234", "203.0.113.9, 198.51.100.1"),
+			want:    "198.51.100.1",
+		},
+		{"garbage stops the walk", request("10.0.0.1:1234", "198.51.100.1, nonsense, 10.0.0.2"), "10.0.0.2"},
+		{"proxy without header", request("10.0.0.1:1234"), "10.0.0.1"},
+		{"only trusted hops", request("10.0.0.1:1234", "10.0.0.2"), "10.0.0.2"},
+		{"no port", request("192.0.2.1"), "192.0.2.1"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := l.ClientIP(test.request)
+			if got.String() != test.want {
+				t.Errorf("client %s, want %s", got, test.want)
+			}
+		})
+	}
+
+	if got := l.ClientIP(request("not an address")); got.IsValid() {
+		t.Errorf("client %s of an invalid address", got)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		delay time.Duration
+		want  int
+	}{
+		{0, 1},
+		{time.Millisecond, 1},
+		{time.Second, 1},
+		{1500 * time.Millisecond, 2},
+		{time.Minute, 60},
+	}
+
+	for _, test := range tests {
+		if got := RetryAfter(test.delay); got != test.want {
+			t.Errorf("RetryAfter(%v) = %d, want %d", test.delay, got, test.want)
+		}
+	}
+}
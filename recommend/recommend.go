@@ -0,0 +1,168 @@
+// Package recommend suggests movies a user hasn't watched from what they
+// watched and rated, and explains each suggestion.
+package recommend
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+
+	"github.com/m4tthewde/blunt/library"
+	"github.com/m4tthewde/blunt/tmdb"
+)
+
+const (
+	// maxSeeds bounds the TMDB lookups of long histories, the strongest
+	// seeds are used.
+	maxSeeds = 20
+	// maxCandidates is how many of the best candidates get their cast
+	// looked up before the final scoring.
+	maxCandidates = 40
+	// concurrency of the lookups, TMDB's rate limit applies on top.
+	concurrency = 4
+)
+
+// Source is where relationships of movies are looked up, *tmdb.Client
+// outside of tests and a fixture graph in them.
+type Source interface {
+	Credits(ctx context.Context, movieId string) (*tmdb.MovieCreditsResponse, error)
+	Recommendations(ctx context.Context, movieId string) (*tmdb.MovieSearchResponse, error)
+	Similar(ctx context.Context, movieId string) (*tmdb.MovieSearchResponse, error)
+}
+
+type Recommender struct {
+	source Source
+}
+
+func New(source Source) *Recommender {
+	return &Recommender{source: source}
+}
+
+// Recommend scores the movies related to the history and returns the best
+// limit of them.
+func (r *Recommender) Recommend(ctx context.Context, history []library.Viewing, limit int) ([]Recommendation, error) {
+	seeds := Seeds(history)
+	graph := Graph{
+		Cast:        make(map[int64][]Person),
+		Recommended: make(map[int64][]Movie),
+		Similar:     make(map[int64][]Movie),
+	}
+
+	strongest := seeds[:min(maxSeeds, len(seeds))]
+	ids := make([]int64, 0, len(strongest))
+	for _, seed := range strongest {
+		ids = append(ids, seed.Id)
+	}
+
+	err := r.fetch(ctx, ids, &graph, true)
+	if err != nil {
+		return nil, err
+	}
+
+	// the cast of candidates only matters for the ones that could make it
+	candidates := Score(seeds, graph, maxCandidates)
+
+	ids = ids[:0]
+	for _, candidate := range candidates {
+		ids = append(ids, candidate.Id)
+	}
+
+	err = r.fetch(ctx, ids, &graph, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return Score(seeds, graph, limit), nil
+}
+
+// fetch adds the cast of the movies to graph, and their recommended and
+// similar movies for seeds. Movies TMDB doesn't know anymore are left out.
+func (r *Recommender) fetch(ctx context.Context, ids []int64, graph *Graph, seeds bool) error {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var firstErr error
+
+	slots := make(chan struct{}, concurrency)
+
+	for _, id := range ids {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			cast, recommended, similar, err := r.lookup(ctx, strconv.FormatInt(id, 10), seeds)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if errors.Is(err, tmdb.ErrNotFound) {
+				return
+			}
+
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				return
+			}
+
+			graph.Cast[id] = cast
+
+			if seeds {
+				graph.Recommended[id] = recommended
+				graph.Similar[id] = similar
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	return firstErr
+}
+
+func (r *Recommender) lookup(ctx context.Context, id string, seed bool) ([]Person, []Movie, []Movie, error) {
+	credits, err := r.source.Credits(ctx, id)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	cast := make([]Person, 0, len(credits.Cast))
+	for _, member := range credits.Cast {
+		cast = append(cast, Person{Id: member.Id, Name: member.Name})
+	}
+
+	if !seed {
+		return cast, nil, nil, nil
+	}
+
+	recommended, err := r.source.Recommendations(ctx, id)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	similar, err := r.source.Similar(ctx, id)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return cast, movies(recommended.Results), movies(similar.Results), nil
+}
+
+func movies(results []tmdb.MovieSearchResult) []Movie {
+	related := make([]Movie, 0, len(results))
+
+	for _, result := range results {
+		related = append(related, Movie{
+			Id:          result.Id,
+			Title:       result.Title,
+			PosterPath:  result.PosterPath,
+			ReleaseDate: result.ReleaseDate,
+		})
+	}
+
+	return related
+}
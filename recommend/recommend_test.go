@@ -0,0 +1,205 @@
+package recommend
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/m4tthewde/blunt/library"
+	"github.com/m4tthewde/blunt/tmdb"
+)
+
+// fixture is a small movie graph around a crime fan's history.
+var fixture = struct {
+	titles      map[int64]string
+	cast        map[int64][]Person
+	recommended map[int64][]int64
+	similar     map[int64][]int64
+}{
+	titles: map[int64]string{
+		1: "Heat", 2: "Ronin", 3: "Cats", 4: "Collateral",
+		10: "The Insider", 11: "Thief", 12: "Casino", 30: "Cats 2",
+	},
+	cast: map[int64][]Person{
+		1:  {{100, "Al Pacino"}, {101, "Robert De Niro"}},
+		2:  {{101, "Robert De Niro"}, {102, "Jean Reno"}},
+		3:  {{103, "James Corden"}},
+		4:  {{105, "Tom Cruise"}},
+		10: {{100, "Al Pacino"}},
+		11: {{104, "James Caan"}},
+		12: {{101, "Robert De Niro"}},
+		30: {{103, "James Corden"}},
+	},
+	recommended: map[int64][]int64{
+		1: {10, 2, 11},
+		2: {12},
+		3: {30},
+		4: {11},
+	},
+	similar: map[int64][]int64{
+		1: {12},
+		2: {10},
+	},
+}
+
+// fakeSource answers from the fixture, other movies don't exist.
+type fakeSource struct {
+	mu    sync.Mutex
+	calls map[string]int
+}
+
+func (f *fakeSource) count(kind string, movieId string) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.calls == nil {
+		f.calls = make(map[string]int)
+	}
+	f.calls[kind]++
+
+	id, _ := strconv.ParseInt(movieId, 10, 64)
+	if _, ok := fixture.titles[id]; !ok {
+		return 0, tmdb.ErrNotFound
+	}
+
+	return id, nil
+}
+
+func (f *fakeSource) Credits(ctx context.Context, movieId string) (*tmdb.MovieCreditsResponse, error) {
+	id, err := f.count("credits", movieId)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &tmdb.MovieCreditsResponse{Id: id}
+	for _, person := range fixture.cast[id] {
+		response.Cast = append(response.Cast, tmdb.MovieCastMember{Id: person.Id, Name: person.Name})
+	}
+
+	return response, nil
+}
+
+func (f *fakeSource) Recommendations(ctx context.Context, movieId string) (*tmdb.MovieSearchResponse, error) {
+	id, err := f.count("recommendations", movieId)
+	if err != nil {
+		return nil, err
+	}
+
+	return results(fixture.recommended[id]), nil
+}
+
+func (f *fakeSource) Similar(ctx context.Context, movieId string) (*tmdb.MovieSearchResponse, error) {
+	id, err := f.count("similar", movieId)
+	if err != nil {
+		return nil, err
+	}
+
+	return results(fixture.similar[id]), nil
+}
+
+func results(ids []int64) *tmdb.MovieSearchResponse {
+	response := &tmdb.MovieSearchResponse{}
+	for _, id := range ids {
+		response.Results = append(response.Results, tmdb.MovieSearchResult{Id: id, Title: fixture.titles[id]})
+	}
+
+	return response
+}
+
+func day(d int) time.Time {
+	return time.Date(2025, 1, d, 0, 0, 0, 0, time.UTC)
+}
+
+func viewing(id int64, date time.Time, rating int) library.Viewing {
+	return library.Viewing{Movie: library.Movie{Id: id, Title: fixture.titles[id]}, Date: date, Rating: rating}
+}
+
+func TestRecommend(t *testing.T) {
+	history := []library.Viewing{
+		viewing(1, day(10), 10),
+		viewing(2, day(5), 8),
+		viewing(3, day(7), 2),
+		viewing(4, day(3), 0),
+		// TMDB lost this one, it is still watched
+		viewing(99, day(1), 9),
+	}
+
+	source := &fakeSource{}
+
+	got, err := New(source).Recommend(context.Background(), history, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Recommendation{
+		{
+			Movie: Movie{Id: 12, Title: "Casino"},
+			Score: 0.6 + 0.5 + 0.4*1.6,
+			Reasons: []Reason{
+				{Kind: ReasonCast, Names: []string{"Robert De Niro"}},
+				{Kind: ReasonRecommended, Names: []string{"Ronin"}},
+				{Kind: ReasonSimilar, Names: []string{"Heat"}},
+			},
+		},
+		{
+			Movie: Movie{Id: 10, Title: "The Insider"},
+			Score: 1 + 0.6*0.5 + 0.4*1,
+			Reasons: []Reason{
+				{Kind: ReasonCast, Names: []string{"Al Pacino"}},
+				{Kind: ReasonRecommended, Names: []string{"Heat"}},
+				{Kind: ReasonSimilar, Names: []string{"Ronin"}},
+			},
+		},
+		{
+			// third in Heat's list, behind the watched Ronin
+			Movie: Movie{Id: 11, Title: "Thief"},
+			Score: 1*rank(2) + 0.3,
+			Reasons: []Reason{
+				{Kind: ReasonRecommended, Names: []string{"Heat", "Collateral"}},
+			},
+		},
+	}
+
+	assertRecommendations(t, got, want)
+
+	// seeds and the three candidates need their cast, only the four seeds
+	// TMDB knows the rest
+	if source.calls["credits"] != 5+3 || source.calls["recommendations"] != 4 || source.calls["similar"] != 4 {
+		t.Errorf("lookups %v", source.calls)
+	}
+}
+
+func TestRecommendEmptyHistory(t *testing.T) {
+	got, err := New(&fakeSource{}).Recommend(context.Background(), nil, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got) != 0 {
+		t.Errorf("recommended %v without a history", got)
+	}
+}
+
+func assertRecommendations(t *testing.T, got []Recommendation, want []Recommendation) {
+	t.Helper()
+
+	if len(got) != len(want) {
+		t.Fatalf("got %d recommendations %+v, want %d", len(got), got, len(want))
+	}
+
+	for i := range want {
+		if math.Abs(got[i].Score-want[i].Score) > 1e-9 {
+			t.Errorf("%d: %s scored %v, want %v", i, got[i].Title, got[i].Score, want[i].Score)
+		}
+
+		got[i].Score = want[i].Score
+
+		if !reflect.DeepEqual(got[i], want[i]) {
+			t.Errorf("%d: got %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,242 @@
+package recommend
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	"github.com/m4tthewde/blunt/library"
+)
+
+const (
+	// recommendedWeight and similarWeight scale what a seed passes on to
+	// the movies TMDB relates to it, recommendations are based on what
+	// viewers liked and say more than shared genres.
+	recommendedWeight = 1.0
+	similarWeight     = 0.5
+	// castWeight scales the affinity of every cast member a candidate
+	// shares with the seeds.
+	castWeight = 0.4
+	// castDepth is how many top billed cast members of a movie count.
+	castDepth = 10
+	// highRating is the rating from which a seed's cast is mentioned in
+	// explanations.
+	highRating = 8
+)
+
+// Seed is a watched movie and how much the user liked it.
+type Seed struct {
+	Movie
+	// Rating of the latest rated viewing, zero when never rated.
+	Rating      int
+	LastWatched time.Time
+}
+
+type Movie struct {
+	Id          int64
+	Title       string
+	PosterPath  string
+	ReleaseDate string
+}
+
+type Person struct {
+	Id   int64
+	Name string
+}
+
+// Graph holds the relationships scoring looks at. Movies missing from a
+// map have no known relationships.
+type Graph struct {
+	// Cast of seeds and candidates, top billed first.
+	Cast map[int64][]Person
+	// Recommended and Similar of seeds, most relevant first.
+	Recommended map[int64][]Movie
+	Similar     map[int64][]Movie
+}
+
+// Reason kinds, each names the seeds or people it is about.
+const (
+	// ReasonCast names people of seeds rated highly who appear in the
+	// movie.
+	ReasonCast = "cast"
+	// ReasonRecommended names seeds TMDB recommends the movie for.
+	ReasonRecommended = "recommended"
+	// ReasonSimilar names seeds the movie is similar to.
+	ReasonSimilar = "similar"
+)
+
+type Reason struct {
+	Kind  string
+	Names []string
+}
+
+type Recommendation struct {
+	Movie
+	Score   float64
+	Reasons []Reason
+}
+
+// Seeds sums up the history by movie. The strongest seeds come first,
+// ties go to the more recently watched one and then to the lower id.
+// Viewings on the same day are taken in the order of history.
+func Seeds(history []library.Viewing) []Seed {
+	seeds := make(map[int64]*Seed)
+	ratedOn := make(map[int64]time.Time)
+
+	for _, viewing := range history {
+		seed, ok := seeds[viewing.Movie.Id]
+		if !ok {
+			seed = &Seed{Movie: Movie{
+				Id:          viewing.Movie.Id,
+				Title:       viewing.Title,
+				PosterPath:  viewing.PosterPath,
+				ReleaseDate: viewing.ReleaseDate,
+			}}
+			seeds[viewing.Movie.Id] = seed
+		}
+
+		if viewing.Date.After(seed.LastWatched) {
+			seed.LastWatched = viewing.Date
+		}
+
+		// a rewatch can change the user's mind, the latest rating wins
+		rated, ok := ratedOn[viewing.Movie.Id]
+		if viewing.Rating != 0 && (!ok || !viewing.Date.Before(rated)) {
+			seed.Rating = viewing.Rating
+			ratedOn[viewing.Movie.Id] = viewing.Date
+		}
+	}
+
+	result := make([]Seed, 0, len(seeds))
+	for _, seed := range seeds {
+		result = append(result, *seed)
+	}
+
+	slices.SortFunc(result, func(a, b Seed) int {
+		return cmp.Or(
+			cmp.Compare(weight(b.Rating), weight(a.Rating)),
+			b.LastWatched.Compare(a.LastWatched),
+			cmp.Compare(a.Id, b.Id),
+		)
+	})
+
+	return result
+}
+
+// weight turns a rating into how much a seed counts, from -0.8 for 1/10 to
+// 1 for 10/10. Watching a movie without rating it is a mild interest.
+func weight(rating int) float64 {
+	if rating == 0 {
+		return 0.3
+	}
+
+	return float64(rating-5) / 5
+}
+
+// rank makes the first entries of TMDB's lists count more, the tenth
+// counts half as much as the first.
+func rank(index int) float64 {
+	return 1 / (1 + float64(index)/9)
+}
+
+type candidate struct {
+	Recommendation
+	recommended []string
+	similar     []string
+	cast        []string
+}
+
+// Score rates the movies related to the seeds which the user hasn't
+// watched and returns the best limit of them. It only depends on its
+// arguments, the same seeds and graph always give the same result.
+func Score(seeds []Seed, graph Graph, limit int) []Recommendation {
+	watched := make(map[int64]bool)
+	affinity := make(map[int64]float64)
+	// favourites are the people of highly rated seeds
+	favourites := make(map[int64]bool)
+
+	for _, seed := range seeds {
+		watched[seed.Id] = true
+
+		for _, person := range top(graph.Cast[seed.Id]) {
+			affinity[person.Id] += weight(seed.Rating)
+
+			if seed.Rating >= highRating {
+				favourites[person.Id] = true
+			}
+		}
+	}
+
+	candidates := make(map[int64]*candidate)
+
+	relate := func(seed Seed, movies []Movie, factor float64, because func(*candidate) *[]string) {
+		for i, movie := range movies {
+			if watched[movie.Id] {
+				continue
+			}
+
+			c, ok := candidates[movie.Id]
+			if !ok {
+				c = &candidate{Recommendation: Recommendation{Movie: movie}}
+				candidates[movie.Id] = c
+			}
+
+			c.Score += weight(seed.Rating) * factor * rank(i)
+
+			names := because(c)
+			if !slices.Contains(*names, seed.Title) {
+				*names = append(*names, seed.Title)
+			}
+		}
+	}
+
+	// disliked seeds don't spread, but their cast counts against candidates
+	for _, seed := range seeds {
+		if weight(seed.Rating) <= 0 {
+			continue
+		}
+
+		relate(seed, graph.Recommended[seed.Id], recommendedWeight, func(c *candidate) *[]string { return &c.recommended })
+		relate(seed, graph.Similar[seed.Id], similarWeight, func(c *candidate) *[]string { return &c.similar })
+	}
+
+	recommendations := make([]Recommendation, 0, len(candidates))
+
+	for _, c := range candidates {
+		for _, person := range top(graph.Cast[c.Id]) {
+			c.Score += castWeight * affinity[person.Id]
+
+			if favourites[person.Id] && !slices.Contains(c.cast, person.Name) {
+				c.cast = append(c.cast, person.Name)
+			}
+		}
+
+		if c.Score <= 0 {
+			continue
+		}
+
+		if len(c.cast) != 0 {
+			c.Reasons = append(c.Reasons, Reason{Kind: ReasonCast, Names: c.cast})
+		}
+
+		if len(c.recommended) != 0 {
+			c.Reasons = append(c.Reasons, Reason{Kind: ReasonRecommended, Names: c.recommended})
+		}
+
+		if len(c.similar) != 0 {
+			c.Reasons = append(c.Reasons, Reason{Kind: ReasonSimilar, Names: c.similar})
+		}
+
+		recommendations = append(recommendations, c.Recommendation)
+	}
+
+	slices.SortFunc(recommendations, func(a, b Recommendation) int {
+		return cmp.Or(cmp.Compare(b.Score, a.Score), cmp.Compare(a.Id, b.Id))
+	})
+
+	return recommendations[:min(limit, len(recommendations))]
+}
+
+func top(cast []Person) []Person {
+	return cast[:min(castDepth, len(cast))]
+}
@@ -0,0 +1,179 @@
+package recommend
+
+import (
+	"testing"
+	"time"
+
+	"github.com/m4tthewde/blunt/library"
+)
+
+func seed(id int64, title string, rating int) Seed {
+	return Seed{Movie: Movie{Id: id, Title: title}, Rating: rating}
+}
+
+func movie(id int64, title string) Movie {
+	return Movie{Id: id, Title: title}
+}
+
+func TestScore(t *testing.T) {
+	tests := []struct {
+		name  string
+		seeds []Seed
+		graph Graph
+		limit int
+		want  []Recommendation
+	}{
+		{
+			name:  "watched movies are left out",
+			seeds: []Seed{seed(1, "Alien", 9), seed(2, "Aliens", 7)},
+			graph: Graph{Recommended: map[int64][]Movie{
+				1: {movie(2, "Aliens"), movie(3, "Prometheus")},
+			}},
+			limit: 10,
+			want: []Recommendation{{
+				Movie:   movie(3, "Prometheus"),
+				Score:   0.8 * rank(1),
+				Reasons: []Reason{{Kind: ReasonRecommended, Names: []string{"Alien"}}},
+			}},
+		},
+		{
+			name:  "rated but disliked movies are left out too",
+			seeds: []Seed{seed(1, "Alien", 9), seed(3, "Prometheus", 2)},
+			graph: Graph{Similar: map[int64][]Movie{
+				1: {movie(3, "Prometheus"), movie(4, "Covenant")},
+			}},
+			limit: 10,
+			want: []Recommendation{{
+				Movie:   movie(4, "Covenant"),
+				Score:   0.8 * similarWeight * rank(1),
+				Reasons: []Reason{{Kind: ReasonSimilar, Names: []string{"Alien"}}},
+			}},
+		},
+		{
+			name:  "disliked seeds don't spread",
+			seeds: []Seed{seed(1, "Cats", 3)},
+			graph: Graph{Recommended: map[int64][]Movie{1: {movie(2, "Cats 2")}}},
+			limit: 10,
+			want:  []Recommendation{},
+		},
+		{
+			name:  "the cast of disliked seeds sinks candidates",
+			seeds: []Seed{seed(1, "Heat", 0), seed(2, "Cats", 1)},
+			graph: Graph{
+				Cast: map[int64][]Person{
+					2: {{7, "James Corden"}},
+					3: {{7, "James Corden"}},
+				},
+				Similar: map[int64][]Movie{1: {movie(3, "The Prom")}},
+			},
+			limit: 10,
+			want:  []Recommendation{},
+		},
+		{
+			name: "people of highly rated seeds explain candidates",
+			seeds: []Seed{
+				seed(1, "Heat", 10),
+				seed(2, "Ronin", 7),
+			},
+			graph: Graph{
+				Cast: map[int64][]Person{
+					1: {{100, "Al Pacino"}},
+					2: {{101, "Robert De Niro"}},
+					3: {{100, "Al Pacino"}, {101, "Robert De Niro"}},
+				},
+				Recommended: map[int64][]Movie{
+					1: {movie(3, "The Irishman")},
+					2: {movie(3, "The Irishman")},
+				},
+			},
+			limit: 10,
+			want: []Recommendation{{
+				Movie: movie(3, "The Irishman"),
+				Score: 1 + 0.4 + castWeight*1 + castWeight*0.4,
+				Reasons: []Reason{
+					// Ronin wasn't rated highly enough to mention De Niro
+					{Kind: ReasonCast, Names: []string{"Al Pacino"}},
+					{Kind: ReasonRecommended, Names: []string{"Heat", "Ronin"}},
+				},
+			}},
+		},
+		{
+			name:  "best first, up to limit",
+			seeds: []Seed{seed(1, "Alien", 10)},
+			graph: Graph{
+				Recommended: map[int64][]Movie{1: {movie(9, "Aliens")}},
+				Similar:     map[int64][]Movie{1: {movie(6, "The Thing"), movie(5, "Predator")}},
+				Cast: map[int64][]Person{
+					1: {{1, "Sigourney Weaver"}},
+					5: {{2, "Arnold Schwarzenegger"}},
+					6: {{2, "Arnold Schwarzenegger"}},
+				},
+			},
+			limit: 2,
+			want: []Recommendation{
+				{
+					Movie:   movie(9, "Aliens"),
+					Score:   1,
+					Reasons: []Reason{{Kind: ReasonRecommended, Names: []string{"Alien"}}},
+				},
+				{
+					Movie:   movie(6, "The Thing"),
+					Score:   similarWeight,
+					Reasons: []Reason{{Kind: ReasonSimilar, Names: []string{"Alien"}}},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assertRecommendations(t, Score(test.seeds, test.graph, test.limit), test.want)
+		})
+	}
+}
+
+func TestScoreIsDeterministic(t *testing.T) {
+	seeds := []Seed{seed(1, "Alien", 10)}
+	graph := Graph{Similar: map[int64][]Movie{1: {movie(5, "Predator"), movie(6, "The Thing"), movie(7, "Them!")}}}
+
+	first := Score(seeds, graph, 10)
+
+	for range 20 {
+		assertRecommendations(t, Score(seeds, graph, 10), first)
+	}
+}
+
+func TestSeeds(t *testing.T) {
+	history := []library.Viewing{
+		viewing(1, day(1), 4),
+		// the rewatch changed the user's mind
+		viewing(1, day(9), 9),
+		viewing(2, day(5), 9),
+		viewing(3, day(8), 0),
+		// an unrated rewatch keeps the rating
+		viewing(2, day(6), 0),
+	}
+
+	got := Seeds(history)
+
+	want := []struct {
+		id          int64
+		rating      int
+		lastWatched time.Time
+	}{
+		{1, 9, day(9)},
+		{2, 9, day(6)},
+		{3, 0, day(8)},
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("%d seeds, want %d", len(got), len(want))
+	}
+
+	for i, w := range want {
+		if got[i].Id != w.id || got[i].Rating != w.rating || !got[i].LastWatched.Equal(w.lastWatched) {
+			t.Errorf("%d: seed %d rated %d on %v, want %d rated %d on %v",
+				i, got[i].Id, got[i].Rating, got[i].LastWatched, w.id, w.rating, w.lastWatched)
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// readinessTTL is how long a TMDB reachability check is reused, so frequent
+// probes don't cost TMDB requests.
+const readinessTTL = 30 * time.Second
+
+// listen serves handler until ctx is cancelled, then stops accepting
+// connections and waits for in-flight requests to finish.
+func listen(ctx context.Context, handler http.Handler, ready *readiness) error {
+	server := http.Server{
+		Addr:              config.Listen,
+		Handler:           handler,
+		ReadHeaderTimeout: config.Server.ReadHeaderTimeout,
+		ReadTimeout:       config.Server.ReadTimeout,
+		WriteTimeout:      config.Server.WriteTimeout,
+		IdleTimeout:       config.Server.IdleTimeout,
+		ErrorLog:          slog.NewLogLogger(slog.Default().Handler(), slog.LevelWarn),
+	}
+
+	tlsEnabled := config.Server.TLSCert != ""
+	if tlsEnabled {
+		certificates, err := newCertReloader(config.Server.TLSCert, config.Server.TLSKey)
+		if err != nil {
+			return err
+		}
+
+		server.TLSConfig = &tls.Config{
+			MinVersion:     tls.VersionTLS12,
+			GetCertificate: certificates.getCertificate,
+		}
+	}
+
+	errs := make(chan error, 1)
+
+	go func() {
+		slog.Info("starting server", "listen", config.Listen, "tls", tlsEnabled)
+
+		var err error
+		if tlsEnabled {
+			err = server.ListenAndServeTLS("", "")
+		} else {
+			err = server.ListenAndServe()
+		}
+
+		errs <- err
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+
+	slog.Info("shutting down, waiting for in-flight requests", "timeout", config.Server.ShutdownTimeout)
+	ready.shutdown()
+
+	shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), config.Server.ShutdownTimeout)
+	defer cancel()
+
+	err := server.Shutdown(shutdownCtx)
+	if err != nil {
+		return fmt.Errorf("shutdown: %w", err)
+	}
+
+	slog.Info("server stopped")
+
+	return nil
+}
+
+// recoverPanics turns a panicking handler into a logged 500 instead of a
+// silently dropped connection.
+func recoverPanics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+
+			// http.ErrAbortHandler is the documented way to abort a response
+			if v == http.ErrAbortHandler {
+				panic(v)
+			}
+
+			slog.ErrorContext(r.Context(), "handler panicked",
+				"method", r.Method,
+				"path", r.URL.Path,
+				"panic", v,
+				"stack", string(debug.Stack()),
+			)
+
+			w.Header().Set("Connection", "close")
+			w.WriteHeader(http.StatusInternalServerError)
+		}()
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// readiness answers health probes. The server is ready while TMDB is
+// reachable with the configured credentials and it isn't shutting down.
+type readiness struct {
+	mu       sync.Mutex
+	checked  time.Time
+	err      error
+	stopping atomic.Bool
+}
+
+func (h *readiness) shutdown() {
+	h.stopping.Store(true)
+}
+
+func (h *readiness) check(ctx context.Context) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if time.Since(h.checked) < readinessTTL {
+		return h.err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	h.err = client.VerifyCredentials(ctx)
+	h.checked = time.Now()
+
+	return h.err
+}
+
+// live reports that the process is up, without looking at dependencies.
+func (h *readiness) live(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	fmt.Fprintln(w, "ok")
+}
+
+func (h *readiness) ready(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+
+	if h.stopping.Load() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprintln(w, "shutting down")
+		return
+	}
+
+	err := h.check(r.Context())
+	if err != nil {
+		slog.WarnContext(r.Context(), "readiness check failed", "error", err)
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprintln(w, "tmdb unreachable")
+		return
+	}
+
+	fmt.Fprintln(w, "ok")
+}
+
+// certReloader serves the certificate from disk and loads it again whenever
+// one of the files has changed, checking at most every few seconds.
+type certReloader struct {
+	certFile string
+	keyFile  string
+
+	mu          sync.Mutex
+	certificate *tls.Certificate
+	modified    time.Time
+	checked     time.Time
+}
+
+func newCertReloader(certFile string, keyFile string) (*certReloader, error) {
+	c := certReloader{certFile: certFile, keyFile: keyFile}
+
+	err := c.reload()
+	if err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+func (c *certReloader) reload() error {
+	modified, err := c.lastModified()
+	if err != nil {
+		return err
+	}
+
+	certificate, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return fmt.Errorf("tls: %w", err)
+	}
+
+	c.certificate = &certificate
+	c.modified = modified
+
+	return nil
+}
+
+func (c *certReloader) lastModified() (time.Time, error) {
+	var latest time.Time
+
+	for _, name := range []string{c.certFile, c.keyFile} {
+		info, err := os.Stat(name)
+		if err != nil {
+			return latest, fmt.Errorf("tls: %w", err)
+		}
+
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+
+	return latest, nil
+}
+
+func (c *certReloader) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if time.Since(c.checked) < 10*time.Second {
+		return c.certificate, nil
+	}
+
+	c.checked = time.Now()
+
+	modified, err := c.lastModified()
+	if err == nil && modified.After(c.modified) {
+		err = c.reload()
+		if err == nil {
+			slog.Info("reloaded TLS certificate", "cert", c.certFile)
+		}
+	}
+
+	// keep serving the old certificate while a renewal is half written
+	if err != nil {
+		slog.Warn("could not reload TLS certificate, keeping the previous one", "error", err)
+	}
+
+	return c.certificate, nil
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/m4tthewde/blunt/components"
+	"github.com/m4tthewde/blunt/users"
+	"github.com/m4tthewde/blunt/watch"
+)
+
+var watchServices *watch.Store
+
+// availability returns the filter state of the request and the user's
+// services, the filter is nil for anonymous visitors.
+func availability(r *http.Request, active bool) (*components.Availability, map[int64]bool, error) {
+	user := users.FromContext(r.Context())
+	if user == nil {
+		return nil, nil, nil
+	}
+
+	services, err := watchServices.Services(r.Context(), user.Id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &components.Availability{Active: active, Configured: len(services) != 0}, services, nil
+}
+
+// filtering reports whether the user asked for titles on their services
+// and has some.
+func filtering(filter *components.Availability) bool {
+	return filter != nil && filter.Active && filter.Configured
+}
+
+func settings(w http.ResponseWriter, r *http.Request, user *users.User) {
+	region := client.Region(r.Context())
+
+	providers, err := client.MovieProviders(r.Context(), region)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	services, err := watchServices.Services(r.Context(), user.Id)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	saved := r.URL.Query().Get("saved") != ""
+
+	render(w, r, components.Settings(region, providers.Results, services, saved))
+}
+
+// saveSettings replaces the services offered in the user's region and
+// keeps the ones chosen while in another region.
+func saveSettings(w http.ResponseWriter, r *http.Request, user *users.User) {
+	err := r.ParseForm()
+	if err != nil {
+		http.Error(w, "invalid form", http.StatusBadRequest)
+		return
+	}
+
+	providers, err := client.MovieProviders(r.Context(), client.Region(r.Context()))
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	services, err := watchServices.Services(r.Context(), user.Id)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	for _, provider := range providers.Results {
+		delete(services, provider.ProviderId)
+	}
+
+	for _, value := range r.PostForm["service"] {
+		id, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || id < 1 {
+			http.Error(w, "invalid service", http.StatusBadRequest)
+			return
+		}
+
+		services[id] = true
+	}
+
+	ids := make([]int64, 0, len(services))
+	for id := range services {
+		ids = append(ids, id)
+	}
+
+	err = watchServices.SetServices(r.Context(), user.Id, ids)
+	if err != nil {
+		serverError(w, r, err)
+		return
+	}
+
+	http.Redirect(w, r, "/me/settings?saved=1", http.StatusSeeOther)
+}
@@ -0,0 +1,31 @@
+document.addEventListener("DOMContentLoaded", function () {
+	for (const select of document.querySelectorAll("select[data-autosubmit]")) {
+		select.addEventListener("change", function () {
+			select.form.submit();
+		});
+	}
+});
+
+// Clicking an expanded graph node collapses it instead of loading it again.
+document.addEventListener("htmx:beforeRequest", function (evt) {
+	const target = evt.detail.target;
+
+	if (!target.classList.contains("graph-children") || target.children.length == 0) {
+		return;
+	}
+
+	while (target.firstChild) {
+		target.removeChild(target.firstChild);
+	}
+
+	evt.preventDefault();
+});
+
+// Rejected searches, graph expansions and import fixes show why instead of
+// doing nothing, htmx doesn't swap error responses by default.
+document.addEventListener("htmx:beforeSwap", function (evt) {
+	if (evt.detail.xhr.status == 429 || evt.detail.xhr.status == 422) {
+		evt.detail.shouldSwap = true;
+		evt.detail.isError = false;
+	}
+});
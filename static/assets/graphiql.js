@@ -0,0 +1,8 @@
+document.addEventListener("DOMContentLoaded", function () {
+	const root = ReactDOM.createRoot(document.getElementById("graphiql"));
+
+	root.render(React.createElement(GraphiQL, {
+		fetcher: GraphiQL.createFetcher({ url: window.location.pathname }),
+		defaultQuery: "{\n  search(query: \"Alien\", limit: 1) {\n    movie {\n      title\n      cast(limit: 3) {\n        person {\n          name\n          movies(limit: 5, orderBy: POPULAR) {\n            movie {\n              title\n            }\n          }\n        }\n      }\n    }\n  }\n}\n",
+	}));
+});
@@ -0,0 +1,139 @@
+package static
+
+//go:generate go run ./vendor.go
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+)
+
+//go:embed assets
+var assets embed.FS
+
+const prefix = "/static/"
+
+var (
+	// hashed maps logical names like "app.css" to "app.<hash>.css"
+	hashed = make(map[string]string)
+	// files maps hashed names back to their content
+	files = make(map[string][]byte)
+
+	started = time.Now()
+)
+
+// required are the vendored assets the pages can't work without.
+var required = []string{
+	"vendor/htmx.min.js",
+	"vendor/react.production.min.js",
+	"vendor/react-dom.production.min.js",
+	"vendor/graphiql.min.js",
+	"vendor/graphiql.min.css",
+}
+
+func init() {
+	fs.WalkDir(assets, "assets", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+
+		data, err := assets.ReadFile(name)
+		if err != nil {
+			return err
+		}
+
+		logical := strings.TrimPrefix(name, "assets/")
+		sum := sha256.Sum256(data)
+		ext := path.Ext(logical)
+		name = strings.TrimSuffix(logical, ext) + "." + hex.EncodeToString(sum[:])[:12] + ext
+
+		hashed[logical] = name
+		files[name] = data
+
+		return nil
+	})
+}
+
+// Path returns the content hashed URL of an asset, so it can be cached
+// forever and still change with every release that touches it.
+func Path(name string) string {
+	return prefix + hashed[name]
+}
+
+// Exists reports whether an asset was embedded into the binary.
+func Exists(name string) bool {
+	_, ok := hashed[name]
+
+	return ok
+}
+
+// Check fails when a required vendored asset wasn't embedded, or isn't the
+// file pinned in vendor/sha256sums.
+func Check() error {
+	sums := pinnedSums()
+
+	missing := make([]string, 0)
+	for _, name := range required {
+		if !Exists(name) {
+			missing = append(missing, name)
+			continue
+		}
+
+		sum := sha256.Sum256(files[hashed[name]])
+		got := hex.EncodeToString(sum[:])
+
+		want, ok := sums[path.Base(name)]
+		if !ok {
+			return fmt.Errorf("static: %s has no pinned sum in vendor/sha256sums", name)
+		}
+		if got != want {
+			return fmt.Errorf("static: %s has sha256 %s, pinned %s", name, got, want)
+		}
+	}
+
+	if len(missing) != 0 {
+		return fmt.Errorf("static: %s not embedded, run `go generate ./static` and rebuild", strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+// pinnedSums reads vendor/sha256sums, written in the format of sha256sum(1).
+func pinnedSums() map[string]string {
+	sums := make(map[string]string)
+
+	for _, line := range strings.Split(string(files[hashed["vendor/sha256sums"]]), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		sum, name, ok := strings.Cut(line, "  ")
+		if ok {
+			sums[name] = sum
+		}
+	}
+
+	return sums
+}
+
+// Handler serves the embedded assets below /static/.
+func Handler(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, prefix)
+
+	data, ok := files[name]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	http.ServeContent(w, r, name, started, bytes.NewReader(data))
+}
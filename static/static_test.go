@@ -0,0 +1,75 @@
+package static
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	content := func(name string) []byte {
+		return []byte("/* " + name + " */")
+	}
+
+	sum := func(data []byte) string {
+		s := sha256.Sum256(data)
+
+		return hex.EncodeToString(s[:])
+	}
+
+	pinned := "# comment\n"
+	for _, name := range required {
+		pinned += sum(content(name)) + "  " + name[len("vendor/"):] + "\n"
+	}
+
+	tests := []struct {
+		name   string
+		change func(files map[string][]byte)
+		want   string
+	}{
+		{name: "all pinned", change: func(map[string][]byte) {}},
+		{
+			name:   "missing",
+			change: func(files map[string][]byte) { delete(files, "vendor/htmx.min.js") },
+			want:   "vendor/htmx.min.js not embedded",
+		},
+		{
+			name:   "changed",
+			change: func(files map[string][]byte) { files["vendor/graphiql.min.css"] = []byte("body {}") },
+			want:   "vendor/graphiql.min.css has sha256",
+		},
+		{
+			name:   "not pinned",
+			change: func(files map[string][]byte) { files["vendor/sha256sums"] = []byte("# nothing yet\n") },
+			want:   "no pinned sum",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			embedded := map[string][]byte{"vendor/sha256sums": []byte(pinned)}
+			for _, name := range required {
+				embedded[name] = content(name)
+			}
+			test.change(embedded)
+
+			savedHashed, savedFiles := hashed, files
+			t.Cleanup(func() { hashed, files = savedHashed, savedFiles })
+
+			// the tests don't need content hashed names
+			hashed, files = make(map[string]string), embedded
+			for name := range embedded {
+				hashed[name] = name
+			}
+
+			err := Check()
+			if test.want == "" && err != nil {
+				t.Errorf("error %v", err)
+			}
+			if test.want != "" && (err == nil || !strings.Contains(err.Error(), test.want)) {
+				t.Errorf("error %v, want one containing %q", err, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+//go:build ignore
+
+// vendor.go downloads the third party scripts embedded into the binary. The
+// versions are pinned in assets/vendor/versions and the content in
+// assets/vendor/sha256sums, downloads that don't match are refused. Run it
+// with -pin to record the sums of new versions.
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"slices"
+	"strings"
+)
+
+// files lists what is taken from each npm package.
+var files = map[string][]string{
+	"htmx.org":  {"dist/htmx.min.js"},
+	"react":     {"umd/react.production.min.js"},
+	"react-dom": {"umd/react-dom.production.min.js"},
+	"graphiql":  {"graphiql.min.js", "graphiql.min.css"},
+}
+
+const sumsFile = "assets/vendor/sha256sums"
+
+func main() {
+	pin := flag.Bool("pin", false, "record the sums of the downloaded files instead of checking them")
+	flag.Parse()
+
+	sums, err := readSums()
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	versions, err := os.Open("assets/vendor/versions")
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	defer versions.Close()
+
+	scanner := bufio.NewScanner(versions)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		pkg, version, ok := strings.Cut(line, " ")
+		if !ok {
+			log.Fatalf("versions: %q is not \"package version\"\n", line)
+		}
+
+		for _, file := range files[pkg] {
+			url := fmt.Sprintf("https://cdn.jsdelivr.net/npm/%s@%s/%s", pkg, strings.TrimSpace(version), file)
+
+			name := path.Base(file)
+
+			data, err := download(url)
+			if err != nil {
+				log.Fatalln(err)
+			}
+
+			sum := sha256.Sum256(data)
+			got := hex.EncodeToString(sum[:])
+
+			switch want, ok := sums[name]; {
+			case *pin:
+				sums[name] = got
+			case !ok:
+				log.Fatalf("%s has no pinned sum in %s, check the download and run with -pin\n", name, sumsFile)
+			case want != got:
+				log.Fatalf("%s: sha256 %s, pinned %s\n", url, got, want)
+			}
+
+			err = os.WriteFile("assets/vendor/"+name, data, 0o644)
+			if err != nil {
+				log.Fatalln(err)
+			}
+
+			log.Printf("Vendored %s\n", url)
+		}
+	}
+
+	err = scanner.Err()
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	if *pin {
+		err = writeSums(sums)
+		if err != nil {
+			log.Fatalln(err)
+		}
+	}
+}
+
+func download(url string) ([]byte, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+
+	return io.ReadAll(resp.Body)
+}
+
+// readSums returns the pinned sums by file name.
+func readSums() (map[string]string, error) {
+	data, err := os.ReadFile(sumsFile)
+	if err != nil {
+		return nil, err
+	}
+
+	sums := make(map[string]string)
+
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		sum, name, ok := strings.Cut(line, "  ")
+		if !ok {
+			return nil, fmt.Errorf("%s: %q is not \"sum  file\"", sumsFile, line)
+		}
+
+		sums[name] = sum
+	}
+
+	return sums, nil
+}
+
+// writeSums replaces the sums, keeping the comment at the top.
+func writeSums(sums map[string]string) error {
+	data, err := os.ReadFile(sumsFile)
+	if err != nil {
+		return err
+	}
+
+	var out strings.Builder
+
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.HasPrefix(line, "#") {
+			out.WriteString(line + "\n")
+		}
+	}
+
+	names := make([]string, 0, len(sums))
+	for name := range sums {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		fmt.Fprintf(&out, "%s  %s\n", sums[name], name)
+	}
+
+	return os.WriteFile(sumsFile, []byte(out.String()), 0o644)
+}
@@ -0,0 +1,301 @@
+package tmdb
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/time/rate"
+)
+
+var tracer = otel.Tracer("github.com/m4tthewde/blunt/tmdb")
+
+const DefaultBaseUrl = "https://api.themoviedb.org/3"
+
+const (
+	// AuthBearer sends the v4 read access token as a bearer token.
+	AuthBearer = "bearer"
+	// AuthApiKey sends the classic v3 key as the api_key query parameter.
+	AuthApiKey = "api_key"
+)
+
+var (
+	ErrInvalidCredentials = errors.New("tmdb: invalid credentials")
+	ErrNotFound           = errors.New("tmdb: not found")
+)
+
+// Error is returned for every non 200 response from TMDB.
+type Error struct {
+	Path          string
+	Status        int
+	StatusCode    int    `json:"status_code"`
+	StatusMessage string `json:"status_message"`
+}
+
+func (e *Error) Error() string {
+	if e.StatusMessage == "" {
+		return fmt.Sprintf("tmdb: GET %s: %d %s", e.Path, e.Status, http.StatusText(e.Status))
+	}
+
+	return fmt.Sprintf("tmdb: GET %s: %d %s", e.Path, e.Status, e.StatusMessage)
+}
+
+func (e *Error) Is(target error) bool {
+	switch target {
+	case ErrInvalidCredentials:
+		return e.Status == http.StatusUnauthorized
+	case ErrNotFound:
+		return e.Status == http.StatusNotFound
+	}
+
+	return false
+}
+
+type Options struct {
+	BaseUrl  string
+	AuthMode string
+	Token    string
+	ApiKey   string
+	// Language and Region are used when a request context carries no Locale.
+	Language  string
+	Region    string
+	CacheTTL  time.Duration
+	CacheSize int
+	// RateLimit is the number of requests per second sent to TMDB, zero
+	// disables rate limiting.
+	RateLimit float64
+	RateBurst int
+	// IncludeAdult lets searches find adult titles, family mode overrides it.
+	IncludeAdult bool
+	// Family enables family mode when set.
+	Family *FamilyOptions
+}
+
+type Client struct {
+	baseUrl       string
+	authMode      string
+	token         string
+	apiKey        string
+	defaultLocale Locale
+	httpClient    *http.Client
+	cache         *cache
+	limiter       *rate.Limiter
+	includeAdult  bool
+	// family is nil unless family mode is on.
+	family *family
+}
+
+func NewClient(options Options) *Client {
+	baseUrl := options.BaseUrl
+	if baseUrl == "" {
+		baseUrl = DefaultBaseUrl
+	}
+
+	limiter := rate.NewLimiter(rate.Inf, 0)
+	if options.RateLimit > 0 {
+		limiter = rate.NewLimiter(rate.Limit(options.RateLimit), max(options.RateBurst, 1))
+	}
+
+	client := &Client{
+		baseUrl:       strings.TrimSuffix(baseUrl, "/"),
+		authMode:      cmp.Or(options.AuthMode, AuthBearer),
+		token:         options.Token,
+		apiKey:        options.ApiKey,
+		defaultLocale: Locale{Language: options.Language, Region: options.Region},
+		httpClient:    &http.Client{},
+		cache:         newCache(options.CacheSize, options.CacheTTL),
+		limiter:       limiter,
+		includeAdult:  options.IncludeAdult,
+	}
+
+	if options.Family != nil {
+		client.family = newFamily(*options.Family)
+		client.includeAdult = false
+	}
+
+	return client
+}
+
+func (c *Client) get(ctx context.Context, path string, query url.Values, response any) error {
+	if query == nil {
+		query = url.Values{}
+	}
+
+	locale := c.locale(ctx)
+	if locale.Language != "" {
+		query.Set("language", locale.Language)
+	}
+
+	requestUrl := c.baseUrl + path
+	if len(query) != 0 {
+		requestUrl += "?" + query.Encode()
+	}
+
+	ctx, span := tracer.Start(ctx, "tmdb GET "+endpoint(path),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("tmdb.endpoint", endpoint(path)), attribute.String("tmdb.path", path)),
+	)
+	defer span.End()
+
+	body, ok := c.cache.get(requestUrl)
+	if ok {
+		cacheLookups.WithLabelValues("hit").Inc()
+		span.SetAttributes(attribute.String("tmdb.cache", "hit"))
+		slog.DebugContext(ctx, "tmdb request", "path", path, "cache", "hit")
+
+		return json.Unmarshal(body, response)
+	}
+
+	cacheLookups.WithLabelValues("miss").Inc()
+	span.SetAttributes(attribute.String("tmdb.cache", "miss"))
+
+	waitStart := time.Now()
+
+	err := c.limiter.Wait(ctx)
+	rateLimitWait.Observe(time.Since(waitStart).Seconds())
+	span.AddEvent("rate limiter passed", trace.WithAttributes(attribute.Float64("tmdb.rate_limit_wait_seconds", time.Since(waitStart).Seconds())))
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+
+	start := time.Now()
+	body, err = c.do(ctx, path, query)
+
+	recordRequest(ctx, path, err, time.Since(start))
+
+	if err != nil {
+		return err
+	}
+
+	c.cache.set(requestUrl, body)
+
+	return json.Unmarshal(body, response)
+}
+
+// recordRequest logs a request sent to TMDB and updates its metrics and
+// span. Failures are warnings, except for missing movies and people, which
+// are usually caused by a bad link.
+func recordRequest(ctx context.Context, path string, err error, duration time.Duration) {
+	status := http.StatusOK
+	level := slog.LevelDebug
+
+	var tmdbErr *Error
+
+	switch {
+	case errors.As(err, &tmdbErr):
+		status = tmdbErr.Status
+		if !errors.Is(err, ErrNotFound) {
+			level = slog.LevelWarn
+		}
+	case err != nil:
+		status = 0
+		level = slog.LevelWarn
+	}
+
+	span := trace.SpanFromContext(ctx)
+	span.SetAttributes(attribute.Int("http.response.status_code", status))
+	if err != nil && level == slog.LevelWarn {
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	upstreamRequests.WithLabelValues(endpoint(path), strconv.Itoa(status)).Inc()
+	upstreamDuration.WithLabelValues(endpoint(path)).Observe(duration.Seconds())
+
+	attrs := []any{"path", path, "cache", "miss", "status", status, "duration", duration}
+	if err != nil {
+		attrs = append(attrs, "error", err)
+	}
+
+	slog.Log(ctx, level, "tmdb request", attrs...)
+}
+
+// do sends an authenticated GET request, bypassing cache and rate limiter.
+func (c *Client) do(ctx context.Context, path string, query url.Values) ([]byte, error) {
+	q := url.Values{}
+	for key, values := range query {
+		q[key] = values
+	}
+
+	if c.authMode == AuthApiKey {
+		q.Set("api_key", c.apiKey)
+	}
+
+	requestUrl := c.baseUrl + path
+	if len(q) != 0 {
+		requestUrl += "?" + q.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", requestUrl, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.authMode == AuthBearer {
+		req.Header.Add("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		// strip the URL from the error, it may contain the api key
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+
+		return nil, fmt.Errorf("tmdb: GET %s: %w", path, err)
+	}
+
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		tmdbErr := Error{Path: path, Status: resp.StatusCode}
+		json.Unmarshal(body, &tmdbErr)
+
+		return nil, &tmdbErr
+	}
+
+	return body, nil
+}
+
+// VerifyCredentials checks the configured token or api key against TMDB's
+// /authentication endpoint. Invalid credentials match ErrInvalidCredentials.
+func (c *Client) VerifyCredentials(ctx context.Context) error {
+	body, err := c.do(ctx, "/authentication", nil)
+	if err != nil {
+		return err
+	}
+
+	var response struct {
+		Success bool `json:"success"`
+	}
+
+	err = json.Unmarshal(body, &response)
+	if err != nil {
+		return err
+	}
+
+	if !response.Success {
+		return ErrInvalidCredentials
+	}
+
+	return nil
+}
@@ -0,0 +1,309 @@
+package tmdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// FamilyOptions hide adult titles and movies certified above a threshold
+// from every search, credits list and recommendation the client returns.
+type FamilyOptions struct {
+	// Region whose certifications count, an ISO 3166-1 code.
+	Region string
+	// MaxCertification is the highest certification allowed, e.g. PG-13 in
+	// the US or 12 in DE.
+	MaxCertification string
+	// AllowUnrated lets movies without a certification in Region through.
+	AllowUnrated bool
+}
+
+const (
+	// verdicts are kept for a day, certifications hardly ever change
+	verdictTTL  = 24 * time.Hour
+	verdictSize = 20000
+	// concurrency of the certification lookups of a single list
+	familyConcurrency = 8
+	// familyLookups caps the certification lookups of a single list. Items
+	// without a verdict beyond it stay hidden and are counted as pending
+	// until a later request looked them up, long filmographies fill in over
+	// a few requests instead of costing hundreds of TMDB calls at once. It's
+	// the size of a page of TMDB results, so search results and
+	// recommendations are always checked completely.
+	familyLookups = 20
+)
+
+// family decides which movies are suitable and remembers its verdicts.
+type family struct {
+	options  FamilyOptions
+	verdicts *cache
+
+	mu sync.Mutex
+	// order of the certifications of the region, loaded on first use
+	order map[string]int
+	// permissive when the threshold is the region's highest certification
+	// and unrated movies are allowed, only adult titles are hidden then
+	permissive bool
+}
+
+func newFamily(options FamilyOptions) *family {
+	return &family{options: options, verdicts: newCache(verdictSize, verdictTTL)}
+}
+
+type CertificationsResponse struct {
+	Certifications map[string][]Certification `json:"certifications"`
+}
+
+type Certification struct {
+	Certification string `json:"certification"`
+	Meaning       string `json:"meaning"`
+	// Order is higher for certifications of older audiences.
+	Order int `json:"order"`
+}
+
+// Certifications returns the movie certifications of every country.
+func (c *Client) Certifications(ctx context.Context) (*CertificationsResponse, error) {
+	var response CertificationsResponse
+	err := c.get(ctx, "/certification/movie/list", nil, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	return &response, nil
+}
+
+// CheckFamily makes sure the certification threshold of family mode
+// exists, it does nothing when family mode is off.
+func (c *Client) CheckFamily(ctx context.Context) error {
+	if c.family == nil {
+		return nil
+	}
+
+	_, err := c.certificationOrder(ctx)
+	return err
+}
+
+func (c *Client) certificationOrder(ctx context.Context) (map[string]int, error) {
+	c.family.mu.Lock()
+	defer c.family.mu.Unlock()
+
+	if c.family.order != nil {
+		return c.family.order, nil
+	}
+
+	response, err := c.Certifications(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	order := make(map[string]int)
+	for _, certification := range response.Certifications[c.family.options.Region] {
+		order[certification.Certification] = certification.Order
+	}
+
+	_, ok := order[c.family.options.MaxCertification]
+	if !ok {
+		return nil, fmt.Errorf("tmdb: %q is not a movie certification of %s", c.family.options.MaxCertification, c.family.options.Region)
+	}
+
+	c.family.order = order
+	c.family.permissive = c.family.options.AllowUnrated
+	for _, rank := range order {
+		if rank > order[c.family.options.MaxCertification] {
+			c.family.permissive = false
+		}
+	}
+
+	return order, nil
+}
+
+// permissive reports whether family mode only hides adult titles, there's
+// no need to look up certifications then.
+func (c *Client) permissive(ctx context.Context) (bool, error) {
+	_, err := c.certificationOrder(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	c.family.mu.Lock()
+	defer c.family.mu.Unlock()
+
+	return c.family.permissive, nil
+}
+
+// suitable reports whether family mode lets a movie through, the most
+// restrictive of its certifications in the region counts.
+func (c *Client) suitable(ctx context.Context, movieId int64) (bool, error) {
+	allowed, ok := c.verdict(movieId)
+	if ok {
+		return allowed, nil
+	}
+
+	permissive, err := c.permissive(ctx)
+	if err != nil || permissive {
+		return permissive, err
+	}
+
+	order, err := c.certificationOrder(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	key := strconv.FormatInt(movieId, 10)
+
+	dates, err := c.ReleaseDates(ctx, key)
+	if err != nil {
+		return false, err
+	}
+
+	rated := false
+	highest := 0
+
+	for _, date := range dates.Country(c.family.options.Region) {
+		rank, ok := order[date.Certification]
+		if !ok {
+			// empty or unknown certifications say nothing
+			continue
+		}
+
+		rated = true
+		highest = max(highest, rank)
+	}
+
+	allowed = c.family.options.AllowUnrated
+	if rated {
+		allowed = highest <= order[c.family.options.MaxCertification]
+	}
+
+	verdict := []byte{0}
+	if allowed {
+		verdict[0] = 1
+	}
+
+	c.family.verdicts.set(key, verdict)
+
+	return allowed, nil
+}
+
+// verdict returns a remembered verdict about a movie.
+func (c *Client) verdict(movieId int64) (allowed bool, ok bool) {
+	verdict, ok := c.family.verdicts.get(strconv.FormatInt(movieId, 10))
+	if !ok {
+		return false, false
+	}
+
+	return verdict[0] == 1, true
+}
+
+// familyFilter keeps the suitable items in their order. id and adult
+// describe an item, adult ones never pass. Remembered verdicts are used as
+// they are, at most familyLookups others are looked up and the rest is
+// hidden for now and counted as pending.
+func familyFilter[T any](ctx context.Context, c *Client, items []T, id func(T) int64, adult func(T) bool) ([]T, int, error) {
+	if c.family == nil {
+		return items, 0, nil
+	}
+
+	permissive, err := c.permissive(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	verdicts := make(map[int64]bool)
+	pending := make([]int64, 0)
+
+	for _, item := range items {
+		if adult(item) || permissive {
+			continue
+		}
+
+		_, known := verdicts[id(item)]
+		if known {
+			continue
+		}
+
+		allowed, ok := c.verdict(id(item))
+		if ok {
+			verdicts[id(item)] = allowed
+		} else if len(pending) < familyLookups && !slices.Contains(pending, id(item)) {
+			pending = append(pending, id(item))
+		}
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var firstErr error
+
+	slots := make(chan struct{}, familyConcurrency)
+
+	for _, movieId := range pending {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			allowed, err := c.suitable(ctx, movieId)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			// movies TMDB doesn't know anymore aren't worth failing for
+			if err != nil && !errors.Is(err, ErrNotFound) {
+				if firstErr == nil {
+					firstErr = err
+				}
+				return
+			}
+
+			verdicts[movieId] = allowed
+		}()
+	}
+
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, 0, firstErr
+	}
+
+	kept := make([]T, 0, len(items))
+	pendingItems := 0
+
+	for _, item := range items {
+		if adult(item) {
+			continue
+		}
+
+		allowed, checked := verdicts[id(item)]
+		switch {
+		case permissive || allowed:
+			kept = append(kept, item)
+		case !checked:
+			pendingItems++
+		}
+	}
+
+	return kept, pendingItems, nil
+}
+
+// familyPeople drops adult people, their certifications don't matter.
+func familyPeople[T any](c *Client, people []T, adult func(T) bool) []T {
+	if c.family == nil {
+		return people
+	}
+
+	kept := make([]T, 0, len(people))
+	for _, person := range people {
+		if !adult(person) {
+			kept = append(kept, person)
+		}
+	}
+
+	return kept
+}
@@ -0,0 +1,143 @@
+package tmdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// fakeFamily serves a person with credits of movies 1 to credits, every
+// third rated R and the others PG, plus an adult title.
+func fakeFamily(t *testing.T, credits int, lookups *atomic.Int32) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var response any
+
+		switch {
+		case r.URL.Path == "/certification/movie/list":
+			response = CertificationsResponse{Certifications: map[string][]Certification{
+				"US": {{Certification: "G", Order: 1}, {Certification: "PG", Order: 2}, {Certification: "PG-13", Order: 3}, {Certification: "R", Order: 4}},
+			}}
+		case r.URL.Path == "/person/1/movie_credits":
+			cast := []PeopleCredit{{Id: 1000, Title: "Adult", Adult: true}}
+			for id := 1; id <= credits; id++ {
+				cast = append(cast, PeopleCredit{Id: int64(id), Title: fmt.Sprintf("Movie %d", id)})
+			}
+			// a second role in the first movie
+			cast = append(cast, PeopleCredit{Id: 1, Title: "Movie 1"})
+
+			response = PeopleCreditsResponse{Cast: cast}
+		case strings.HasSuffix(r.URL.Path, "/release_dates"):
+			lookups.Add(1)
+
+			id, _ := strconv.Atoi(strings.Split(r.URL.Path, "/")[2])
+			certification := "PG"
+			if id%3 == 0 {
+				certification = "R"
+			}
+
+			response = map[string]any{"results": []map[string]any{{
+				"iso_3166_1":    "US",
+				"release_dates": []map[string]any{{"certification": certification, "type": ReleaseTheatrical}},
+			}}}
+		default:
+			http.NotFound(w, r)
+			return
+		}
+
+		json.NewEncoder(w).Encode(response)
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestFamilyFilterCapsLookups(t *testing.T) {
+	var lookups atomic.Int32
+
+	server := fakeFamily(t, 50, &lookups)
+	client := NewClient(Options{
+		BaseUrl: server.URL,
+		Family:  &FamilyOptions{Region: "US", MaxCertification: "PG-13"},
+	})
+
+	shown := 0
+
+	for request := 1; request <= 3; request++ {
+		credits, err := client.PeopleCredits(context.Background(), "1")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := lookups.Load(); got > int32(request*familyLookups) {
+			t.Fatalf("request %d: %d certification lookups, want at most %d", request, got, request*familyLookups)
+		}
+
+		if len(credits.Cast) < shown {
+			t.Fatalf("request %d: %d credits shown, %d before", request, len(credits.Cast), shown)
+		}
+		shown = len(credits.Cast)
+
+		// every movie is either checked or counted as pending
+		checked := min(int(lookups.Load()), 50)
+		if credits.Pending != 50-checked {
+			t.Errorf("request %d: %d credits pending, want %d", request, credits.Pending, 50-checked)
+		}
+
+		for _, credit := range credits.Cast {
+			if credit.Adult || credit.Id%3 == 0 {
+				t.Errorf("request %d: %s is not suitable", request, credit.Title)
+			}
+		}
+	}
+
+	// once every movie has a verdict, all suitable ones are shown: the 34
+	// movies rated PG and the second role
+	if shown != 34+1 {
+		t.Errorf("%d credits shown, want 35", shown)
+	}
+
+	if got := lookups.Load(); got != 50 {
+		t.Errorf("%d certification lookups, want one per movie", got)
+	}
+
+	// from then on every request gives the same answer
+	credits, err := client.PeopleCredits(context.Background(), "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(credits.Cast) != shown || credits.Pending != 0 || lookups.Load() != 50 {
+		t.Errorf("%d credits shown, %d pending after %d lookups", len(credits.Cast), credits.Pending, lookups.Load())
+	}
+}
+
+func TestFamilyFilterPermissive(t *testing.T) {
+	var lookups atomic.Int32
+
+	server := fakeFamily(t, 50, &lookups)
+	client := NewClient(Options{
+		BaseUrl: server.URL,
+		Family:  &FamilyOptions{Region: "US", MaxCertification: "R", AllowUnrated: true},
+	})
+
+	credits, err := client.PeopleCredits(context.Background(), "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(credits.Cast) != 50+1 {
+		t.Errorf("%d credits shown, want all but the adult title", len(credits.Cast))
+	}
+
+	if got := lookups.Load(); got != 0 {
+		t.Errorf("%d certification lookups, want none", got)
+	}
+}
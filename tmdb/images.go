@@ -0,0 +1,236 @@
+package tmdb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+const (
+	PosterImage   = "poster"
+	ProfileImage  = "profile"
+	BackdropImage = "backdrop"
+	LogoImage     = "logo"
+)
+
+// PlaceholderUrl is shown for movies and people without an image, it is
+// served by the imageproxy package.
+const PlaceholderUrl = "/img/placeholder.svg"
+
+type ImageConfiguration struct {
+	SecureBaseUrl string   `json:"secure_base_url"`
+	PosterSizes   []string `json:"poster_sizes"`
+	ProfileSizes  []string `json:"profile_sizes"`
+	BackdropSizes []string `json:"backdrop_sizes"`
+	LogoSizes     []string `json:"logo_sizes"`
+}
+
+type configurationResponse struct {
+	Images ImageConfiguration `json:"images"`
+}
+
+// defaultImageConfiguration mirrors TMDB's /configuration and is used until
+// LoadImageConfiguration succeeds.
+var defaultImageConfiguration = ImageConfiguration{
+	SecureBaseUrl: "https://image.tmdb.org/t/p/",
+	PosterSizes:   []string{"w92", "w154", "w185", "w342", "w500", "w780", "original"},
+	ProfileSizes:  []string{"w45", "w185", "h632", "original"},
+	BackdropSizes: []string{"w300", "w780", "w1280", "original"},
+	LogoSizes:     []string{"w45", "w92", "w154", "w185", "w300", "w500", "original"},
+}
+
+var imageConfiguration atomic.Pointer[ImageConfiguration]
+
+var (
+	proxyPrefix string
+	proxyResize bool
+)
+
+// ResizeWidths are the widths the image proxy resizes to: the widths pages
+// display images at, for screens of 1x, 2x and 3x density. Any other width
+// is rounded up, so the proxy only ever produces these.
+var ResizeWidths = []int{30, 45, 60, 90, 120, 135, 180, 240, 270, 360, 540}
+
+// ResizeWidth returns the smallest of ResizeWidths that is at least width,
+// falling back to the largest.
+func ResizeWidth(width int) int {
+	for _, w := range ResizeWidths {
+		if w >= width {
+			return w
+		}
+	}
+
+	return ResizeWidths[len(ResizeWidths)-1]
+}
+
+// ResizedSize names an image of kind resized by the image proxy to width,
+// like "profile-w90". The kind tells the proxy which TMDB sizes exist to
+// resize from.
+func ResizedSize(kind string, width int) string {
+	switch kind {
+	case ProfileImage, BackdropImage, LogoImage:
+	default:
+		kind = PosterImage
+	}
+
+	return kind + "-w" + strconv.Itoa(width)
+}
+
+func init() {
+	imageConfiguration.Store(&defaultImageConfiguration)
+}
+
+// LoadImageConfiguration fetches image base URL and sizes from TMDB and
+// makes them available to ImageUrl and ImageSrcset.
+func (c *Client) LoadImageConfiguration(ctx context.Context) error {
+	body, err := c.do(ctx, "/configuration", nil)
+	if err != nil {
+		return err
+	}
+
+	var response configurationResponse
+	err = json.Unmarshal(body, &response)
+	if err != nil {
+		return err
+	}
+
+	if response.Images.SecureBaseUrl == "" {
+		return fmt.Errorf("tmdb: /configuration returned no image base url")
+	}
+
+	imageConfiguration.Store(&response.Images)
+
+	return nil
+}
+
+// UseImageProxy makes ImageUrl and ImageSrcset point at a local image proxy
+// mounted at prefix. With resize the proxy is asked for the exact widths a
+// page displays instead of TMDB's fixed sizes.
+func UseImageProxy(prefix string, resize bool) {
+	proxyPrefix = prefix
+	proxyResize = resize
+}
+
+// Images returns the current image configuration.
+func Images() ImageConfiguration {
+	return *imageConfiguration.Load()
+}
+
+// IsImageSize reports whether TMDB serves size for any kind of image.
+func (i ImageConfiguration) IsImageSize(size string) bool {
+	return slices.Contains(i.PosterSizes, size) ||
+		slices.Contains(i.ProfileSizes, size) ||
+		slices.Contains(i.BackdropSizes, size) ||
+		slices.Contains(i.LogoSizes, size)
+}
+
+// ImageSize returns the smallest TMDB size of kind that is at least width
+// pixels wide, falling back to the largest one.
+func ImageSize(kind string, width int) string {
+	images := imageConfiguration.Load()
+
+	size := "original"
+	for _, s := range widths(images.sizes(kind)) {
+		size = s.name
+		if s.width >= width {
+			break
+		}
+	}
+
+	return size
+}
+
+// ImageUrl returns the URL of an image of kind that is at least width pixels
+// wide, or a placeholder when there is no image.
+func ImageUrl(kind string, path string, width int) string {
+	if path == "" {
+		return PlaceholderUrl
+	}
+
+	if proxyPrefix != "" && proxyResize {
+		return proxyPrefix + ResizedSize(kind, ResizeWidth(width)) + path
+	}
+
+	return imageBaseUrl() + ImageSize(kind, width) + path
+}
+
+// ImageSrcset lists the available resolutions of an image displayed width
+// pixels wide for the srcset attribute, so browsers can pick the right one
+// for their screen.
+func ImageSrcset(kind string, path string, width int) string {
+	if path == "" {
+		return ""
+	}
+
+	candidates := make([]string, 0)
+
+	if proxyPrefix != "" && proxyResize {
+		for density := 1; density <= 3; density++ {
+			resized := ResizeWidth(width * density)
+			candidate := fmt.Sprintf("%s%s%s %dw", proxyPrefix, ResizedSize(kind, resized), path, resized)
+
+			if !slices.Contains(candidates, candidate) {
+				candidates = append(candidates, candidate)
+			}
+		}
+
+		return strings.Join(candidates, ", ")
+	}
+
+	images := imageConfiguration.Load()
+	for _, s := range widths(images.sizes(kind)) {
+		candidates = append(candidates, fmt.Sprintf("%s%s%s %dw", imageBaseUrl(), s.name, path, s.width))
+	}
+
+	return strings.Join(candidates, ", ")
+}
+
+func imageBaseUrl() string {
+	if proxyPrefix != "" {
+		return proxyPrefix
+	}
+
+	return imageConfiguration.Load().SecureBaseUrl
+}
+
+func (i ImageConfiguration) sizes(kind string) []string {
+	switch kind {
+	case ProfileImage:
+		return i.ProfileSizes
+	case BackdropImage:
+		return i.BackdropSizes
+	case LogoImage:
+		return i.LogoSizes
+	}
+
+	return i.PosterSizes
+}
+
+type imageSize struct {
+	name  string
+	width int
+}
+
+// widths keeps the "w<width>" sizes, TMDB lists them in ascending order.
+func widths(sizes []string) []imageSize {
+	result := make([]imageSize, 0)
+
+	for _, size := range sizes {
+		if !strings.HasPrefix(size, "w") {
+			continue
+		}
+
+		width, err := strconv.Atoi(size[1:])
+		if err != nil {
+			continue
+		}
+
+		result = append(result, imageSize{name: size, width: width})
+	}
+
+	return result
+}
@@ -0,0 +1,33 @@
+package tmdb
+
+import "testing"
+
+func TestImageUrlThroughResizingProxy(t *testing.T) {
+	UseImageProxy("/img/", true)
+	t.Cleanup(func() { UseImageProxy("", false) })
+
+	tests := []struct {
+		kind   string
+		width  int
+		url    string
+		srcset string
+	}{
+		{PosterImage, 90, "/img/poster-w90/a.jpg", "/img/poster-w90/a.jpg 90w, /img/poster-w180/a.jpg 180w, /img/poster-w270/a.jpg 270w"},
+		{ProfileImage, 100, "/img/profile-w120/a.jpg", "/img/profile-w120/a.jpg 120w, /img/profile-w240/a.jpg 240w, /img/profile-w360/a.jpg 360w"},
+		// the largest width is shared by every density above it
+		{BackdropImage, 500, "/img/backdrop-w540/a.jpg", "/img/backdrop-w540/a.jpg 540w"},
+		{"", 30, "/img/poster-w30/a.jpg", "/img/poster-w30/a.jpg 30w, /img/poster-w60/a.jpg 60w, /img/poster-w90/a.jpg 90w"},
+	}
+
+	for _, test := range tests {
+		url := ImageUrl(test.kind, "/a.jpg", test.width)
+		if url != test.url {
+			t.Errorf("%s at %d: url %q, want %q", test.kind, test.width, url, test.url)
+		}
+
+		srcset := ImageSrcset(test.kind, "/a.jpg", test.width)
+		if srcset != test.srcset {
+			t.Errorf("%s at %d: srcset %q, want %q", test.kind, test.width, srcset, test.srcset)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package tmdb
+
+import "context"
+
+// Locale selects the language of TMDB content and the region used for
+// release dates and search results.
+type Locale struct {
+	Language string
+	Region   string
+}
+
+type localeKey struct{}
+
+// WithLocale attaches a locale to ctx, every client call made with the
+// returned context uses it instead of the client's default locale.
+func WithLocale(ctx context.Context, locale Locale) context.Context {
+	return context.WithValue(ctx, localeKey{}, locale)
+}
+
+func (c *Client) locale(ctx context.Context) Locale {
+	locale, _ := ctx.Value(localeKey{}).(Locale)
+
+	if locale.Language == "" {
+		locale.Language = c.defaultLocale.Language
+	}
+
+	if locale.Region == "" {
+		locale.Region = c.defaultLocale.Region
+	}
+
+	return locale
+}
+
+// Region returns the region calls made with ctx use.
+func (c *Client) Region(ctx context.Context) string {
+	return c.locale(ctx).Region
+}
@@ -0,0 +1,45 @@
+package tmdb
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	upstreamRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "blunt_tmdb_requests_total",
+		Help: "Requests sent to TMDB by endpoint and status code, 0 when no response was received.",
+	}, []string{"endpoint", "status"})
+
+	upstreamDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "blunt_tmdb_request_duration_seconds",
+		Help:    "Time TMDB took to answer by endpoint.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"endpoint"})
+
+	cacheLookups = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "blunt_tmdb_cache_lookups_total",
+		Help: "Lookups in the TMDB response cache by result, hit or miss.",
+	}, []string{"result"})
+
+	rateLimitWait = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "blunt_tmdb_rate_limit_wait_seconds",
+		Help:    "Time requests waited for the TMDB rate limiter.",
+		Buckets: []float64{0.001, 0.005, 0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+	})
+)
+
+var idPattern = regexp.MustCompile(`/[0-9]+`)
+
+// endpoint replaces ids in a path, so all movies share one time series.
+func endpoint(path string) string {
+	// external ids aren't numeric
+	if strings.HasPrefix(path, "/find/") {
+		return "/find/{id}"
+	}
+
+	return idPattern.ReplaceAllString(path, "/{id}")
+}
@@ -1,20 +1,23 @@
 package tmdb
 
 import (
+	"cmp"
 	"context"
-	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
 	"strings"
 )
 
 type MovieSearchResult struct {
+	Title         string  `json:"title"`
 	OriginalTitle string  `json:"original_title"`
 	PosterPath    string  `json:"poster_path"`
 	Popularity    float64 `json:"popularity"`
 	ReleaseDate   string  `json:"release_date"`
 	Id            int64   `json:"id"`
+	Adult         bool    `json:"adult"`
 }
 
 type MovieSearchResponse struct {
@@ -26,6 +29,7 @@ type PeopleSearchResult struct {
 	ProfilePath string  `json:"profile_path"`
 	Popularity  float64 `json:"popularity"`
 	Id          int64   `json:"id"`
+	Adult       bool    `json:"adult"`
 }
 
 type PeopleSearchResponse struct {
@@ -34,6 +38,7 @@ type PeopleSearchResponse struct {
 
 type MovieDetailsResponse struct {
 	Id               int64   `json:"id"`
+	Title            string  `json:"title"`
 	OriginalTitle    string  `json:"original_title"`
 	PosterPath       string  `json:"poster_path"`
 	Popularity       float64 `json:"popularity"`
@@ -43,6 +48,8 @@ type MovieDetailsResponse struct {
 	OriginalLanguage string  `json:"original_language"`
 	Overview         string  `json:"overview"`
 	Revenue          int64   `json:"revenue"`
+	ImdbId           string  `json:"imdb_id"`
+	Adult            bool    `json:"adult"`
 }
 
 type MovieCreditsResponse struct {
@@ -56,6 +63,7 @@ type MovieCastMember struct {
 	Character   string  `json:"character"`
 	ProfilePath string  `json:"profile_path"`
 	Popularity  float64 `json:"popularity"`
+	Adult       bool    `json:"adult"`
 }
 
 type PeopleResponse struct {
@@ -68,210 +76,408 @@ type PeopleResponse struct {
 	KnownForDepartment string `json:"known_for_department"`
 	Homepage           string `json:"homepage"`
 	PlaceOfBirth       string `json:"place_of_birth"`
+	Adult              bool   `json:"adult"`
 }
 
 type PeopleCreditsResponse struct {
 	Cast []PeopleCredit `json:"cast"`
 	Id   int64          `json:"id"`
+	// Pending counts the credits family mode hides until their
+	// certification is looked up by a later request.
+	Pending int `json:"-"`
 }
 
 type PeopleCredit struct {
 	Id            int64   `json:"id"`
+	Title         string  `json:"title"`
 	OriginalTitle string  `json:"original_title"`
+	Character     string  `json:"character"`
 	PosterPath    string  `json:"poster_path"`
 	ReleaseDate   string  `json:"release_date"`
 	Popularity    float64 `json:"popularity"`
+	Adult         bool    `json:"adult"`
 }
 
-func SearchMovies(ctx context.Context, token string, search string) (*MovieSearchResponse, error) {
-	client := http.Client{}
-
-	req, err := http.NewRequestWithContext(ctx, "GET", "https://api.themoviedb.org/3/search/movie", nil)
-	if err != nil {
-		return nil, err
-	}
+func movieResultId(result MovieSearchResult) int64 {
+	return result.Id
+}
 
-	req.Header.Add("Authorization", "Bearer "+token)
+func movieResultAdult(result MovieSearchResult) bool {
+	return result.Adult
+}
 
-	q := req.URL.Query()
+func (c *Client) SearchMovies(ctx context.Context, search string) (*MovieSearchResponse, error) {
+	q := url.Values{}
 
-	q.Add("language", "en-US")
 	q.Add("page", "1")
 	q.Add("query", search)
+	q.Add("include_adult", strconv.FormatBool(c.includeAdult))
 
-	req.URL.RawQuery = q.Encode()
+	locale := c.locale(ctx)
+	if locale.Region != "" {
+		q.Add("region", locale.Region)
+	}
 
-	resp, err := client.Do(req)
+	var response MovieSearchResponse
+	err := c.get(ctx, "/search/movie", q, &response)
 	if err != nil {
 		return nil, err
 	}
 
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
+	response.Results, _, err = familyFilter(ctx, c, response.Results, movieResultId, movieResultAdult)
 	if err != nil {
 		return nil, err
 	}
 
-	var response MovieSearchResponse
-	json.Unmarshal(body, &response)
-
 	return &response, nil
 }
 
-func SearchPeople(ctx context.Context, token string, search string) (*PeopleSearchResponse, error) {
-	client := http.Client{}
+// SearchMovieTitle searches movies released in year, or in any year when
+// it's zero.
+func (c *Client) SearchMovieTitle(ctx context.Context, title string, year int) (*MovieSearchResponse, error) {
+	q := url.Values{}
 
-	req, err := http.NewRequestWithContext(ctx, "GET", "https://api.themoviedb.org/3/search/person", nil)
+	q.Add("page", "1")
+	q.Add("query", title)
+	q.Add("include_adult", strconv.FormatBool(c.includeAdult))
+
+	if year != 0 {
+		q.Add("year", strconv.Itoa(year))
+	}
+
+	var response MovieSearchResponse
+	err := c.get(ctx, "/search/movie", q, &response)
 	if err != nil {
 		return nil, err
 	}
 
-	req.Header.Add("Authorization", "Bearer "+token)
+	response.Results, _, err = familyFilter(ctx, c, response.Results, movieResultId, movieResultAdult)
+	if err != nil {
+		return nil, err
+	}
 
-	q := req.URL.Query()
+	return &response, nil
+}
+
+func (c *Client) SearchPeople(ctx context.Context, search string) (*PeopleSearchResponse, error) {
+	q := url.Values{}
 
-	q.Add("language", "en-US")
 	q.Add("page", "1")
 	q.Add("query", search)
+	q.Add("include_adult", strconv.FormatBool(c.includeAdult))
 
-	req.URL.RawQuery = q.Encode()
-
-	resp, err := client.Do(req)
+	var response PeopleSearchResponse
+	err := c.get(ctx, "/search/person", q, &response)
 	if err != nil {
 		return nil, err
 	}
 
-	defer resp.Body.Close()
+	response.Results = familyPeople(c, response.Results, func(person PeopleSearchResult) bool { return person.Adult })
+
+	return &response, nil
+}
 
-	body, err := io.ReadAll(resp.Body)
+func (c *Client) MovieDetails(ctx context.Context, movieId string) (*MovieDetailsResponse, error) {
+	var response MovieDetailsResponse
+	err := c.get(ctx, fmt.Sprintf("/movie/%s", movieId), nil, &response)
 	if err != nil {
 		return nil, err
 	}
 
-	var response PeopleSearchResponse
-	json.Unmarshal(body, &response)
+	// hidden movies don't exist as far as family mode is concerned
+	if c.family != nil {
+		allowed, err := c.suitable(ctx, response.Id)
+		if err != nil {
+			return nil, err
+		}
+
+		if response.Adult || !allowed {
+			return nil, ErrNotFound
+		}
+	}
 
 	return &response, nil
 }
 
-func MovieDetails(ctx context.Context, token string, movieId string) (*MovieDetailsResponse, error) {
-	client := http.Client{}
-
-	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("https://api.themoviedb.org/3/movie/%s", movieId), nil)
+func (c *Client) Credits(ctx context.Context, movieId string) (*MovieCreditsResponse, error) {
+	var response MovieCreditsResponse
+	err := c.get(ctx, fmt.Sprintf("/movie/%s/credits", movieId), nil, &response)
 	if err != nil {
 		return nil, err
 	}
 
-	req.Header.Add("Authorization", "Bearer "+token)
+	response.Cast = familyPeople(c, response.Cast, func(member MovieCastMember) bool { return member.Adult })
 
-	resp, err := client.Do(req)
+	return &response, nil
+}
+
+func (c *Client) People(ctx context.Context, personId string) (*PeopleResponse, error) {
+	var response PeopleResponse
+	err := c.get(ctx, fmt.Sprintf("/person/%s", personId), nil, &response)
 	if err != nil {
 		return nil, err
 	}
 
-	defer resp.Body.Close()
+	if c.family != nil && response.Adult {
+		return nil, ErrNotFound
+	}
+
+	return &response, nil
+}
 
-	body, err := io.ReadAll(resp.Body)
+func (c *Client) PeopleCredits(ctx context.Context, personId string) (*PeopleCreditsResponse, error) {
+	var response PeopleCreditsResponse
+	err := c.get(ctx, fmt.Sprintf("/person/%s/movie_credits", personId), nil, &response)
 	if err != nil {
 		return nil, err
 	}
 
-	var response MovieDetailsResponse
-	json.Unmarshal(body, &response)
+	response.Cast, response.Pending, err = familyFilter(ctx, c, response.Cast,
+		func(credit PeopleCredit) int64 { return credit.Id },
+		func(credit PeopleCredit) bool { return credit.Adult },
+	)
+	if err != nil {
+		return nil, err
+	}
 
 	return &response, nil
 }
 
-func Credits(ctx context.Context, token string, movieId string) (*MovieCreditsResponse, error) {
-	client := http.Client{}
-
-	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("https://api.themoviedb.org/3/movie/%s/credits", movieId), nil)
+// Recommendations returns the movies TMDB recommends to people who liked
+// the movie, most relevant first.
+func (c *Client) Recommendations(ctx context.Context, movieId string) (*MovieSearchResponse, error) {
+	var response MovieSearchResponse
+	err := c.get(ctx, fmt.Sprintf("/movie/%s/recommendations", movieId), url.Values{"page": {"1"}}, &response)
 	if err != nil {
 		return nil, err
 	}
 
-	req.Header.Add("Authorization", "Bearer "+token)
-
-	resp, err := client.Do(req)
+	response.Results, _, err = familyFilter(ctx, c, response.Results, movieResultId, movieResultAdult)
 	if err != nil {
 		return nil, err
 	}
 
-	defer resp.Body.Close()
+	return &response, nil
+}
 
-	body, err := io.ReadAll(resp.Body)
+// Similar returns the movies sharing genres and keywords with the movie.
+func (c *Client) Similar(ctx context.Context, movieId string) (*MovieSearchResponse, error) {
+	var response MovieSearchResponse
+	err := c.get(ctx, fmt.Sprintf("/movie/%s/similar", movieId), url.Values{"page": {"1"}}, &response)
 	if err != nil {
 		return nil, err
 	}
 
-	var response MovieCreditsResponse
-	json.Unmarshal(body, &response)
+	response.Results, _, err = familyFilter(ctx, c, response.Results, movieResultId, movieResultAdult)
+	if err != nil {
+		return nil, err
+	}
 
 	return &response, nil
 }
 
-func People(ctx context.Context, token string, personId string) (*PeopleResponse, error) {
-	client := http.Client{}
+// Release types of /movie/{id}/release_dates.
+const (
+	ReleasePremiere          = 1
+	ReleaseTheatricalLimited = 2
+	ReleaseTheatrical        = 3
+	ReleaseDigital           = 4
+	ReleasePhysical          = 5
+	ReleaseTV                = 6
+)
+
+type ReleaseDatesResponse struct {
+	Id      int64                 `json:"id"`
+	Results []CountryReleaseDates `json:"results"`
+}
+
+type CountryReleaseDates struct {
+	// Country is an ISO 3166-1 code.
+	Country      string        `json:"iso_3166_1"`
+	ReleaseDates []ReleaseDate `json:"release_dates"`
+}
+
+type ReleaseDate struct {
+	Certification string `json:"certification"`
+	Note          string `json:"note"`
+	// ReleaseDate is an ISO 8601 timestamp, midnight UTC of the local date.
+	ReleaseDate string `json:"release_date"`
+	Type        int    `json:"type"`
+}
+
+// Date returns the day of the release in the DateOnly layout.
+func (r ReleaseDate) Date() string {
+	date, _, _ := strings.Cut(r.ReleaseDate, "T")
+	return date
+}
+
+// Country returns the release dates in a country, nil when TMDB knows none.
+func (r *ReleaseDatesResponse) Country(country string) []ReleaseDate {
+	for _, result := range r.Results {
+		if strings.EqualFold(result.Country, country) {
+			return result.ReleaseDates
+		}
+	}
 
-	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("https://api.themoviedb.org/3/person/%s", personId), nil)
+	return nil
+}
+
+// CertificationOf returns the certification of the theatrical release, or
+// of any other release when it has none.
+func CertificationOf(dates []ReleaseDate) string {
+	for _, releaseType := range []int{ReleaseTheatrical, ReleaseTheatricalLimited, ReleasePremiere, ReleaseDigital, ReleasePhysical, ReleaseTV} {
+		for _, date := range dates {
+			if date.Type == releaseType && date.Certification != "" {
+				return date.Certification
+			}
+		}
+	}
+
+	return ""
+}
+
+// ReleaseDates returns the release dates and certifications of a movie in
+// every country TMDB knows them for.
+func (c *Client) ReleaseDates(ctx context.Context, movieId string) (*ReleaseDatesResponse, error) {
+	var response ReleaseDatesResponse
+	err := c.get(ctx, fmt.Sprintf("/movie/%s/release_dates", movieId), nil, &response)
 	if err != nil {
 		return nil, err
 	}
 
-	req.Header.Add("Authorization", "Bearer "+token)
+	return &response, nil
+}
+
+type WatchProvidersResponse struct {
+	Id int64 `json:"id"`
+	// Results are keyed by ISO 3166-1 country code.
+	Results map[string]RegionProviders `json:"results"`
+}
 
-	resp, err := client.Do(req)
+// RegionProviders are the offers of a movie in a region by kind of offer.
+type RegionProviders struct {
+	// Link is TMDB's page of the offers, which links to the providers.
+	Link     string     `json:"link"`
+	Flatrate []Provider `json:"flatrate"`
+	Rent     []Provider `json:"rent"`
+	Buy      []Provider `json:"buy"`
+	Free     []Provider `json:"free"`
+	Ads      []Provider `json:"ads"`
+}
+
+type Provider struct {
+	ProviderId      int64  `json:"provider_id"`
+	ProviderName    string `json:"provider_name"`
+	LogoPath        string `json:"logo_path"`
+	DisplayPriority int    `json:"display_priority"`
+}
+
+type ProvidersResponse struct {
+	Results []Provider `json:"results"`
+}
+
+// Offers reports whether any of the providers offers the movie in any way.
+func (r RegionProviders) Offers(providers map[int64]bool) bool {
+	for _, offers := range [][]Provider{r.Flatrate, r.Free, r.Ads, r.Rent, r.Buy} {
+		for _, provider := range offers {
+			if providers[provider.ProviderId] {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// WatchProviders returns where a movie can be streamed, rented or bought,
+// by region. The data comes from JustWatch, which has to be credited.
+func (c *Client) WatchProviders(ctx context.Context, movieId string) (*WatchProvidersResponse, error) {
+	var response WatchProvidersResponse
+	err := c.get(ctx, fmt.Sprintf("/movie/%s/watch/providers", movieId), nil, &response)
 	if err != nil {
 		return nil, err
 	}
 
-	defer resp.Body.Close()
+	return &response, nil
+}
 
-	body, err := io.ReadAll(resp.Body)
+// MovieProviders returns the providers offering movies in region, most
+// prominent first.
+func (c *Client) MovieProviders(ctx context.Context, region string) (*ProvidersResponse, error) {
+	var response ProvidersResponse
+	err := c.get(ctx, "/watch/providers/movie", url.Values{"watch_region": {region}}, &response)
 	if err != nil {
 		return nil, err
 	}
 
-	var response PeopleResponse
-	json.Unmarshal(body, &response)
+	slices.SortStableFunc(response.Results, func(a, b Provider) int {
+		return cmp.Or(cmp.Compare(a.DisplayPriority, b.DisplayPriority), cmp.Compare(a.ProviderName, b.ProviderName))
+	})
 
 	return &response, nil
 }
 
-func PeopleCredits(ctx context.Context, token string, personId string) (*PeopleCreditsResponse, error) {
-	client := http.Client{}
+// External id sources of /find.
+const (
+	ImdbSource     = "imdb_id"
+	WikidataSource = "wikidata_id"
+)
+
+type FindResponse struct {
+	MovieResults  []MovieSearchResult  `json:"movie_results"`
+	PersonResults []PeopleSearchResult `json:"person_results"`
+}
+
+// Find looks up movies and people by their id on another site.
+func (c *Client) Find(ctx context.Context, externalId string, source string) (*FindResponse, error) {
+	q := url.Values{}
+	q.Add("external_source", source)
 
-	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("https://api.themoviedb.org/3/person/%s/movie_credits", personId), nil)
+	var response FindResponse
+	err := c.get(ctx, "/find/"+url.PathEscape(externalId), q, &response)
 	if err != nil {
 		return nil, err
 	}
 
-	req.Header.Add("Authorization", "Bearer "+token)
-
-	resp, err := client.Do(req)
+	response.MovieResults, _, err = familyFilter(ctx, c, response.MovieResults, movieResultId, movieResultAdult)
 	if err != nil {
 		return nil, err
 	}
 
-	defer resp.Body.Close()
+	response.PersonResults = familyPeople(c, response.PersonResults, func(person PeopleSearchResult) bool { return person.Adult })
+
+	return &response, nil
+}
+
+// ExternalIdsResponse holds the ids of a movie or person on other sites,
+// empty when TMDB doesn't know them.
+type ExternalIdsResponse struct {
+	Id          int64  `json:"id"`
+	ImdbId      string `json:"imdb_id"`
+	WikidataId  string `json:"wikidata_id"`
+	FacebookId  string `json:"facebook_id"`
+	InstagramId string `json:"instagram_id"`
+	TwitterId   string `json:"twitter_id"`
+}
 
-	body, err := io.ReadAll(resp.Body)
+func (c *Client) MovieExternalIds(ctx context.Context, movieId string) (*ExternalIdsResponse, error) {
+	var response ExternalIdsResponse
+	err := c.get(ctx, fmt.Sprintf("/movie/%s/external_ids", movieId), nil, &response)
 	if err != nil {
 		return nil, err
 	}
 
-	var response PeopleCreditsResponse
-	json.Unmarshal(body, &response)
-
 	return &response, nil
 }
 
-func BuildPosterPath(posterPath string) string {
-	if posterPath == "" {
-		return "https://www.themoviedb.org/assets/2/v4/glyphicons/basic/glyphicons-basic-38-picture-grey-c2ebdbb057f2a7614185931650f8cee23fa137b93812ccb132b9df511df1cfac.svg"
+func (c *Client) PersonExternalIds(ctx context.Context, personId string) (*ExternalIdsResponse, error) {
+	var response ExternalIdsResponse
+	err := c.get(ctx, fmt.Sprintf("/person/%s/external_ids", personId), nil, &response)
+	if err != nil {
+		return nil, err
 	}
-	return "https://image.tmdb.org/t/p/w600_and_h900_bestv2" + posterPath
+
+	return &response, nil
 }
 
 func GetReleaseYear(releaseDate string) string {
@@ -0,0 +1,178 @@
+package transfer
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/m4tthewde/blunt/library"
+	"github.com/m4tthewde/blunt/tmdb"
+)
+
+// Library is what an export is written from. History has to be sorted by
+// date, oldest first.
+type Library struct {
+	Watchlist []library.WatchlistEntry
+	History   []library.Viewing
+}
+
+// Export writes the library in one of the Formats. The Letterboxd files
+// carry TMDB and IMDb ids too, which Letterboxd understands and which make
+// importing them back exact.
+func Export(w io.Writer, format string, lib Library) error {
+	writer := csv.NewWriter(w)
+
+	var records [][]string
+
+	switch format {
+	case LetterboxdDiary:
+		records = diary(lib.History)
+	case LetterboxdWatched:
+		records = watched(lib.History)
+	case LetterboxdRatings:
+		records = ratings(lib.History)
+	case LetterboxdWatchlist:
+		records = watchlist(lib.Watchlist)
+	case ImdbRatings:
+		records = imdbRatings(lib.History)
+	default:
+		return fmt.Errorf("transfer: unknown format %q", format)
+	}
+
+	err := writer.WriteAll(records)
+	if err != nil {
+		return fmt.Errorf("transfer: %w", err)
+	}
+
+	return nil
+}
+
+func diary(history []library.Viewing) [][]string {
+	records := [][]string{{"Date", "Name", "Year", "Letterboxd URI", "Rating", "Rewatch", "Tags", "Watched Date", "tmdbID", "imdbID"}}
+	seen := make(map[int64]bool)
+
+	for _, viewing := range history {
+		rewatch := ""
+		if seen[viewing.Movie.Id] {
+			rewatch = "Yes"
+		}
+
+		seen[viewing.Movie.Id] = true
+
+		date := viewing.Date.Format(library.DateLayout)
+
+		records = append(records, []string{
+			date, viewing.Title, tmdb.GetReleaseYear(viewing.ReleaseDate), "",
+			stars(viewing.Rating), rewatch, "", date, tmdbId(viewing.Movie), viewing.ImdbId,
+		})
+	}
+
+	return records
+}
+
+// watched lists every movie once, on the day it was first watched.
+func watched(history []library.Viewing) [][]string {
+	records := [][]string{{"Date", "Name", "Year", "Letterboxd URI", "tmdbID", "imdbID"}}
+	seen := make(map[int64]bool)
+
+	for _, viewing := range history {
+		if seen[viewing.Movie.Id] {
+			continue
+		}
+
+		seen[viewing.Movie.Id] = true
+
+		records = append(records, []string{
+			viewing.Date.Format(library.DateLayout), viewing.Title, tmdb.GetReleaseYear(viewing.ReleaseDate), "",
+			tmdbId(viewing.Movie), viewing.ImdbId,
+		})
+	}
+
+	return records
+}
+
+func ratings(history []library.Viewing) [][]string {
+	records := [][]string{{"Date", "Name", "Year", "Letterboxd URI", "Rating", "tmdbID", "imdbID"}}
+
+	for _, viewing := range latestRatings(history) {
+		records = append(records, []string{
+			viewing.Date.Format(library.DateLayout), viewing.Title, tmdb.GetReleaseYear(viewing.ReleaseDate), "",
+			stars(viewing.Rating), tmdbId(viewing.Movie), viewing.ImdbId,
+		})
+	}
+
+	return records
+}
+
+func watchlist(entries []library.WatchlistEntry) [][]string {
+	records := [][]string{{"Date", "Name", "Year", "Letterboxd URI", "tmdbID", "imdbID"}}
+
+	for _, entry := range entries {
+		records = append(records, []string{
+			entry.Added.Format(library.DateLayout), entry.Title, tmdb.GetReleaseYear(entry.ReleaseDate), "",
+			tmdbId(entry.Movie), entry.ImdbId,
+		})
+	}
+
+	return records
+}
+
+// imdbRatings writes the columns of IMDb's export that its importers read.
+// Movies without a known IMDb id are matched by title and year instead.
+func imdbRatings(history []library.Viewing) [][]string {
+	records := [][]string{{"Const", "Your Rating", "Date Rated", "Title", "URL", "Title Type", "Year"}}
+
+	for _, viewing := range latestRatings(history) {
+		url := ""
+		if viewing.ImdbId != "" {
+			url = "https://www.imdb.com/title/" + viewing.ImdbId + "/"
+		}
+
+		records = append(records, []string{
+			viewing.ImdbId, strconv.Itoa(viewing.Rating), viewing.Date.Format(library.DateLayout), viewing.Title,
+			url, "Movie", tmdb.GetReleaseYear(viewing.ReleaseDate),
+		})
+	}
+
+	return records
+}
+
+// latestRatings returns the latest rated viewing of every rated movie, in
+// the order they were rated.
+func latestRatings(history []library.Viewing) []library.Viewing {
+	latest := make(map[int64]int)
+	for i, viewing := range history {
+		if viewing.Rating != 0 {
+			latest[viewing.Movie.Id] = i
+		}
+	}
+
+	rated := make([]library.Viewing, 0, len(latest))
+	for i, viewing := range history {
+		if viewing.Rating != 0 && latest[viewing.Movie.Id] == i {
+			rated = append(rated, viewing)
+		}
+	}
+
+	return rated
+}
+
+// FileName names an export after the format and the day.
+func FileName(format string, now time.Time) string {
+	return "blunt-" + format + "-" + now.Format(library.DateLayout) + ".csv"
+}
+
+// stars turns a rating into Letterboxd's half stars.
+func stars(rating int) string {
+	if rating == 0 {
+		return ""
+	}
+
+	return strconv.FormatFloat(float64(rating)/2, 'f', -1, 64)
+}
+
+func tmdbId(movie library.Movie) string {
+	return strconv.FormatInt(movie.Id, 10)
+}
@@ -0,0 +1,246 @@
+// Package transfer imports viewing histories exported by Letterboxd and IMDb
+// and exports the library in their formats.
+package transfer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	LetterboxdWatched   = "letterboxd-watched"
+	LetterboxdWatchlist = "letterboxd-watchlist"
+	LetterboxdRatings   = "letterboxd-ratings"
+	LetterboxdDiary     = "letterboxd-diary"
+	ImdbRatings         = "imdb-ratings"
+)
+
+// Formats lists the files that can be imported and exported, named after
+// the file in the export of the other site.
+var Formats = []Format{
+	{LetterboxdDiary, "diary.csv"},
+	{LetterboxdWatched, "watched.csv"},
+	{LetterboxdRatings, "ratings.csv"},
+	{LetterboxdWatchlist, "watchlist.csv"},
+	{ImdbRatings, "ratings.csv"},
+}
+
+type Format struct {
+	Name     string
+	FileName string
+}
+
+// MaxRows bounds an import, every row may cost two TMDB requests.
+const MaxRows = 5000
+
+// Kind is what a row adds to the library.
+type Kind string
+
+const (
+	KindWatchlist Kind = "watchlist"
+	// KindViewing is a viewing on a known date.
+	KindViewing Kind = "viewing"
+	// KindWatched only says the movie was watched, Date is when that was
+	// logged.
+	KindWatched Kind = "watched"
+	// KindRating rates the latest viewing, or adds one on Date if there is
+	// none.
+	KindRating Kind = "rating"
+)
+
+type Row struct {
+	Line   int
+	Kind   Kind
+	Title  string
+	Year   int
+	ImdbId string
+	TmdbId int64
+	Date   time.Time
+	// Rating from 1 to 10, zero when not rated.
+	Rating int
+}
+
+// ErrNotMovie is returned for IMDb rows of series and episodes, which have
+// no place in the library.
+var ErrNotMovie = errors.New("not a movie")
+
+// Parse reads the rows of an exported file. Rows that can't be read make the
+// whole file fail, they usually mean it's not the format it claims to be.
+func Parse(format string, r io.Reader) ([]Row, int, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, 0, fmt.Errorf("transfer: reading header: %w", err)
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		// Excel prepends a byte order mark
+		name = strings.TrimPrefix(name, "\ufeff")
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	parse, required, err := parser(format)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	for _, name := range required {
+		_, ok := columns[strings.ToLower(name)]
+		if !ok {
+			return nil, 0, fmt.Errorf("transfer: not a %s export, the %q column is missing", format, name)
+		}
+	}
+
+	rows := make([]Row, 0)
+	skipped := 0
+
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, 0, fmt.Errorf("transfer: %w", err)
+		}
+
+		if len(rows) == MaxRows {
+			return nil, 0, fmt.Errorf("transfer: files may have at most %d rows", MaxRows)
+		}
+
+		field := func(name string) string {
+			i, ok := columns[strings.ToLower(name)]
+			if !ok || i >= len(record) {
+				return ""
+			}
+
+			return strings.TrimSpace(record[i])
+		}
+
+		row, err := parse(field)
+		if errors.Is(err, ErrNotMovie) {
+			skipped++
+			continue
+		}
+		if err != nil {
+			return nil, 0, fmt.Errorf("transfer: line %d: %w", line, err)
+		}
+
+		row.Line = line
+
+		// exports of the explorer carry the ids
+		row.TmdbId, _ = strconv.ParseInt(field("tmdbID"), 10, 64)
+		if row.ImdbId == "" {
+			row.ImdbId = field("imdbID")
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, skipped, nil
+}
+
+type fieldFunc func(name string) string
+
+func parser(format string) (func(field fieldFunc) (Row, error), []string, error) {
+	letterboxd := []string{"Date", "Name", "Year"}
+
+	switch format {
+	case LetterboxdWatched:
+		return letterboxdRow(KindWatched), letterboxd, nil
+	case LetterboxdWatchlist:
+		return letterboxdRow(KindWatchlist), letterboxd, nil
+	case LetterboxdRatings:
+		return letterboxdRow(KindRating), append(letterboxd, "Rating"), nil
+	case LetterboxdDiary:
+		return letterboxdRow(KindViewing), append(letterboxd, "Rating", "Watched Date"), nil
+	case ImdbRatings:
+		return imdbRow, []string{"Const", "Your Rating", "Date Rated", "Title", "Year"}, nil
+	}
+
+	return nil, nil, fmt.Errorf("transfer: unknown format %q", format)
+}
+
+func letterboxdRow(kind Kind) func(field fieldFunc) (Row, error) {
+	return func(field fieldFunc) (Row, error) {
+		row := Row{Kind: kind, Title: field("Name")}
+
+		var err error
+
+		row.Year, err = optionalInt(field("Year"))
+		if err != nil {
+			return row, fmt.Errorf("invalid year: %w", err)
+		}
+
+		date := field("Date")
+		if kind == KindViewing && field("Watched Date") != "" {
+			date = field("Watched Date")
+		}
+
+		row.Date, err = time.Parse(time.DateOnly, date)
+		if err != nil {
+			return row, fmt.Errorf("invalid date: %w", err)
+		}
+
+		// half stars from 0.5 to 5
+		stars := field("Rating")
+		if stars != "" {
+			value, err := strconv.ParseFloat(stars, 64)
+			if err != nil || value < 0.5 || value > 5 {
+				return row, fmt.Errorf("invalid rating %q", stars)
+			}
+
+			row.Rating = int(math.Round(value * 2))
+		}
+
+		if kind == KindRating && row.Rating == 0 {
+			return row, errors.New("rating is missing")
+		}
+
+		return row, nil
+	}
+}
+
+func imdbRow(field fieldFunc) (Row, error) {
+	switch strings.ToLower(strings.ReplaceAll(field("Title Type"), " ", "")) {
+	case "tvseries", "tvminiseries", "tvepisode", "podcastseries", "podcastepisode", "videogame":
+		return Row{}, ErrNotMovie
+	}
+
+	row := Row{Kind: KindRating, Title: field("Title"), ImdbId: field("Const")}
+
+	var err error
+
+	row.Year, err = optionalInt(field("Year"))
+	if err != nil {
+		return row, fmt.Errorf("invalid year: %w", err)
+	}
+
+	row.Date, err = time.Parse(time.DateOnly, field("Date Rated"))
+	if err != nil {
+		return row, fmt.Errorf("invalid date: %w", err)
+	}
+
+	row.Rating, err = strconv.Atoi(field("Your Rating"))
+	if err != nil || row.Rating < 1 || row.Rating > 10 {
+		return row, fmt.Errorf("invalid rating %q", field("Your Rating"))
+	}
+
+	return row, nil
+}
+
+func optionalInt(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	return strconv.Atoi(value)
+}
@@ -0,0 +1,146 @@
+package transfer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func date(value string) time.Time {
+	parsed, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		panic(err)
+	}
+
+	return parsed
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		file    string
+		want    []Row
+		skipped int
+	}{
+		{
+			name:   "letterboxd diary",
+			format: LetterboxdDiary,
+			file: "\ufeffDate,Name,Year,Letterboxd URI,Rating,Rewatch,Tags,Watched Date\n" +
+				"2024-03-02,Heat,1995,https://boxd.it/1,4.5,,,2024-03-01\n" +
+				"2024-03-05,\"Crouching Tiger, Hidden Dragon\",2000,https://boxd.it/2,,Yes,,\n",
+			want: []Row{
+				{Line: 2, Kind: KindViewing, Title: "Heat", Year: 1995, Date: date("2024-03-01"), Rating: 9},
+				// without a watched date, the logging date will do
+				{Line: 3, Kind: KindViewing, Title: "Crouching Tiger, Hidden Dragon", Year: 2000, Date: date("2024-03-05")},
+			},
+		},
+		{
+			name:   "letterboxd watched",
+			format: LetterboxdWatched,
+			file:   "Date,Name,Year,Letterboxd URI\n2023-11-20,Ronin,1998,https://boxd.it/3\n2023-11-21,Untitled,,https://boxd.it/4\n",
+			want: []Row{
+				{Line: 2, Kind: KindWatched, Title: "Ronin", Year: 1998, Date: date("2023-11-20")},
+				{Line: 3, Kind: KindWatched, Title: "Untitled", Date: date("2023-11-21")},
+			},
+		},
+		{
+			name:   "letterboxd ratings",
+			format: LetterboxdRatings,
+			file:   "Date,Name,Year,Letterboxd URI,Rating\n2022-01-01,Cats,2019,https://boxd.it/5,0.5\n",
+			want:   []Row{{Line: 2, Kind: KindRating, Title: "Cats", Year: 2019, Date: date("2022-01-01"), Rating: 1}},
+		},
+		{
+			name:   "letterboxd watchlist",
+			format: LetterboxdWatchlist,
+			file:   "date,name,year,letterboxd uri\n2024-06-01,Dune: Part Two,2024,https://boxd.it/6\n",
+			want:   []Row{{Line: 2, Kind: KindWatchlist, Title: "Dune: Part Two", Year: 2024, Date: date("2024-06-01")}},
+		},
+		{
+			name:   "imdb ratings",
+			format: ImdbRatings,
+			file: "Const,Your Rating,Date Rated,Title,URL,Title Type,IMDb Rating,Runtime (mins),Year\n" +
+				"tt0113277,8,2021-05-04,Heat,https://www.imdb.com/title/tt0113277/,Movie,8.3,170,1995\n" +
+				"tt0903747,10,2021-05-05,Breaking Bad,https://www.imdb.com/title/tt0903747/,TV Series,9.5,49,2008\n" +
+				"tt0959337,6,2021-05-06,Revolutionary Road,https://www.imdb.com/title/tt0959337/,movie,7.3,119,2008\n",
+			want: []Row{
+				{Line: 2, Kind: KindRating, Title: "Heat", Year: 1995, ImdbId: "tt0113277", Date: date("2021-05-04"), Rating: 8},
+				{Line: 4, Kind: KindRating, Title: "Revolutionary Road", Year: 2008, ImdbId: "tt0959337", Date: date("2021-05-06"), Rating: 6},
+			},
+			skipped: 1,
+		},
+		{
+			name:   "exports of the explorer carry ids",
+			format: LetterboxdWatched,
+			file:   "Date,Name,Year,Letterboxd URI,tmdbID,imdbID\n2023-11-20,Ronin,1998,,8195,tt0122690\n",
+			want:   []Row{{Line: 2, Kind: KindWatched, Title: "Ronin", Year: 1998, TmdbId: 8195, ImdbId: "tt0122690", Date: date("2023-11-20")}},
+		},
+		{
+			name:   "header only",
+			format: LetterboxdWatched,
+			file:   "Date,Name,Year,Letterboxd URI\n",
+			want:   []Row{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rows, skipped, err := Parse(test.format, strings.NewReader(test.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(rows, test.want) {
+				t.Errorf("rows\n%+v\nwant\n%+v", rows, test.want)
+			}
+
+			if skipped != test.skipped {
+				t.Errorf("%d rows skipped, want %d", skipped, test.skipped)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		file   string
+		want   string
+	}{
+		{"empty file", LetterboxdWatched, "", "reading header"},
+		{"unknown format", "trakt", "Date,Name,Year\n", "unknown format"},
+		{"wrong format", LetterboxdDiary, "Date,Name,Year,Letterboxd URI\n", `"Rating" column is missing`},
+		{"imdb file as letterboxd", LetterboxdRatings, "Const,Your Rating,Date Rated,Title,Year\n", `"Date" column is missing`},
+		{"invalid date", LetterboxdWatched, "Date,Name,Year\n01/02/2024,Heat,1995\n", "line 2: invalid date"},
+		{"invalid year", LetterboxdWatched, "Date,Name,Year\n2024-01-02,Heat,MCMXCV\n", "line 2: invalid year"},
+		{"too many stars", LetterboxdRatings, "Date,Name,Year,Rating\n2024-01-02,Heat,1995,6\n", `line 2: invalid rating "6"`},
+		{"rating missing", LetterboxdRatings, "Date,Name,Year,Rating\n2024-01-02,Heat,1995,\n", "line 2: rating is missing"},
+		{"imdb rating out of range", ImdbRatings, "Const,Your Rating,Date Rated,Title,Year\ntt0113277,11,2021-05-04,Heat,1995\n", `line 2: invalid rating "11"`},
+		{"broken quotes", LetterboxdWatched, "Date,Name,Year\n2024-01-02,\"Heat,1995\n", "transfer:"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, _, err := Parse(test.format, strings.NewReader(test.file))
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Errorf("error %v, want one containing %q", err, test.want)
+			}
+		})
+	}
+}
+
+func TestParseMaxRows(t *testing.T) {
+	var file strings.Builder
+
+	file.WriteString("Date,Name,Year\n")
+	for range MaxRows + 1 {
+		file.WriteString("2024-01-02,Heat,1995\n")
+	}
+
+	_, _, err := Parse(LetterboxdWatched, strings.NewReader(file.String()))
+	if err == nil || !strings.Contains(err.Error(), "at most") {
+		t.Errorf("error %v, want the row limit", err)
+	}
+}
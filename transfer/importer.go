@@ -0,0 +1,260 @@
+package transfer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/m4tthewde/blunt/library"
+	"github.com/m4tthewde/blunt/tmdb"
+)
+
+// ReasonInvalid marks rows the library refused, like viewings in the future.
+const ReasonInvalid = "invalid"
+
+var ErrRunning = errors.New("transfer: an import is already running")
+
+// Job is the progress of an import.
+type Job struct {
+	Format  string
+	Started time.Time
+	// Finished is zero while the import runs.
+	Finished time.Time
+	Total    int
+	// Processed counts the rows looked at so far, every one of them ends up
+	// in one of the counts below.
+	Processed int
+	Imported  int
+	// Unchanged rows were already in the library.
+	Unchanged int
+	Unmatched int
+	// Skipped rows aren't about movies, they're counted before the import
+	// starts.
+	Skipped int
+	// Err is why the import stopped early, rows after Processed weren't
+	// imported.
+	Err error
+}
+
+func (j Job) Running() bool {
+	return j.Finished.IsZero()
+}
+
+// Importer matches imported rows in the background, a large history takes
+// minutes of TMDB lookups. Every user has at most one import running.
+type Importer struct {
+	// ctx stops imports on shutdown.
+	ctx     context.Context
+	client  *tmdb.Client
+	library *library.Store
+	issues  *Store
+
+	mu   sync.Mutex
+	jobs map[int64]*Job
+	// running counts the imports in progress, so shutdown can wait for them
+	running sync.WaitGroup
+}
+
+func NewImporter(ctx context.Context, client *tmdb.Client, library *library.Store, issues *Store) *Importer {
+	return &Importer{
+		ctx:     ctx,
+		client:  client,
+		library: library,
+		issues:  issues,
+		jobs:    make(map[int64]*Job),
+	}
+}
+
+// Start imports rows for the user. The import keeps the values of ctx, like
+// the locale, but outlives it. wait is called before every row, it blocks
+// until the row may be looked up and fails when the import should stop.
+func (i *Importer) Start(ctx context.Context, userId int64, format string, rows []Row, skipped int, wait func(ctx context.Context) error) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	job, ok := i.jobs[userId]
+	if ok && job.Running() {
+		return ErrRunning
+	}
+
+	job = &Job{
+		Format:  format,
+		Started: time.Now(),
+		Total:   len(rows),
+		Skipped: skipped,
+	}
+	i.jobs[userId] = job
+
+	ctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	stop := context.AfterFunc(i.ctx, cancel)
+
+	i.running.Add(1)
+
+	go func() {
+		defer i.running.Done()
+		defer cancel()
+		defer stop()
+
+		i.run(ctx, userId, job, rows, wait)
+	}()
+
+	return nil
+}
+
+// Wait waits for the running imports, they stop once the importer's
+// context is done.
+func (i *Importer) Wait() {
+	i.running.Wait()
+}
+
+// Job returns the user's running or last finished import.
+func (i *Importer) Job(userId int64) (Job, bool) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	job, ok := i.jobs[userId]
+	if !ok {
+		return Job{}, false
+	}
+
+	return *job, true
+}
+
+func (i *Importer) run(ctx context.Context, userId int64, job *Job, rows []Row, wait func(ctx context.Context) error) {
+	var err error
+
+	for _, row := range rows {
+		err = wait(ctx)
+		if err != nil {
+			break
+		}
+
+		var outcome func(job *Job)
+
+		outcome, err = i.importRow(ctx, userId, job.Format, row)
+		if err != nil {
+			break
+		}
+
+		i.mu.Lock()
+		outcome(job)
+		job.Processed++
+		i.mu.Unlock()
+	}
+
+	i.mu.Lock()
+	job.Finished = time.Now()
+	job.Err = err
+	summary := *job
+	i.mu.Unlock()
+
+	if err != nil {
+		slog.WarnContext(ctx, "import stopped", "user", userId, "format", summary.Format,
+			"processed", summary.Processed, "total", summary.Total, "error", err)
+		return
+	}
+
+	slog.InfoContext(ctx, "import finished", "user", userId, "format", summary.Format,
+		"imported", summary.Imported, "unchanged", summary.Unchanged, "unmatched", summary.Unmatched,
+		"duration", summary.Finished.Sub(summary.Started))
+}
+
+// importRow returns how the row changes the job's counts. Errors stop the
+// import, they're about TMDB or the database rather than the row.
+func (i *Importer) importRow(ctx context.Context, userId int64, format string, row Row) (func(job *Job), error) {
+	unmatched := func(reason string) (func(job *Job), error) {
+		err := i.issues.AddIssue(ctx, userId, format, row, reason)
+		if err != nil {
+			return nil, err
+		}
+
+		return func(job *Job) { job.Unmatched++ }, nil
+	}
+
+	movieId, reason, err := match(ctx, i.client, row)
+	if err != nil {
+		return nil, err
+	}
+
+	if reason != "" {
+		return unmatched(reason)
+	}
+
+	changed, err := i.apply(ctx, userId, row, movieId)
+	if errors.Is(err, tmdb.ErrNotFound) {
+		return unmatched(ReasonNotFound)
+	}
+	if errors.Is(err, library.ErrInvalidDate) || errors.Is(err, library.ErrInvalidRating) {
+		return unmatched(ReasonInvalid)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !changed {
+		return func(job *Job) { job.Unchanged++ }, nil
+	}
+
+	return func(job *Job) { job.Imported++ }, nil
+}
+
+// apply adds the row to the library as the movie, importing a file twice
+// changes nothing the second time.
+func (i *Importer) apply(ctx context.Context, userId int64, row Row, movieId int64) (bool, error) {
+	details, err := i.client.MovieDetails(ctx, strconv.FormatInt(movieId, 10))
+	if err != nil {
+		return false, err
+	}
+
+	movie := library.NewMovie(details)
+
+	switch row.Kind {
+	case KindWatchlist:
+		// the watchlist is for movies not seen yet
+		status, err := i.library.Status(ctx, userId, movie.Id)
+		if err != nil || status.OnWatchlist || len(status.Viewings) > 0 {
+			return false, err
+		}
+
+		return true, i.library.AddToWatchlist(ctx, userId, movie)
+	case KindViewing:
+		watched, err := i.library.HasViewing(ctx, userId, movie.Id, row.Date)
+		if err != nil || watched {
+			return false, err
+		}
+	case KindWatched:
+		watched, err := i.library.HasViewing(ctx, userId, movie.Id, time.Time{})
+		if err != nil || watched {
+			return false, err
+		}
+	case KindRating:
+		rated, err := i.library.RateLatestViewing(ctx, userId, movie.Id, row.Rating)
+		if err != nil || rated {
+			return rated, err
+		}
+	default:
+		return false, fmt.Errorf("transfer: unknown kind %q", row.Kind)
+	}
+
+	return true, i.library.AddViewing(ctx, userId, movie, row.Date, row.Rating)
+}
+
+// Resolve imports the row of an issue as the movie the user picked and
+// forgets the issue.
+func (i *Importer) Resolve(ctx context.Context, userId int64, issueId int64, movieId int64) error {
+	issue, err := i.issues.Issue(ctx, userId, issueId)
+	if err != nil {
+		return err
+	}
+
+	_, err = i.apply(ctx, userId, issue.Row, movieId)
+	if err != nil {
+		return err
+	}
+
+	return i.issues.DeleteIssue(ctx, userId, issueId)
+}
@@ -0,0 +1,141 @@
+package transfer
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/m4tthewde/blunt/library"
+)
+
+var ErrNoIssue = errors.New("transfer: no such import issue")
+
+// Issue is an imported row that didn't match a TMDB movie.
+type Issue struct {
+	Id     int64
+	Format string
+	Row
+	Reason  string
+	Created time.Time
+}
+
+// Store keeps the issues of past imports.
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+// AddIssue records a row, unless the same row of an earlier import of the
+// file is still waiting.
+func (s *Store) AddIssue(ctx context.Context, userId int64, format string, row Row, reason string) error {
+	date := ""
+	if !row.Date.IsZero() {
+		date = row.Date.Format(library.DateLayout)
+	}
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO import_issues (user_id, format, line, kind, title, year, imdb_id, date, rating, reason, created_at)
+		SELECT ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+		WHERE NOT EXISTS (
+			SELECT 1 FROM import_issues
+			WHERE user_id = ? AND format = ? AND kind = ? AND title = ? AND year = ? AND imdb_id = ? AND date = ?
+		)`,
+		userId, format, row.Line, string(row.Kind), row.Title, row.Year, row.ImdbId, date, row.Rating, reason, time.Now().Unix(),
+		userId, format, string(row.Kind), row.Title, row.Year, row.ImdbId, date,
+	)
+	if err != nil {
+		return fmt.Errorf("transfer: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Store) Issues(ctx context.Context, userId int64) ([]Issue, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, format, line, kind, title, year, imdb_id, date, rating, reason, created_at
+		FROM import_issues WHERE user_id = ? ORDER BY created_at, format, line`,
+		userId,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("transfer: %w", err)
+	}
+	defer rows.Close()
+
+	issues := make([]Issue, 0)
+
+	for rows.Next() {
+		issue, err := scanIssue(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		issues = append(issues, issue)
+	}
+
+	return issues, rows.Err()
+}
+
+func (s *Store) Issue(ctx context.Context, userId int64, id int64) (Issue, error) {
+	row := s.db.QueryRowContext(ctx, `
+		SELECT id, format, line, kind, title, year, imdb_id, date, rating, reason, created_at
+		FROM import_issues WHERE id = ? AND user_id = ?`,
+		id, userId,
+	)
+
+	issue, err := scanIssue(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return issue, ErrNoIssue
+	}
+
+	return issue, err
+}
+
+func (s *Store) DeleteIssue(ctx context.Context, userId int64, id int64) error {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM import_issues WHERE id = ? AND user_id = ?", id, userId)
+	if err != nil {
+		return fmt.Errorf("transfer: %w", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("transfer: %w", err)
+	}
+
+	if deleted == 0 {
+		return ErrNoIssue
+	}
+
+	return nil
+}
+
+func scanIssue(row interface{ Scan(dest ...any) error }) (Issue, error) {
+	var issue Issue
+	var kind, date string
+	var created int64
+
+	err := row.Scan(&issue.Id, &issue.Format, &issue.Line, &kind, &issue.Title, &issue.Year,
+		&issue.ImdbId, &date, &issue.Rating, &issue.Reason, &created)
+	if errors.Is(err, sql.ErrNoRows) {
+		return issue, err
+	}
+	if err != nil {
+		return issue, fmt.Errorf("transfer: %w", err)
+	}
+
+	issue.Kind = Kind(kind)
+	issue.Created = time.Unix(created, 0)
+
+	if date != "" {
+		issue.Date, err = time.Parse(library.DateLayout, date)
+		if err != nil {
+			return issue, fmt.Errorf("transfer: %w", err)
+		}
+	}
+
+	return issue, nil
+}
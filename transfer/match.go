@@ -0,0 +1,112 @@
+package transfer
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/m4tthewde/blunt/tmdb"
+)
+
+// Reasons why a row couldn't be matched, shown next to the row until it is
+// resolved.
+const (
+	ReasonNotFound  = "not-found"
+	ReasonAmbiguous = "ambiguous"
+)
+
+// match finds the TMDB movie a row is about. Ids are trusted, titles only
+// when a single movie fits. Rows that don't match return a reason.
+func match(ctx context.Context, client *tmdb.Client, row Row) (int64, string, error) {
+	if row.TmdbId != 0 {
+		return row.TmdbId, "", nil
+	}
+
+	if row.ImdbId != "" {
+		found, err := client.Find(ctx, row.ImdbId, tmdb.ImdbSource)
+		if err != nil && !errors.Is(err, tmdb.ErrNotFound) {
+			return 0, "", err
+		}
+
+		if err == nil && len(found.MovieResults) > 0 {
+			return found.MovieResults[0].Id, "", nil
+		}
+	}
+
+	if row.Title == "" {
+		return 0, ReasonNotFound, nil
+	}
+
+	response, err := client.SearchMovieTitle(ctx, row.Title, row.Year)
+	if err != nil {
+		return 0, "", err
+	}
+
+	candidates := response.Results
+
+	// TMDB filters by the primary release, the other site may know the
+	// movie by its release elsewhere a year later or earlier
+	if len(candidates) == 0 && row.Year != 0 {
+		response, err = client.SearchMovieTitle(ctx, row.Title, 0)
+		if err != nil {
+			return 0, "", err
+		}
+
+		for _, result := range response.Results {
+			if nearYear(result.ReleaseDate, row.Year) {
+				candidates = append(candidates, result)
+			}
+		}
+	}
+
+	if len(candidates) == 0 {
+		return 0, ReasonNotFound, nil
+	}
+
+	exact := make([]tmdb.MovieSearchResult, 0)
+	title := normalizeTitle(row.Title)
+
+	for _, candidate := range candidates {
+		if normalizeTitle(candidate.Title) != title && normalizeTitle(candidate.OriginalTitle) != title {
+			continue
+		}
+
+		if row.Year == 0 || nearYear(candidate.ReleaseDate, row.Year) {
+			exact = append(exact, candidate)
+		}
+	}
+
+	switch {
+	case len(exact) == 1:
+		return exact[0].Id, "", nil
+	case len(exact) == 0 && len(candidates) == 1:
+		return candidates[0].Id, "", nil
+	}
+
+	return 0, ReasonAmbiguous, nil
+}
+
+func nearYear(releaseDate string, year int) bool {
+	released, err := strconv.Atoi(tmdb.GetReleaseYear(releaseDate))
+	if err != nil {
+		return false
+	}
+
+	return released >= year-1 && released <= year+1
+}
+
+// normalizeTitle drops case, punctuation and spacing, which the sites
+// don't agree on.
+func normalizeTitle(title string) string {
+	var b strings.Builder
+
+	for _, r := range strings.ToLower(title) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
@@ -0,0 +1,171 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/netip"
+	"time"
+
+	"github.com/m4tthewde/blunt/csrf"
+)
+
+const (
+	ModeLocal = "local"
+	ModeProxy = "proxy"
+
+	SessionCookie = "session"
+)
+
+type Options struct {
+	// Mode is local for accounts with passwords, or proxy when an SSO
+	// gateway in front of the explorer authenticates users.
+	Mode       string
+	SessionTTL time.Duration
+	// ProxyHeader names the user in proxy mode. It's only believed from
+	// TrustedProxies.
+	ProxyHeader    string
+	TrustedProxies []netip.Prefix
+	// SecureCookies marks cookies Secure on plain HTTP requests too, behind
+	// a reverse proxy that terminates TLS.
+	SecureCookies bool
+}
+
+// Auth resolves the user of every request.
+type Auth struct {
+	store   *Store
+	options Options
+}
+
+func NewAuth(store *Store, options Options) *Auth {
+	return &Auth{store: store, options: options}
+}
+
+type (
+	userKey struct{}
+	modeKey struct{}
+)
+
+// FromContext returns the signed in user, or nil for anonymous requests.
+func FromContext(ctx context.Context) *User {
+	user, _ := ctx.Value(userKey{}).(*User)
+
+	return user
+}
+
+func WithUser(ctx context.Context, user *User) context.Context {
+	return context.WithValue(ctx, userKey{}, user)
+}
+
+// LocalAccounts reports whether users log in and out on the explorer itself,
+// instead of at the reverse proxy.
+func LocalAccounts(ctx context.Context) bool {
+	mode, _ := ctx.Value(modeKey{}).(string)
+
+	return mode == ModeLocal
+}
+
+// Middleware adds the user of the request to its context.
+func (a *Auth) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, err := a.user(r)
+		if err != nil {
+			slog.WarnContext(r.Context(), "could not resolve user", "error", err)
+		}
+
+		ctx := context.WithValue(r.Context(), modeKey{}, a.options.Mode)
+		if user != nil {
+			ctx = WithUser(ctx, user)
+		}
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func (a *Auth) user(r *http.Request) (*User, error) {
+	if a.options.Mode == ModeProxy {
+		name := r.Header.Get(a.options.ProxyHeader)
+		if name == "" || !a.fromTrustedProxy(r) {
+			return nil, nil
+		}
+
+		return a.store.Ensure(r.Context(), name)
+	}
+
+	cookie, err := r.Cookie(SessionCookie)
+	if err != nil {
+		return nil, nil
+	}
+
+	user, err := a.store.SessionUser(r.Context(), cookie.Value)
+	if errors.Is(err, ErrNoSession) {
+		return nil, nil
+	}
+
+	return user, err
+}
+
+func (a *Auth) fromTrustedProxy(r *http.Request) bool {
+	addrPort, err := netip.ParseAddrPort(r.RemoteAddr)
+	if err != nil {
+		return false
+	}
+
+	for _, prefix := range a.options.TrustedProxies {
+		if prefix.Contains(addrPort.Addr().Unmap()) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (a *Auth) secure(r *http.Request) bool {
+	return a.options.SecureCookies || r.TLS != nil
+}
+
+// Login starts a session and hands its cookie to the browser, along with a
+// new CSRF token.
+func (a *Auth) Login(w http.ResponseWriter, r *http.Request, user *User) error {
+	token, err := a.store.CreateSession(r.Context(), user, a.options.SessionTTL)
+	if err != nil {
+		return err
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     SessionCookie,
+		Value:    token,
+		Path:     "/",
+		MaxAge:   int(a.options.SessionTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   a.secure(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+	csrf.Rotate(w, r, a.options.SecureCookies)
+
+	return nil
+}
+
+// Logout ends the session of the request, if there is one, and replaces
+// the CSRF token.
+func (a *Auth) Logout(w http.ResponseWriter, r *http.Request) error {
+	csrf.Rotate(w, r, a.options.SecureCookies)
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     SessionCookie,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   a.secure(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	cookie, err := r.Cookie(SessionCookie)
+	if err != nil {
+		return nil
+	}
+
+	return a.store.DeleteSession(r.Context(), cookie.Value)
+}
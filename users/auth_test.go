@@ -0,0 +1,147 @@
+package users
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/m4tthewde/blunt/csrf"
+)
+
+// whoami answers with the name of the signed in user.
+var whoami = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	if user := FromContext(r.Context()); user != nil {
+		w.Write([]byte(user.Name))
+	}
+})
+
+func TestProxyMode(t *testing.T) {
+	auth := NewAuth(testStore(t), Options{
+		Mode:           ModeProxy,
+		ProxyHeader:    "Remote-User",
+		TrustedProxies: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")},
+	})
+	handler := auth.Middleware(whoami)
+
+	tests := []struct {
+		name   string
+		remote string
+		header string
+		want   string
+	}{
+		{"trusted proxy", "10.1.2.3:4567", "alice", "alice"},
+		{"trusted proxy over IPv6", "[::ffff:10.1.2.3]:4567", "alice", "alice"},
+		{"anyone else", "192.0.2.1:4567", "alice", ""},
+		{"no header", "10.1.2.3:4567", "", ""},
+		{"invalid name", "10.1.2.3:4567", "two words", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = test.remote
+			if test.header != "" {
+				req.Header.Set("Remote-User", test.header)
+			}
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Body.String() != test.want {
+				t.Errorf("user %q, want %q", rec.Body, test.want)
+			}
+		})
+	}
+}
+
+func TestLoginLogout(t *testing.T) {
+	store := testStore(t)
+	auth := NewAuth(store, Options{Mode: ModeLocal, SessionTTL: time.Hour})
+	handler := auth.Middleware(whoami)
+
+	user, err := store.Register(context.Background(), "alice", "long enough password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	err = auth.Login(rec, httptest.NewRequest(http.MethodPost, "/login", nil), user)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cookies := map[string]*http.Cookie{}
+	for _, cookie := range rec.Result().Cookies() {
+		cookies[cookie.Name] = cookie
+	}
+
+	session := cookies[SessionCookie]
+	if session == nil || !session.HttpOnly || session.MaxAge != 3600 {
+		t.Fatalf("session cookie %+v", session)
+	}
+	if cookies[csrf.CookieName] == nil {
+		t.Error("login kept the CSRF token")
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(session)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Body.String() != "alice" {
+		t.Errorf("signed in as %q", rec.Body)
+	}
+
+	rec = httptest.NewRecorder()
+	err = auth.Logout(rec, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cleared := false
+	rotated := false
+	for _, cookie := range rec.Result().Cookies() {
+		cleared = cleared || cookie.Name == SessionCookie && cookie.MaxAge < 0
+		rotated = rotated || cookie.Name == csrf.CookieName
+	}
+	if !cleared || !rotated {
+		t.Errorf("logout cleared the session cookie: %v, rotated the CSRF token: %v", cleared, rotated)
+	}
+
+	// the old cookie is worthless even if the browser keeps it
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Body.String() != "" {
+		t.Errorf("still signed in as %q", rec.Body)
+	}
+}
+
+func TestSecureCookies(t *testing.T) {
+	store := testStore(t)
+
+	user, err := store.Register(context.Background(), "alice", "long enough password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, secure := range []bool{false, true} {
+		auth := NewAuth(store, Options{Mode: ModeLocal, SessionTTL: time.Hour, SecureCookies: secure})
+
+		// plain HTTP, as a reverse proxy terminating TLS forwards it
+		rec := httptest.NewRecorder()
+		err = auth.Login(rec, httptest.NewRequest(http.MethodPost, "/login", nil), user)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, cookie := range rec.Result().Cookies() {
+			if cookie.Secure != secure {
+				t.Errorf("secure cookies %v: %s cookie secure %v", secure, cookie.Name, cookie.Secure)
+			}
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package users
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrNoSession is returned for unknown and expired sessions.
+var ErrNoSession = errors.New("users: no such session")
+
+// CreateSession starts a session of user lasting ttl and returns the token
+// identifying it.
+func (s *Store) CreateSession(ctx context.Context, user *User, ttl time.Duration) (string, error) {
+	token := rand.Text()
+	now := time.Now()
+
+	// a good time to forget sessions nobody will use again
+	_, err := s.db.ExecContext(ctx, "DELETE FROM sessions WHERE expires_at <= ?", now.Unix())
+	if err != nil {
+		return "", fmt.Errorf("users: %w", err)
+	}
+
+	_, err = s.db.ExecContext(ctx,
+		"INSERT INTO sessions (token_hash, user_id, created_at, expires_at) VALUES (?, ?, ?, ?)",
+		hashToken(token), user.Id, now.Unix(), now.Add(ttl).Unix(),
+	)
+	if err != nil {
+		return "", fmt.Errorf("users: %w", err)
+	}
+
+	return token, nil
+}
+
+// SessionUser returns the user a session belongs to.
+func (s *Store) SessionUser(ctx context.Context, token string) (*User, error) {
+	var user User
+	var created int64
+
+	err := s.db.QueryRowContext(ctx, `
+		SELECT users.id, users.name, users.created_at
+		FROM sessions JOIN users ON users.id = sessions.user_id
+		WHERE sessions.token_hash = ? AND sessions.expires_at > ?`,
+		hashToken(token), time.Now().Unix(),
+	).Scan(&user.Id, &user.Name, &created)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNoSession
+	}
+	if err != nil {
+		return nil, fmt.Errorf("users: %w", err)
+	}
+
+	user.Created = time.Unix(created, 0)
+
+	return &user, nil
+}
+
+func (s *Store) DeleteSession(ctx context.Context, token string) error {
+	_, err := s.db.ExecContext(ctx, "DELETE FROM sessions WHERE token_hash = ?", hashToken(token))
+	if err != nil {
+		return fmt.Errorf("users: %w", err)
+	}
+
+	return nil
+}
+
+func hashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,174 @@
+// Package users manages accounts and their sessions, and tells handlers who
+// is making a request.
+package users
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	ErrInvalidName        = errors.New("names are 3 to 32 letters, digits, dots, dashes or underscores")
+	ErrWeakPassword       = fmt.Errorf("passwords need at least %d characters", minPasswordLength)
+	ErrNameTaken          = errors.New("the name is already taken")
+	ErrInvalidCredentials = errors.New("unknown name or wrong password")
+)
+
+const (
+	minPasswordLength = 10
+	// argon2 hashes the whole password, so it's bounded like any other input
+	maxPasswordLength = 256
+)
+
+var namePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{3,32}$`)
+
+type User struct {
+	Id      int64
+	Name    string
+	Created time.Time
+}
+
+type Store struct {
+	db *sql.DB
+	// dummyHash is verified for unknown names, so they take as long to
+	// reject as wrong passwords
+	dummyHash string
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db, dummyHash: hashPassword("")}
+}
+
+// Register creates a local account.
+func (s *Store) Register(ctx context.Context, name string, password string) (*User, error) {
+	if !namePattern.MatchString(name) {
+		return nil, ErrInvalidName
+	}
+
+	length := utf8.RuneCountInString(password)
+	if length < minPasswordLength || length > maxPasswordLength {
+		return nil, ErrWeakPassword
+	}
+
+	return s.insert(ctx, name, sql.NullString{String: hashPassword(password), Valid: true})
+}
+
+// Authenticate checks the password of a local account.
+func (s *Store) Authenticate(ctx context.Context, name string, password string) (*User, error) {
+	if len(password) > maxPasswordLength*utf8.UTFMax {
+		return nil, ErrInvalidCredentials
+	}
+
+	var user User
+	var hash sql.NullString
+	var created int64
+
+	err := s.db.QueryRowContext(ctx,
+		"SELECT id, name, password_hash, created_at FROM users WHERE name = ?", name,
+	).Scan(&user.Id, &user.Name, &hash, &created)
+	if errors.Is(err, sql.ErrNoRows) {
+		verifyPassword(password, s.dummyHash)
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, fmt.Errorf("users: %w", err)
+	}
+
+	// accounts of the reverse proxy have no password
+	if !hash.Valid {
+		verifyPassword(password, s.dummyHash)
+		return nil, ErrInvalidCredentials
+	}
+
+	ok, err := verifyPassword(password, hash.String)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrInvalidCredentials
+	}
+
+	user.Created = time.Unix(created, 0)
+
+	return &user, nil
+}
+
+// Ensure returns the account called name, creating one without password for
+// names the reverse proxy vouches for.
+func (s *Store) Ensure(ctx context.Context, name string) (*User, error) {
+	if !validProxyName(name) {
+		return nil, ErrInvalidName
+	}
+
+	user, err := s.byName(ctx, name)
+	if !errors.Is(err, sql.ErrNoRows) {
+		return user, err
+	}
+
+	user, err = s.insert(ctx, name, sql.NullString{})
+	if errors.Is(err, ErrNameTaken) {
+		// created by a concurrent request
+		return s.byName(ctx, name)
+	}
+
+	return user, err
+}
+
+func (s *Store) byName(ctx context.Context, name string) (*User, error) {
+	var user User
+	var created int64
+
+	err := s.db.QueryRowContext(ctx,
+		"SELECT id, name, created_at FROM users WHERE name = ?", name,
+	).Scan(&user.Id, &user.Name, &created)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("users: %w", err)
+	}
+
+	user.Created = time.Unix(created, 0)
+
+	return &user, nil
+}
+
+func (s *Store) insert(ctx context.Context, name string, hash sql.NullString) (*User, error) {
+	user := User{Name: name, Created: time.Now()}
+
+	err := s.db.QueryRowContext(ctx,
+		"INSERT INTO users (name, password_hash, created_at) VALUES (?, ?, ?) RETURNING id",
+		name, hash, user.Created.Unix(),
+	).Scan(&user.Id)
+	if err != nil && strings.Contains(err.Error(), "UNIQUE constraint failed") {
+		return nil, ErrNameTaken
+	}
+	if err != nil {
+		return nil, fmt.Errorf("users: %w", err)
+	}
+
+	return &user, nil
+}
+
+// validProxyName accepts what SSO gateways usually send, like user names or
+// email addresses.
+func validProxyName(name string) bool {
+	if name == "" || len(name) > 254 || !utf8.ValidString(name) {
+		return false
+	}
+
+	for _, r := range name {
+		if !unicode.IsPrint(r) || unicode.IsSpace(r) {
+			return false
+		}
+	}
+
+	return true
+}
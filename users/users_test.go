@@ -0,0 +1,184 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/m4tthewde/blunt/database"
+)
+
+func testStore(t *testing.T) *Store {
+	t.Helper()
+
+	db, err := database.Open(context.Background(), filepath.Join(t.TempDir(), "blunt.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return NewStore(db)
+}
+
+func TestPassword(t *testing.T) {
+	hash := hashPassword("correct horse battery staple")
+
+	if hash == hashPassword("correct horse battery staple") {
+		t.Error("hashes of the same password share a salt")
+	}
+
+	ok, err := verifyPassword("correct horse battery staple", hash)
+	if err != nil || !ok {
+		t.Errorf("right password: %v, %v", ok, err)
+	}
+
+	ok, err = verifyPassword("correct horse battery stapler", hash)
+	if err != nil || ok {
+		t.Errorf("wrong password: %v, %v", ok, err)
+	}
+
+	for _, malformed := range []string{"", "$argon2i$v=19$m=1,t=1,p=1$c2FsdA$a2V5", strings.Replace(hash, "v=19", "v=16", 1)} {
+		_, err = verifyPassword("correct horse battery staple", malformed)
+		if !errors.Is(err, errMalformedHash) {
+			t.Errorf("%q: error %v", malformed, err)
+		}
+	}
+}
+
+func TestRegister(t *testing.T) {
+	store := testStore(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		password string
+		err      error
+	}{
+		{"alice", "long enough password", nil},
+		{"alice", "another long password", ErrNameTaken},
+		{"al", "long enough password", ErrInvalidName},
+		{"alice smith", "long enough password", ErrInvalidName},
+		{"bob", "short", ErrWeakPassword},
+		{"bob", strings.Repeat("x", maxPasswordLength+1), ErrWeakPassword},
+	}
+
+	for _, test := range tests {
+		user, err := store.Register(ctx, test.name, test.password)
+		if !errors.Is(err, test.err) {
+			t.Errorf("%q with %d character password: error %v, want %v", test.name, len(test.password), err, test.err)
+			continue
+		}
+
+		if err == nil && (user.Id == 0 || user.Name != test.name) {
+			t.Errorf("%q: registered %+v", test.name, user)
+		}
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	store := testStore(t)
+	ctx := context.Background()
+
+	registered, err := store.Register(ctx, "alice", "long enough password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = store.Ensure(ctx, "sso@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user, err := store.Authenticate(ctx, "alice", "long enough password")
+	if err != nil || user.Id != registered.Id {
+		t.Errorf("right password: %+v, %v", user, err)
+	}
+
+	tests := []struct {
+		name     string
+		password string
+	}{
+		{"alice", "wrong password"},
+		{"mallory", "long enough password"},
+		// proxy accounts have no password to guess
+		{"sso@example.com", ""},
+		{"alice", strings.Repeat("x", 4*maxPasswordLength+1)},
+	}
+
+	for _, test := range tests {
+		_, err := store.Authenticate(ctx, test.name, test.password)
+		if !errors.Is(err, ErrInvalidCredentials) {
+			t.Errorf("%q: error %v, want invalid credentials", test.name, err)
+		}
+	}
+}
+
+func TestEnsure(t *testing.T) {
+	store := testStore(t)
+	ctx := context.Background()
+
+	first, err := store.Ensure(ctx, "sso@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := store.Ensure(ctx, "sso@example.com")
+	if err != nil || second.Id != first.Id {
+		t.Errorf("second request: %+v, %v, want account %d", second, err, first.Id)
+	}
+
+	for _, name := range []string{"", "two words", "tab\there", strings.Repeat("x", 255)} {
+		_, err := store.Ensure(ctx, name)
+		if !errors.Is(err, ErrInvalidName) {
+			t.Errorf("%q: error %v, want invalid name", name, err)
+		}
+	}
+}
+
+func TestSessions(t *testing.T) {
+	store := testStore(t)
+	ctx := context.Background()
+
+	user, err := store.Register(ctx, "alice", "long enough password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := store.CreateSession(ctx, user, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := store.SessionUser(ctx, token)
+	if err != nil || got.Id != user.Id {
+		t.Errorf("session user %+v, %v", got, err)
+	}
+
+	_, err = store.SessionUser(ctx, token+"x")
+	if !errors.Is(err, ErrNoSession) {
+		t.Errorf("unknown token: error %v", err)
+	}
+
+	err = store.DeleteSession(ctx, token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = store.SessionUser(ctx, token)
+	if !errors.Is(err, ErrNoSession) {
+		t.Errorf("deleted session: error %v", err)
+	}
+
+	expired, err := store.CreateSession(ctx, user, -time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = store.SessionUser(ctx, expired)
+	if !errors.Is(err, ErrNoSession) {
+		t.Errorf("expired session: error %v", err)
+	}
+}
@@ -0,0 +1,167 @@
+// Package watch remembers which streaming and rental services users have
+// and finds the movies available on them.
+package watch
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/m4tthewde/blunt/tmdb"
+)
+
+const (
+	// MaxMovies bounds the TMDB lookups of a single filter.
+	MaxMovies = 50
+	// concurrency of the availability lookups, TMDB's rate limit applies on
+	// top.
+	concurrency = 8
+)
+
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db}
+}
+
+// Services returns the provider ids of the user's services.
+func (s *Store) Services(ctx context.Context, userId int64) (map[int64]bool, error) {
+	rows, err := s.db.QueryContext(ctx, "SELECT provider_id FROM watch_services WHERE user_id = ?", userId)
+	if err != nil {
+		return nil, fmt.Errorf("watch: %w", err)
+	}
+	defer rows.Close()
+
+	services := make(map[int64]bool)
+
+	for rows.Next() {
+		var providerId int64
+
+		err := rows.Scan(&providerId)
+		if err != nil {
+			return nil, fmt.Errorf("watch: %w", err)
+		}
+
+		services[providerId] = true
+	}
+
+	return services, rows.Err()
+}
+
+// SetServices replaces the user's services.
+func (s *Store) SetServices(ctx context.Context, userId int64, providerIds []int64) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("watch: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM watch_services WHERE user_id = ?", userId)
+	if err != nil {
+		return fmt.Errorf("watch: %w", err)
+	}
+
+	for _, providerId := range providerIds {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO watch_services (user_id, provider_id) VALUES (?, ?)
+			ON CONFLICT (user_id, provider_id) DO NOTHING`,
+			userId, providerId,
+		)
+		if err != nil {
+			return fmt.Errorf("watch: %w", err)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("watch: %w", err)
+	}
+
+	return nil
+}
+
+// Filter keeps the items one of the services offers in region, and the
+// items movieId reports aren't movies. Only the first MaxMovies movies are
+// looked up, the ones past them are left out and counted as unchecked.
+func Filter[T any](ctx context.Context, client *tmdb.Client, items []T, movieId func(T) (int64, bool), region string, services map[int64]bool) (kept []T, checked int, unchecked int, err error) {
+	movieIds := make([]int64, 0)
+	for _, item := range items {
+		id, ok := movieId(item)
+		if ok {
+			movieIds = append(movieIds, id)
+		}
+	}
+
+	checked = min(len(movieIds), MaxMovies)
+
+	available, err := Available(ctx, client, movieIds[:checked], region, services)
+	if err != nil {
+		return nil, checked, 0, err
+	}
+
+	kept = make([]T, 0, len(items))
+	for _, item := range items {
+		id, ok := movieId(item)
+		if !ok || available[id] {
+			kept = append(kept, item)
+		}
+	}
+
+	return kept, checked, len(movieIds) - checked, nil
+}
+
+// Available returns which of the movies one of the services offers in
+// region. Movies TMDB doesn't know aren't available. Every movie is a TMDB
+// lookup, callers bound them by MaxMovies.
+func Available(ctx context.Context, client *tmdb.Client, movieIds []int64, region string, services map[int64]bool) (map[int64]bool, error) {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var firstErr error
+
+	available := make(map[int64]bool)
+	slots := make(chan struct{}, concurrency)
+
+	for _, id := range movieIds {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			providers, err := client.WatchProviders(ctx, strconv.FormatInt(id, 10))
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if errors.Is(err, tmdb.ErrNotFound) {
+				return
+			}
+
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				return
+			}
+
+			if providers.Results[region].Offers(services) {
+				available[id] = true
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	return available, nil
+}
@@ -0,0 +1,168 @@
+package watch
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/m4tthewde/blunt/tmdb"
+)
+
+const (
+	streaming = 8
+	rental    = 9
+)
+
+// fakeProviders streams even movies in DE and rents odd ones there and in
+// the US. Movie 404 is unknown and movie 500 fails.
+func fakeProviders(t *testing.T, lookups *atomic.Int32) *tmdb.Client {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/movie/"), "/watch/providers"))
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		lookups.Add(1)
+
+		switch id {
+		case 404:
+			http.NotFound(w, r)
+			return
+		case 500:
+			http.Error(w, "failed", http.StatusInternalServerError)
+			return
+		}
+
+		results := map[string]tmdb.RegionProviders{}
+		if id%2 == 0 {
+			results["DE"] = tmdb.RegionProviders{Flatrate: []tmdb.Provider{{ProviderId: streaming}}}
+		} else {
+			results["DE"] = tmdb.RegionProviders{Rent: []tmdb.Provider{{ProviderId: rental}}}
+			results["US"] = tmdb.RegionProviders{Rent: []tmdb.Provider{{ProviderId: rental}}}
+		}
+
+		json.NewEncoder(w).Encode(tmdb.WatchProvidersResponse{Id: int64(id), Results: results})
+	}))
+	t.Cleanup(server.Close)
+
+	return tmdb.NewClient(tmdb.Options{BaseUrl: server.URL})
+}
+
+func TestAvailable(t *testing.T) {
+	tests := []struct {
+		name     string
+		region   string
+		services []int64
+		want     []int64
+	}{
+		{"streaming", "DE", []int64{streaming}, []int64{2, 4}},
+		{"rental", "DE", []int64{rental}, []int64{1, 3}},
+		{"both", "DE", []int64{streaming, rental}, []int64{1, 2, 3, 4}},
+		{"other region", "US", []int64{streaming}, []int64{}},
+		{"region without offers", "FR", []int64{streaming, rental}, []int64{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var lookups atomic.Int32
+			client := fakeProviders(t, &lookups)
+
+			services := make(map[int64]bool)
+			for _, id := range test.services {
+				services[id] = true
+			}
+
+			available, err := Available(context.Background(), client, []int64{1, 2, 3, 4, 404}, test.region, services)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := slices.Sorted(maps.Keys(available))
+			if !slices.Equal(got, test.want) {
+				t.Errorf("available %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestAvailableError(t *testing.T) {
+	var lookups atomic.Int32
+	client := fakeProviders(t, &lookups)
+
+	_, err := Available(context.Background(), client, []int64{1, 500, 2}, "DE", map[int64]bool{streaming: true})
+	if err == nil {
+		t.Error("the failed lookup was ignored")
+	}
+}
+
+type item struct {
+	id    int64
+	movie bool
+}
+
+func itemMovie(i item) (int64, bool) {
+	return i.id, i.movie
+}
+
+func TestFilter(t *testing.T) {
+	var lookups atomic.Int32
+	client := fakeProviders(t, &lookups)
+
+	items := []item{{1, false}, {2, true}, {3, true}, {4, true}, {5, false}}
+
+	kept, checked, unchecked, err := Filter(context.Background(), client, items, itemMovie, "DE", map[int64]bool{streaming: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// people and the like are kept
+	want := []item{{1, false}, {2, true}, {4, true}, {5, false}}
+	if !slices.Equal(kept, want) {
+		t.Errorf("kept %v, want %v", kept, want)
+	}
+
+	if checked != 3 || unchecked != 0 || lookups.Load() != 3 {
+		t.Errorf("checked %d, unchecked %d, looked up %d", checked, unchecked, lookups.Load())
+	}
+}
+
+func TestFilterMaxMovies(t *testing.T) {
+	var lookups atomic.Int32
+	client := fakeProviders(t, &lookups)
+
+	items := make([]item, 0)
+	for id := range MaxMovies + 10 {
+		items = append(items, item{int64(id + 1), true})
+	}
+	items = append(items, item{1000, false})
+
+	kept, checked, unchecked, err := Filter(context.Background(), client, items, itemMovie, "DE", map[int64]bool{streaming: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if checked != MaxMovies || unchecked != 10 || int(lookups.Load()) != MaxMovies {
+		t.Errorf("checked %d, unchecked %d, looked up %d", checked, unchecked, lookups.Load())
+	}
+
+	// the even movies up to MaxMovies, the ones past it are left out
+	if len(kept) != MaxMovies/2+1 {
+		t.Errorf("kept %d items, want %d", len(kept), MaxMovies/2+1)
+	}
+
+	for _, i := range kept {
+		if i.movie && (i.id%2 != 0 || i.id > MaxMovies) {
+			t.Errorf("kept movie %d", i.id)
+		}
+	}
+}